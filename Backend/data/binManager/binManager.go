@@ -5,6 +5,7 @@ package binManager
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,11 +15,24 @@ import (
 
 // Constante de endereco de arquivo bases
 const (
-	FILES_PATH string = "data/files/"
-	CSV_PATH   string = "data/files/database/pokedex.csv"
-	BIN_FILE   string = "data/files/database/pokedex.bin"
+	FILES_PATH   string = "data/files/"
+	CSV_PATH     string = "data/files/database/pokedex.csv"
+	BIN_FILE     string = "data/files/database/pokedex.bin"
+	VERSION_FILE string = "data/files/database/pokedex.version"
 )
 
+// FORMAT_VERSION é a versao do formato dos registros (ver models.Pokemon.ToBytes)
+// gravada em VERSION_FILE por CsvToBin. Cada versao acrescenta campos à anterior:
+//
+//	1: formato original, sem arquivo de versao
+//	2: Romaji, depois de NomeJap
+//	3: Nomes (nome em cada lingua de models.Languages), depois de Romaji
+//	4: DanoRecebido (multiplicador de cada tipo de ataque), depois de Peso
+const FORMAT_VERSION int32 = 4
+
+// ErrOutdatedFormat indica que a database foi gravada com outro formato de registro
+var ErrOutdatedFormat = errors.New("database gravada em um formato de registro antigo, execute /loadDatabase para recria-la")
+
 // ControleLeitura implementa um objeto para leitura automatizada
 // da base de dados binaria
 type ControleLeitura struct {
@@ -68,6 +82,21 @@ func InicializarControleLeitura(path string) (*ControleLeitura, error) {
 	return controle, nil
 }
 
+// CheckFormat confere se a database foi gravada com o formato de registro
+// atual, retornando ErrOutdatedFormat caso contrario. Registros de outro
+// formato seriam lidos com os campos deslocados, entao a database precisa ser
+// recriada a partir do CSV
+func CheckFormat() error {
+	buffer, err := os.ReadFile(VERSION_FILE)
+	if err != nil || len(buffer) < 4 {
+		return ErrOutdatedFormat
+	}
+	if int32(binary.LittleEndian.Uint32(buffer)) != FORMAT_VERSION {
+		return ErrOutdatedFormat
+	}
+	return nil
+}
+
 // Reset realiza a reinicializacao de leitura do arquivo
 func (c *ControleLeitura) Reset() {
 	c.Arquivo.Seek(4, io.SeekStart)
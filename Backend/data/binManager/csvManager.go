@@ -75,6 +75,9 @@ func (csv *CSV) CsvToBin() {
 		bytes := arrayPokemons[i].ToBytes()
 		writeBytes(file, bytes)
 	}
+
+	// Grava a versao do formato dos registros (ver CheckFormat)
+	os.WriteFile(VERSION_FILE, utils.IntToBytes(FORMAT_VERSION), 0666)
 }
 
 // removeDuplicates remove Pokémons duplicados de uma slice dada com base no campo especificado.
@@ -12,6 +12,11 @@
 // verificar a integridade da árvore e visualizar a árvore em uma representação
 // gráfica.
 //
+// Cada arvore possui um modo de indexacao gravado em seu header: arvores
+// unicas (UNIQUE) rejeitam a insercao de valores repetidos, enquanto arvores
+// nao unicas (NON_UNIQUE) guardam cada valor uma unica vez e agrupam todos os
+// ponteiros daquele valor em uma lista no arquivo de postings.
+//
 // Este pacote não fornece suporte a transações ou persistência, embora esses
 // recursos possam ser adicionados se necessário.
 //
@@ -26,6 +31,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 
@@ -35,14 +41,32 @@ import (
 
 // Path dos arquivos necessarios
 const (
-	PATH   string = "bplustree/"
-	NODES  string = "BPlusTreeNodes.bin"
-	HEADER string = "BPlusTree.bin"
+	PATH     string = "bplustree/"
+	NODES    string = "BPlusTreeNodes.bin"
+	HEADER   string = "BPlusTree.bin"
+	POSTINGS string = "BPlusTreePostings.bin"
 )
 
 // NULL padrao
 const NULL int64 = -1
 
+// Modos de indexacao da arvore
+const (
+	// Cada valor pode aparecer apenas uma vez
+	UNIQUE int64 = 0
+
+	// Valores repetidos sao agrupados em uma lista de ponteiros
+	NON_UNIQUE int64 = 1
+)
+
+// ErrDuplicateKey é retornado ao inserir um valor ja existente em uma
+// arvore unica
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrOutdatedHeader é retornado ao ler o header de uma arvore criada antes
+// dos modos de indexacao, que precisa ser reconstruida
+var ErrOutdatedHeader = errors.New("outdated B+ tree header, rebuild the indexes")
+
 // Bit-Flags used for removing an element from the B+ Tree
 const (
 	// Value removed without any complications
@@ -86,11 +110,14 @@ type BPlusTreeNode struct {
 
 // BPlusTree é.. a Árvore B+
 type BPlusTree struct {
-	file       string
-	nodesFile  *os.File
-	root       int64
-	order      int
-	emptyNodes []int64
+	file          string
+	nodesFile     *os.File
+	postingsFile  *os.File
+	root          int64
+	order         int
+	mode          int64
+	emptyNodes    []int64
+	emptyPostings []int64
 }
 
 // Interface para leitura da database
@@ -308,25 +335,33 @@ func (n *BPlusTreeNode) getStatus() int {
 // ====================================== B+ Tree ====================================== //
 
 // NewBPlusTree inicializa uma arvore vazia, recebendo o endereco do
-// arquivo a ser gravado, a ordem da arvore e o campo que vai ser
-// guardado
-func NewBPlusTree(order int, path string, field string) (*BPlusTree, error) {
+// arquivo a ser gravado, a ordem da arvore, o campo que vai ser
+// guardado e o modo de indexacao (UNIQUE ou NON_UNIQUE)
+func NewBPlusTree(order int, path string, field string, mode int64) (*BPlusTree, error) {
 	if order < 3 {
 		return nil, errors.New("invalid order")
 	}
+	if mode != UNIQUE && mode != NON_UNIQUE {
+		return nil, errors.New("invalid mode")
+	}
 
 	tree_path := filepath.Join(path, PATH)
 	tree_nodes := filepath.Join(tree_path, field+"_"+NODES)
 	tree_header := filepath.Join(tree_path, field+"_"+HEADER)
+	tree_postings := filepath.Join(tree_path, field+"_"+POSTINGS)
 	os.MkdirAll(tree_path, 0755)
 	nodesFile, _ := os.Create(tree_nodes)
+	postingsFile, _ := os.Create(tree_postings)
 	root := newNode(order, 1, NULL)
 	tree := &BPlusTree{
-		root:       0,
-		order:      order,
-		file:       tree_header,
-		nodesFile:  nodesFile,
-		emptyNodes: make([]int64, 0),
+		root:          0,
+		order:         order,
+		mode:          mode,
+		file:          tree_header,
+		nodesFile:     nodesFile,
+		postingsFile:  postingsFile,
+		emptyNodes:    make([]int64, 0),
+		emptyPostings: make([]int64, 0),
 	}
 
 	root.write(nodesFile)
@@ -336,38 +371,66 @@ func NewBPlusTree(order int, path string, field string) (*BPlusTree, error) {
 
 // ReadBPlusTree lê uma arvore de um arquivo header, extraindo
 // a ordem da arvore, o endereco da raiz, a quantidade de nós
-// vazios e os endereços dos nós vazios
+// vazios, os endereços dos nós vazios, o modo de indexacao e
+// os endereços dos blocos de postings vazios
+//
+// Headers antigos, sem modo gravado, geram ErrOutdatedHeader: nesses
+// arquivos os valores repetidos estao espalhados pela arvore, sem lista de
+// postings, e nenhum dos modos os le corretamente
 func ReadBPlusTree(dir string, field string) (*BPlusTree, error) {
 	tree_path := filepath.Join(dir, PATH)
 	tree_nodes := filepath.Join(tree_path, field+"_"+NODES)
 	tree_header := filepath.Join(tree_path, field+"_"+HEADER)
+	tree_postings := filepath.Join(tree_path, field+"_"+POSTINGS)
 
 	file, err := os.ReadFile(tree_header)
 	if err != nil {
 		return nil, err
 	}
 
-	nodesFile, _ := os.OpenFile(tree_nodes, os.O_RDWR|os.O_CREATE, 0644)
 	root, ptr := utils.BytesToInt64(file, 0)
 	order, ptr := utils.BytesToInt64(file, ptr)
-	len, ptr := utils.BytesToInt64(file, ptr)
-	emptyNodes := make([]int64, len)
+	emptyLen, ptr := utils.BytesToInt64(file, ptr)
+	emptyNodes := make([]int64, emptyLen)
 
-	for i := int64(0); i < len; i++ {
+	for i := int64(0); i < emptyLen; i++ {
 		emptyNodes[i], ptr = utils.BytesToInt64(file, ptr)
 	}
 
+	if ptr >= len(file) {
+		return nil, fmt.Errorf("%w: %s", ErrOutdatedHeader, field)
+	}
+	mode, ptr := utils.BytesToInt64(file, ptr)
+	emptyLen, ptr = utils.BytesToInt64(file, ptr)
+	emptyPostings := make([]int64, emptyLen)
+
+	for i := int64(0); i < emptyLen; i++ {
+		emptyPostings[i], ptr = utils.BytesToInt64(file, ptr)
+	}
+
+	nodesFile, _ := os.OpenFile(tree_nodes, os.O_RDWR|os.O_CREATE, 0644)
+	postingsFile, _ := os.OpenFile(tree_postings, os.O_RDWR|os.O_CREATE, 0644)
+
 	return &BPlusTree{
-		root:       root,
-		order:      int(order),
-		file:       tree_header,
-		nodesFile:  nodesFile,
-		emptyNodes: emptyNodes,
+		root:          root,
+		order:         int(order),
+		mode:          mode,
+		file:          tree_header,
+		nodesFile:     nodesFile,
+		postingsFile:  postingsFile,
+		emptyNodes:    emptyNodes,
+		emptyPostings: emptyPostings,
 	}, nil
 }
 
+// Mode retorna o modo de indexacao da arvore (UNIQUE ou NON_UNIQUE)
+func (b *BPlusTree) Mode() int64 {
+	return b.mode
+}
+
 // Close fecha o arquivo da árvore, salvando o endereço da raiz,
-// a ordem, quantidade de nós vazios e os endereços dos nós vazios
+// a ordem, quantidade de nós vazios, os endereços dos nós vazios,
+// o modo de indexacao e os blocos de postings vazios
 // (Esta função deve ser chamada para salvar qualquer alteração feita
 // na base de dados. Nao cumprimento disso poderá ocasionar em dados
 // corrompidos)
@@ -382,8 +445,16 @@ func (b *BPlusTree) Close() {
 		binary.Write(file, binary.LittleEndian, b.emptyNodes[i])
 	}
 
+	binary.Write(file, binary.LittleEndian, b.mode)
+	binary.Write(file, binary.LittleEndian, int64(len(b.emptyPostings)))
+
+	for i := 0; i < len(b.emptyPostings); i++ {
+		binary.Write(file, binary.LittleEndian, b.emptyPostings[i])
+	}
+
 	file.Close()
 	b.nodesFile.Close()
+	b.postingsFile.Close()
 }
 
 // popEmptyNode busca um endereço de um nó vazio, o remove
@@ -474,8 +545,24 @@ func (b *BPlusTree) insert(node *BPlusTreeNode, data *Key) (int64, *Key, int64)
 }
 
 // Insert insere uma chave na árvore e atualiza o arquivo
-// com os nós
-func (b *BPlusTree) Insert(data *Key) {
+// com os nós.
+//
+// Em arvores unicas um valor ja existente gera ErrDuplicateKey.
+// Em arvores nao unicas um valor ja existente apenas recebe o
+// novo ponteiro em sua lista.
+func (b *BPlusTree) Insert(data *Key) error {
+	if k := b.find(data.Id); k != nil {
+		if b.mode == UNIQUE {
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, data.Id)
+		}
+		b.appendPosting(k.Ptr, data.Ptr)
+		return nil
+	}
+
+	if b.mode == NON_UNIQUE {
+		data = &Key{data.Id, b.newPosting(data.Ptr)}
+	}
+
 	l, m, r := b.insert(b.readNode(b.root), data)
 
 	if m != nil {
@@ -486,6 +573,8 @@ func (b *BPlusTree) Insert(data *Key) {
 		root.write(b.nodesFile)
 		b.root = root.address
 	}
+
+	return nil
 }
 
 // printFile abre o arquivo com os nós e printa todos eles
@@ -811,7 +900,36 @@ func (b *BPlusTree) remove(address int64, old *Key) (*Key, *Key, int, int64) {
 // Remove remove um elemento da árvore, pesquisando
 // recursivamente pelo elemento e por fim retornando-o,
 // ou nil, caso não encontrado
+//
+// Em arvores nao unicas apenas o ponteiro é removido da lista
+// do valor, e a chave só sai da arvore quando a lista esvazia
 func (b *BPlusTree) Remove(old *Key) *Key {
+	if b.mode == UNIQUE {
+		return b.removeKey(old)
+	}
+
+	k := b.find(old.Id)
+	if k == nil {
+		return nil
+	}
+
+	head := k.Ptr
+	found, empty := b.removePosting(head, old.Ptr)
+	if !found {
+		return nil
+	}
+
+	if empty {
+		b.freePosting(head)
+		b.removeKey(&Key{old.Id, head})
+	}
+
+	return old
+}
+
+// removeKey remove uma chave exata (valor e ponteiro) da árvore,
+// ajustando os nós e a raiz quando necessario
+func (b *BPlusTree) removeKey(old *Key) *Key {
 	k, kk, flag, _ := b.remove(b.root, old)
 	root := b.readNode(b.root)
 
@@ -837,6 +955,10 @@ func (n *BPlusTreeNode) find2(id float64) (*Key, int64) {
 	address := NULL
 	i := n.numberOfKeys - 1
 
+	if i < 0 {
+		return nil, NULL
+	}
+
 	for i > 0 && n.keys[i].Id > id {
 		i--
 	}
@@ -852,9 +974,27 @@ func (n *BPlusTreeNode) find2(id float64) (*Key, int64) {
 	return k, address
 }
 
-// Find pesquisa por um elemento presente na árvore
-// e o retorna se encontrado, ou nil, caso contrário
-func (b *BPlusTree) Find(id float64) *Key {
+// Find pesquisa por um valor presente na árvore e retorna todas
+// as chaves associadas a ele (uma unica chave em arvores unicas),
+// ou nil, caso não encontrado
+func (b *BPlusTree) Find(id float64) []Key {
+	k := b.find(id)
+	if k == nil {
+		return nil
+	}
+
+	ptrs := b.pointers(k)
+	keys := make([]Key, len(ptrs))
+	for i, ptr := range ptrs {
+		keys[i] = Key{id, ptr}
+	}
+
+	return keys
+}
+
+// find pesquisa por um valor presente na árvore e retorna a
+// chave gravada nos nós, ou nil, caso contrário
+func (b *BPlusTree) find(id float64) *Key {
 	var k *Key
 	var address int64
 	node := b.readNode(b.root)
@@ -892,10 +1032,28 @@ func (b *BPlusTree) findNode(id float64) *BPlusTreeNode {
 	return node
 }
 
-// FindRange pesquisa na árvore por todos os valores contidos em um
-// intervalo [a, b) e os retorna
+// FindRange pesquisa na árvore por todos os valores contidos no intervalo
+// inclusivo [start, end] e os retorna. Qualquer intervalo é aceito, inclusive
+// abaixo de 1 (campos booleanos valem 0 ou 1 e multiplicadores podem ser 0),
+// e um intervalo sem valores retorna uma lista vazia
 func (b *BPlusTree) FindRange(start float64, end float64) ([]int64, error) {
-	if start > end || end < 1 {
+	keys, err := b.FindRangeKeys(start, end)
+	if keys == nil {
+		return nil, err
+	}
+
+	addresses := make([]int64, len(keys))
+	for i, k := range keys {
+		addresses[i] = k.Ptr
+	}
+
+	return addresses, err
+}
+
+// FindRangeKeys funciona como FindRange, mas retorna cada ponteiro junto do
+// seu valor, em ordem crescente de valor
+func (b *BPlusTree) FindRangeKeys(start float64, end float64) ([]Key, error) {
+	if start > end {
 		return nil, nil
 	}
 
@@ -906,11 +1064,14 @@ func (b *BPlusTree) FindRange(start float64, end float64) ([]int64, error) {
 		index++
 	}
 
+	// Todas as chaves do no sao menores que start: o intervalo, se existir,
+	// comeca na proxima folha
 	if index == node.numberOfKeys {
-		return nil, errors.New("not found")
+		node = b.readNode(node.next)
+		index = 0
 	}
 
-	addresses := make([]int64, 0)
+	keys := make([]Key, 0)
 
 	for start <= end && node != nil {
 
@@ -918,7 +1079,9 @@ func (b *BPlusTree) FindRange(start float64, end float64) ([]int64, error) {
 		if start > end {
 			break
 		}
-		addresses = append(addresses, node.keys[index].Ptr)
+		for _, ptr := range b.pointers(&node.keys[index]) {
+			keys = append(keys, Key{start, ptr})
+		}
 
 		if index == node.numberOfKeys-1 {
 			node = b.readNode(node.next)
@@ -928,38 +1091,112 @@ func (b *BPlusTree) FindRange(start float64, end float64) ([]int64, error) {
 		index++
 	}
 
-	return addresses, nil
+	return keys, nil
+}
+
+// Bounds retorna a menor e a maior chave presentes na árvore
+func (b *BPlusTree) Bounds() (min float64, max float64, err error) {
+	first := b.findNode(-math.MaxFloat64)
+	if first.numberOfKeys == 0 {
+		return 0, 0, errors.New("empty tree")
+	}
+
+	// A maior chave esta na folha mais a direita
+	node := b.readNode(b.root)
+	for node.leaf == 0 {
+		node = b.readNode(node.child[node.numberOfKeys])
+	}
+
+	return first.keys[0].Id, node.max().Id, nil
+}
+
+// CheckUnique verifica, antes de uma insercao, se o objeto violaria a
+// restricao de alguma das arvores unicas fornecidas, retornando
+// ErrDuplicateKey nesse caso. Arvores nao unicas sao ignoradas
+func CheckUnique(obj IndexableObject, path string, fields []string) error {
+	for _, field := range fields {
+		tree, err := ReadBPlusTree(path, field)
+		if err != nil {
+			return err
+		}
+
+		id, _ := obj.GetFieldF64(field)
+		exists := tree.mode == UNIQUE && tree.find(id) != nil
+		tree.Close()
+
+		if exists {
+			return fmt.Errorf("%w: %s = %v", ErrDuplicateKey, field, id)
+		}
+	}
+
+	return nil
+}
+
+// CheckUniqueUpdate verifica, antes de uma atualizacao, se os novos valores
+// do objeto violariam a restricao de alguma arvore unica. Os campos cujo
+// valor nao mudou sao ignorados, ja que a chave encontrada seria a do
+// proprio objeto
+func CheckUniqueUpdate(old IndexableObject, new IndexableObject, path string, fields []string) error {
+	changed := make([]string, 0, len(fields))
+	for _, field := range fields {
+		oldId, _ := old.GetFieldF64(field)
+		newId, _ := new.GetFieldF64(field)
+		if oldId != newId {
+			changed = append(changed, field)
+		}
+	}
+
+	return CheckUnique(new, path, changed)
 }
 
 // Create insere um elemento na árvore
-func Create(pokemon models.Pokemon, pokeAddress int64, path string, fields []string) {
+func Create(pokemon models.Pokemon, pokeAddress int64, path string, fields []string) error {
 	for _, field := range fields {
-		tree, _ := ReadBPlusTree(path, field)
+		tree, err := ReadBPlusTree(path, field)
+		if err != nil {
+			return err
+		}
 		id, _ := pokemon.GetFieldF64(field)
 		k := Key{id, pokeAddress}
-		tree.Insert(&k)
+		err = tree.Insert(&k)
 		tree.Close()
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 // Update atualiza um elemento da árvore
-func Update(old models.Pokemon, new models.Pokemon, kAddress int64, kkAddress int64, path string, fields []string) {
+func Update(old models.Pokemon, new models.Pokemon, kAddress int64, kkAddress int64, path string, fields []string) error {
 	for _, field := range fields {
-		tree, _ := ReadBPlusTree(path, field)
+		tree, err := ReadBPlusTree(path, field)
+		if err != nil {
+			return err
+		}
 		kId, _ := old.GetFieldF64(field)
 		kkId, _ := new.GetFieldF64(field)
 		k := Key{kId, kAddress}
 		kk := Key{kkId, kkAddress}
 		tree.Remove(&k)
-		tree.Insert(&kk)
+		err = tree.Insert(&kk)
 		tree.Close()
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 // Delete remove um elemento da árvore
 func Delete(pokemon models.Pokemon, address int64, path string, fields []string) {
 	for _, field := range fields {
-		tree, _ := ReadBPlusTree(path, field)
+		tree, err := ReadBPlusTree(path, field)
+		if err != nil {
+			continue
+		}
 		id, _ := pokemon.GetFieldF64(field)
 		tree.Remove(&Key{id, address})
 
@@ -973,9 +1210,14 @@ func Delete(pokemon models.Pokemon, address int64, path string, fields []string)
 // contidos no arquivo informado na árvore, escrevendo-os em um novo arquivo
 // e escrevendo as informações gerais da arvore (como ordem, endereço da raíz,
 // etc) em outro arquivo
-func StartBPlusTreeFile(dir string, field string, controler Reader) error {
+//
+// O modo (UNIQUE ou NON_UNIQUE) define como valores repetidos sao tratados
+func StartBPlusTreeFile(dir string, field string, mode int64, controler Reader) error {
 	order := 8
-	tree, _ := NewBPlusTree(order, dir, field)
+	tree, err := NewBPlusTree(order, dir, field, mode)
+	if err != nil {
+		return err
+	}
 
 	for {
 		objInterface, isDead, _, err := controler.ReadNextGeneric()
@@ -991,7 +1233,10 @@ func StartBPlusTreeFile(dir string, field string, controler Reader) error {
 		if !isDead {
 			id, address := obj.GetFieldF64(field)
 			r := Key{id, address}
-			tree.Insert(&r)
+			if err := tree.Insert(&r); err != nil {
+				tree.Close()
+				return err
+			}
 		}
 	}
 
@@ -1003,9 +1248,14 @@ func StartBPlusTreeFile(dir string, field string, controler Reader) error {
 // contidos no arquivo informado na árvore, escrevendo-os em um novo arquivo
 // e escrevendo as informações gerais da arvore (como ordem, endereço da raíz,
 // etc) em outro arquivo
-func StartBPlusTreeFilesSearch(dir string, field string, controler Reader) error {
+//
+// O modo (UNIQUE ou NON_UNIQUE) define como valores repetidos sao tratados
+func StartBPlusTreeFilesSearch(dir string, field string, mode int64, controler Reader) error {
 	order := 8
-	tree, _ := NewBPlusTree(order, dir, field)
+	tree, err := NewBPlusTree(order, dir, field, mode)
+	if err != nil {
+		return err
+	}
 
 	for {
 		objInterface, isDead, address, err := controler.ReadNextGeneric()
@@ -1021,7 +1271,10 @@ func StartBPlusTreeFilesSearch(dir string, field string, controler Reader) error
 		if !isDead {
 			id, _ := obj.GetFieldF64(field)
 			r := Key{id, address}
-			tree.Insert(&r)
+			if err := tree.Insert(&r); err != nil {
+				tree.Close()
+				return err
+			}
 		}
 	}
 
@@ -0,0 +1,204 @@
+// O arquivo postingList do pacote bplustree implementa as listas de ponteiros
+// usadas pelas arvores nao unicas (NON_UNIQUE).
+//
+// Em uma arvore nao unica cada valor aparece uma unica vez nas folhas, e o Ptr
+// da chave aponta para o primeiro bloco de uma lista encadeada de blocos de
+// tamanho fixo no arquivo de postings, contendo todos os ponteiros associados
+// aquele valor. O endereco do primeiro bloco nunca muda enquanto a lista
+// existir, entao as copias da chave nos nós internos continuam validas.
+package bplustree
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// POSTING_SIZE é a quantidade de ponteiros armazenados em cada bloco
+const POSTING_SIZE int = 16
+
+// postingBlock representa um bloco da lista de ponteiros de uma chave
+type postingBlock struct {
+	address int64
+	next    int64
+	count   int64
+	ptrs    []int64
+}
+
+// newPostingBlock inicializa um bloco vazio, preenchendo os ponteiros com NULL
+func newPostingBlock(address int64) *postingBlock {
+	block := postingBlock{
+		address: address,
+		next:    NULL,
+		count:   0,
+		ptrs:    make([]int64, POSTING_SIZE),
+	}
+
+	for i := range block.ptrs {
+		block.ptrs[i] = NULL
+	}
+
+	return &block
+}
+
+// postingBlockSize calcula o tamanho de um bloco em bytes
+func postingBlockSize() int64 {
+	return int64(binary.Size(int64(0)) * (2 + POSTING_SIZE))
+}
+
+// write escreve o bloco no arquivo, diretamente no endereco do bloco,
+// se existente, caso contrario, escreve no final do arquivo
+func (p *postingBlock) write(file *os.File) {
+	if p.address == NULL {
+		p.address, _ = file.Seek(0, io.SeekEnd)
+	} else {
+		file.Seek(p.address, io.SeekStart)
+	}
+
+	binary.Write(file, binary.LittleEndian, p.next)
+	binary.Write(file, binary.LittleEndian, p.count)
+	binary.Write(file, binary.LittleEndian, p.ptrs)
+}
+
+// readPostingBlock lê um bloco do arquivo de postings dado seu endereço
+func (b *BPlusTree) readPostingBlock(address int64) *postingBlock {
+	if address == NULL {
+		return nil
+	}
+
+	b.postingsFile.Seek(address, io.SeekStart)
+	buf := make([]byte, postingBlockSize())
+	b.postingsFile.Read(buf)
+
+	block := newPostingBlock(address)
+	block.next, _ = utils.BytesToInt64(buf, 0)
+	block.count, _ = utils.BytesToInt64(buf, 8)
+	for i, ptr := 0, 16; i < POSTING_SIZE; i++ {
+		block.ptrs[i], ptr = utils.BytesToInt64(buf, ptr)
+	}
+
+	return block
+}
+
+// popEmptyPosting busca um endereço de um bloco vazio e o remove
+// da lista de blocos vazios, ou retorna NULL se nao houver nenhum
+func (b *BPlusTree) popEmptyPosting() int64 {
+	if len(b.emptyPostings) > 0 {
+		address := b.emptyPostings[0]
+		b.emptyPostings = b.emptyPostings[1:]
+		return address
+	}
+
+	return NULL
+}
+
+// newPosting cria uma nova lista contendo apenas o ponteiro fornecido
+// e retorna o endereço de seu primeiro bloco
+func (b *BPlusTree) newPosting(ptr int64) int64 {
+	block := newPostingBlock(b.popEmptyPosting())
+	block.ptrs[0] = ptr
+	block.count++
+	block.write(b.postingsFile)
+
+	return block.address
+}
+
+// appendPosting adiciona um ponteiro à lista iniciada em head, procurando
+// o primeiro bloco com espaço livre ou encadeando um novo bloco ao final
+func (b *BPlusTree) appendPosting(head int64, ptr int64) {
+	block := b.readPostingBlock(head)
+
+	for block.count == int64(POSTING_SIZE) && block.next != NULL {
+		block = b.readPostingBlock(block.next)
+	}
+
+	if block.count < int64(POSTING_SIZE) {
+		block.ptrs[block.count] = ptr
+		block.count++
+		block.write(b.postingsFile)
+		return
+	}
+
+	block.next = b.newPosting(ptr)
+	block.write(b.postingsFile)
+}
+
+// readPosting percorre a lista iniciada em head e retorna todos os ponteiros
+func (b *BPlusTree) readPosting(head int64) []int64 {
+	ptrs := make([]int64, 0)
+
+	for block := b.readPostingBlock(head); block != nil; block = b.readPostingBlock(block.next) {
+		ptrs = append(ptrs, block.ptrs[:block.count]...)
+	}
+
+	return ptrs
+}
+
+// removePosting remove um ponteiro da lista iniciada em head, retornando
+// se o ponteiro foi encontrado e se a lista ficou vazia.
+//
+// Blocos que ficam vazios sao desencadeados e guardados para reuso, com
+// exceção do primeiro, que recebe o conteudo do seu sucessor para manter
+// o endereço da lista inalterado.
+func (b *BPlusTree) removePosting(head int64, ptr int64) (found bool, empty bool) {
+	var prev *postingBlock
+	block := b.readPostingBlock(head)
+
+	for block != nil && !found {
+		for i := int64(0); i < block.count && !found; i++ {
+			if block.ptrs[i] == ptr {
+				found = true
+				block.ptrs[i] = block.ptrs[block.count-1]
+				block.ptrs[block.count-1] = NULL
+				block.count--
+			}
+		}
+
+		if !found {
+			prev = block
+			block = b.readPostingBlock(block.next)
+		}
+	}
+
+	if !found {
+		return
+	}
+
+	if block.count > 0 {
+		block.write(b.postingsFile)
+	} else if prev != nil {
+		prev.next = block.next
+		prev.write(b.postingsFile)
+		b.emptyPostings = append(b.emptyPostings, block.address)
+	} else if block.next != NULL {
+		next := b.readPostingBlock(block.next)
+		b.emptyPostings = append(b.emptyPostings, next.address)
+		next.address = block.address
+		next.write(b.postingsFile)
+	} else {
+		block.write(b.postingsFile)
+		empty = true
+	}
+
+	return
+}
+
+// freePosting devolve todos os blocos da lista iniciada em head
+// para a lista de blocos vazios
+func (b *BPlusTree) freePosting(head int64) {
+	for block := b.readPostingBlock(head); block != nil; block = b.readPostingBlock(block.next) {
+		b.emptyPostings = append(b.emptyPostings, block.address)
+	}
+}
+
+// pointers retorna os ponteiros representados por uma chave da arvore,
+// sendo o proprio Ptr em arvores unicas ou a lista completa em arvores
+// nao unicas
+func (b *BPlusTree) pointers(k *Key) []int64 {
+	if b.mode == UNIQUE {
+		return []int64{k.Ptr}
+	}
+	return b.readPosting(k.Ptr)
+}
@@ -0,0 +1,80 @@
+// O arquivo stats do pacote bplustree coleta estatisticas da arvore, usadas
+// para acompanhar seu crescimento e detectar arvores degeneradas sem depender
+// de PrintFile.
+package bplustree
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Stats resume o estado de uma arvore B+
+type Stats struct {
+	Files         map[string]int64 `json:"files"`         // Tamanho de cada arquivo em bytes
+	Mode          string           `json:"mode"`          // "unique" ou "non-unique"
+	Order         int              `json:"order"`         // Ordem da arvore
+	Height        int64            `json:"height"`        // Niveis da raiz ate as folhas
+	Nodes         int64            `json:"nodes"`         // Nós alcançaveis a partir da raiz
+	Leaves        int64            `json:"leaves"`        // Nós folha
+	Keys          int64            `json:"keys"`          // Chaves armazenadas nas folhas
+	Pointers      int64            `json:"pointers"`      // Ponteiros representados pelas chaves
+	FillFactor    float64          `json:"fillFactor"`    // Chaves / (Nodes * (Order - 1))
+	FreeNodes     int              `json:"freeNodes"`     // Nós na lista de reaproveitamento
+	PostingBlocks int64            `json:"postingBlocks"` // Blocos no arquivo de postings
+	FreePostings  int              `json:"freePostings"`  // Blocos de postings livres
+}
+
+// Stats percorre a arvore a partir da raiz, nivel por nivel,
+// coletando suas estatisticas
+func (b *BPlusTree) Stats() Stats {
+	stats := Stats{
+		Files: map[string]int64{
+			b.file:                utils.FileSize(b.file),
+			b.nodesFile.Name():    utils.FileSize(b.nodesFile.Name()),
+			b.postingsFile.Name(): utils.FileSize(b.postingsFile.Name()),
+		},
+		Mode:         "unique",
+		Order:        b.order,
+		FreeNodes:    len(b.emptyNodes),
+		FreePostings: len(b.emptyPostings),
+	}
+	if b.mode == NON_UNIQUE {
+		stats.Mode = "non-unique"
+	}
+	stats.PostingBlocks = stats.Files[b.postingsFile.Name()] / postingBlockSize()
+
+	// Chaves de nós internos sao copias das folhas, e contam apenas no preenchimento
+	var allKeys int64
+	level := []int64{b.root}
+	for len(level) > 0 {
+		stats.Height++
+		next := make([]int64, 0)
+
+		for _, address := range level {
+			node := b.readNode(address)
+			stats.Nodes++
+			allKeys += node.numberOfKeys
+
+			if node.leaf == 1 {
+				stats.Leaves++
+				stats.Keys += node.numberOfKeys
+				for i := int64(0); i < node.numberOfKeys; i++ {
+					stats.Pointers += int64(len(b.pointers(&node.keys[i])))
+				}
+				continue
+			}
+			for i := int64(0); i <= node.numberOfKeys; i++ {
+				if node.child[i] != NULL {
+					next = append(next, node.child[i])
+				}
+			}
+		}
+
+		level = next
+	}
+
+	if stats.Nodes > 0 {
+		stats.FillFactor = float64(allKeys) / float64(stats.Nodes*int64(b.order-1))
+	}
+
+	return stats
+}
@@ -352,10 +352,11 @@ func ReadBTree(dir string) (*BTree, error) {
 	}
 
 	return &BTree{
-		root:      root,
-		order:     int(order),
-		file:      tree_header,
-		nodesFile: nodesFile,
+		root:       root,
+		order:      int(order),
+		file:       tree_header,
+		nodesFile:  nodesFile,
+		emptyNodes: emptyNodes,
 	}, nil
 }
 
@@ -0,0 +1,63 @@
+// O arquivo stats do pacote btree coleta estatisticas da arvore, usadas para
+// acompanhar seu crescimento e detectar arvores degeneradas sem depender
+// de PrintFile.
+package btree
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Stats resume o estado de uma arvore B
+type Stats struct {
+	Files      map[string]int64 `json:"files"`      // Tamanho de cada arquivo em bytes
+	Order      int              `json:"order"`      // Ordem da arvore
+	Height     int64            `json:"height"`     // Niveis da raiz ate as folhas
+	Nodes      int64            `json:"nodes"`      // Nós alcançaveis a partir da raiz
+	Leaves     int64            `json:"leaves"`     // Nós folha
+	Keys       int64            `json:"keys"`       // Chaves armazenadas
+	FillFactor float64          `json:"fillFactor"` // Keys / (Nodes * (Order - 1))
+	FreeNodes  int              `json:"freeNodes"`  // Nós na lista de reaproveitamento
+}
+
+// Stats percorre a arvore a partir da raiz, nivel por nivel,
+// coletando suas estatisticas
+func (b *BTree) Stats() Stats {
+	stats := Stats{
+		Files: map[string]int64{
+			b.file:             utils.FileSize(b.file),
+			b.nodesFile.Name(): utils.FileSize(b.nodesFile.Name()),
+		},
+		Order:     b.order,
+		FreeNodes: len(b.emptyNodes),
+	}
+
+	level := []int64{b.root}
+	for len(level) > 0 {
+		stats.Height++
+		next := make([]int64, 0)
+
+		for _, address := range level {
+			node := b.readNode(address)
+			stats.Nodes++
+			stats.Keys += node.numberOfKeys
+
+			if node.leaf == 1 {
+				stats.Leaves++
+				continue
+			}
+			for i := int64(0); i <= node.numberOfKeys; i++ {
+				if node.child[i] != NULL {
+					next = append(next, node.child[i])
+				}
+			}
+		}
+
+		level = next
+	}
+
+	if stats.Nodes > 0 {
+		stats.FillFactor = float64(stats.Keys) / float64(stats.Nodes*int64(b.order-1))
+	}
+
+	return stats
+}
@@ -0,0 +1,424 @@
+// O arquivo fieldHash do pacote hashing permite declarar indices hash sobre
+// campos arbitrarios de um objeto, inclusive campos textuais.
+//
+// O valor do campo é transformado em uma chave numerica por uma funcao hash
+// plugavel (HashFunctions) e essa chave é inserida uma unica vez na hash
+// dinamica. O endereço guardado para a chave aponta para uma lista encadeada
+// de blocos de overflow contendo os endereços de todos os registros com aquele
+// valor, o que permite chaves repetidas.
+//
+// Como apenas a chave numerica é armazenada, valores diferentes podem colidir
+// em uma mesma lista. Quem consulta o indice deve conferir o campo dos
+// registros retornados.
+//
+// Exemplo de uso:
+//
+//	hashing.StartFieldHashFile(controler, 8, binManager.FILES_PATH, "nome", "fnv")
+//	fh, _ := hashing.LoadFieldHash(binManager.FILES_PATH, "nome")
+//	addresses := fh.Read("Pikachu")
+//	fh.Close()
+package hashing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Arquivos dos indices hash de campos
+const (
+	FIELDS_DIR    string = "hashFields"
+	OVERFLOW_FILE string = "Hash_Overflow.bin"
+)
+
+// OVERFLOW_SIZE é a quantidade de endereços guardados por bloco de overflow
+const OVERFLOW_SIZE int = 8
+
+// HashFunction transforma o valor textual de um campo em uma chave
+// numerica nao negativa para a hash dinamica
+type HashFunction func(value string) int64
+
+// HashFunctions é o conjunto de funcoes hash disponiveis para os indices
+// de campos, identificadas pelo nome gravado no arquivo do indice
+var HashFunctions = map[string]HashFunction{
+	"int":  IntHash,
+	"fnv":  FNVHash,
+	"djb2": DJB2Hash,
+}
+
+// FieldHash é um indice hash sobre um campo qualquer, com suporte a
+// chaves repetidas atraves de listas de overflow
+type FieldHash struct {
+	hash         DinamicHash // Hash dinamica de chaves numericas
+	overflowFile *os.File    // Arquivo com as listas de endereços
+	hashName     string      // Nome da funcao hash utilizada
+	hashFn       HashFunction
+	freeBlocks   int64 // Primeiro bloco livre para reaproveitamento
+}
+
+// overflowBlock é um bloco de uma lista de endereços de uma chave
+type overflowBlock struct {
+	address   int64
+	next      int64
+	count     int64
+	addresses []int64
+}
+
+// ================================== Funcoes Hash =================================== //
+
+// IntHash usa o proprio valor numerico do campo como chave, ideal para
+// campos inteiros
+func IntHash(value string) int64 {
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return utils.AbsInt64(n)
+}
+
+// FNVHash aplica a FNV-1a de 64 bits sobre o valor
+func FNVHash(value string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	return int64(h.Sum64() & math.MaxInt64)
+}
+
+// DJB2Hash aplica a funcao djb2 (h * 33 + c) sobre os bytes do valor
+func DJB2Hash(value string) int64 {
+	var h uint64 = 5381
+	for i := 0; i < len(value); i++ {
+		h = h*33 + uint64(value[i])
+	}
+	return int64(h & math.MaxInt64)
+}
+
+// ==================================== Overflow ===================================== //
+
+// overflowBlockSize calcula o tamanho em bytes de um bloco de overflow
+func overflowBlockSize() int64 {
+	return int64(binary.Size(int64(0)) * (2 + OVERFLOW_SIZE))
+}
+
+// newOverflowBlock inicializa um bloco vazio
+func newOverflowBlock(address int64) *overflowBlock {
+	return &overflowBlock{
+		address:   address,
+		next:      -1,
+		addresses: make([]int64, OVERFLOW_SIZE),
+	}
+}
+
+// write grava o bloco em seu endereço, ou ao final do arquivo se for novo
+func (o *overflowBlock) write(file *os.File) {
+	if o.address < 0 {
+		o.address, _ = file.Seek(0, io.SeekEnd)
+	} else {
+		file.Seek(o.address, io.SeekStart)
+	}
+
+	binary.Write(file, binary.LittleEndian, o.next)
+	binary.Write(file, binary.LittleEndian, o.count)
+	binary.Write(file, binary.LittleEndian, o.addresses)
+}
+
+// readBlock lê um bloco de overflow do arquivo
+func (fh *FieldHash) readBlock(address int64) *overflowBlock {
+	if address < 0 {
+		return nil
+	}
+
+	fh.overflowFile.Seek(address, io.SeekStart)
+	data := make([]byte, overflowBlockSize())
+	fh.overflowFile.Read(data)
+
+	block := newOverflowBlock(address)
+	ptr := 0
+	block.next, ptr = utils.BytesToInt64(data, ptr)
+	block.count, ptr = utils.BytesToInt64(data, ptr)
+	for i := 0; i < OVERFLOW_SIZE; i++ {
+		block.addresses[i], ptr = utils.BytesToInt64(data, ptr)
+	}
+
+	return block
+}
+
+// allocBlock retorna um bloco vazio, reaproveitando a lista de blocos livres
+func (fh *FieldHash) allocBlock() *overflowBlock {
+	if fh.freeBlocks < 0 {
+		return newOverflowBlock(-1)
+	}
+
+	free := fh.readBlock(fh.freeBlocks)
+	fh.freeBlocks = free.next
+
+	return newOverflowBlock(free.address)
+}
+
+// freeBlock devolve um bloco para a lista de blocos livres
+func (fh *FieldHash) freeBlock(block *overflowBlock) {
+	block.next = fh.freeBlocks
+	block.count = 0
+	block.write(fh.overflowFile)
+	fh.freeBlocks = block.address
+}
+
+// ==================================== FieldHash ==================================== //
+
+// fieldIdentifier retorna o identificador da hash de um campo, usado
+// para montar o caminho de seus arquivos
+func fieldIdentifier(field string) string {
+	return filepath.Join(FIELDS_DIR, field)
+}
+
+// key normaliza o valor e aplica a funcao hash do indice. A comparacao
+// é insensivel a maiusculas e minusculas
+func (fh *FieldHash) key(value string) int64 {
+	return fh.hashFn(strings.ToLower(strings.TrimSpace(value)))
+}
+
+// writeHeader grava o inicio do arquivo de overflow: a lista de blocos
+// livres e o nome da funcao hash. Nenhum bloco fica no endereço 0, que
+// a hash dinamica usa como "nao encontrado"
+func (fh *FieldHash) writeHeader() {
+	fh.overflowFile.Seek(0, io.SeekStart)
+	binary.Write(fh.overflowFile, binary.LittleEndian, fh.freeBlocks)
+	binary.Write(fh.overflowFile, binary.LittleEndian, int32(len(fh.hashName)))
+	binary.Write(fh.overflowFile, binary.LittleEndian, []byte(fh.hashName))
+}
+
+// LoadFieldHash carrega o indice hash de um campo para a memoria primaria
+func LoadFieldHash(path string, field string) (*FieldHash, error) {
+	overflowPath := filepath.Join(path, fieldIdentifier(field), OVERFLOW_FILE)
+	header, err := os.ReadFile(overflowPath)
+	if err != nil {
+		return nil, err
+	}
+
+	freeBlocks, ptr := utils.BytesToInt64(header, 0)
+	hashName, _ := utils.BytesToString(header, ptr)
+	hashFn, ok := HashFunctions[hashName]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash function '%s'", hashName)
+	}
+
+	hash, err := LoadDinamicHash(path, fieldIdentifier(field))
+	if err != nil {
+		return nil, err
+	}
+	overflowFile, _ := os.OpenFile(overflowPath, os.O_RDWR, 0644)
+
+	return &FieldHash{
+		hash:         hash,
+		overflowFile: overflowFile,
+		hashName:     hashName,
+		hashFn:       hashFn,
+		freeBlocks:   freeBlocks,
+	}, nil
+}
+
+// Close salva os metadados e fecha os arquivos do indice
+func (fh *FieldHash) Close() {
+	fh.writeHeader()
+	fh.overflowFile.Close()
+	fh.hash.Close()
+}
+
+// Insert adiciona o endereço de um registro à lista do valor fornecido,
+// criando a chave na hash se ela ainda nao existir
+func (fh *FieldHash) Insert(value string, address int64) {
+	key := fh.key(value)
+	head, err := fh.hash.Read(key)
+
+	if err != nil {
+		block := fh.allocBlock()
+		block.addresses[0] = address
+		block.count = 1
+		block.write(fh.overflowFile)
+		fh.hash.addRecord(newBucketRecord(key, block.address))
+		return
+	}
+
+	block := fh.readBlock(head)
+	for block.count == int64(OVERFLOW_SIZE) && block.next >= 0 {
+		block = fh.readBlock(block.next)
+	}
+
+	if block.count < int64(OVERFLOW_SIZE) {
+		block.addresses[block.count] = address
+		block.count++
+		block.write(fh.overflowFile)
+		return
+	}
+
+	next := fh.allocBlock()
+	next.addresses[0] = address
+	next.count = 1
+	next.write(fh.overflowFile)
+	block.next = next.address
+	block.write(fh.overflowFile)
+}
+
+// Read retorna os endereços de todos os registros cujo valor possui a
+// mesma chave que o valor fornecido
+func (fh *FieldHash) Read(value string) []int64 {
+	addresses := make([]int64, 0)
+
+	head, err := fh.hash.Read(fh.key(value))
+	if err != nil {
+		return addresses
+	}
+
+	for block := fh.readBlock(head); block != nil; block = fh.readBlock(block.next) {
+		addresses = append(addresses, block.addresses[:block.count]...)
+	}
+
+	return addresses
+}
+
+// Remove retira o endereço de um registro da lista do valor fornecido.
+// Quando a lista esvazia a chave é removida da hash
+func (fh *FieldHash) Remove(value string, address int64) error {
+	key := fh.key(value)
+	head, err := fh.hash.Read(key)
+	if err != nil {
+		return err
+	}
+
+	var prev *overflowBlock
+	for block := fh.readBlock(head); block != nil; prev, block = block, fh.readBlock(block.next) {
+		for i := int64(0); i < block.count; i++ {
+			if block.addresses[i] != address {
+				continue
+			}
+
+			block.count--
+			block.addresses[i] = block.addresses[block.count]
+			block.addresses[block.count] = 0
+
+			switch {
+			case block.count > 0:
+				block.write(fh.overflowFile)
+			case prev != nil:
+				prev.next = block.next
+				prev.write(fh.overflowFile)
+				fh.freeBlock(block)
+			case block.next >= 0:
+				// O primeiro bloco recebe o conteudo do segundo para manter a chave
+				next := fh.readBlock(block.next)
+				fh.freeBlock(newOverflowBlock(next.address))
+				next.address = block.address
+				next.write(fh.overflowFile)
+			default:
+				fh.freeBlock(block)
+				fh.hash.Delete(key)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("record not found")
+}
+
+// StartFieldHashFile cria o indice hash de um campo a partir de um Reader,
+// usando a funcao hash de nome fornecido (ver HashFunctions).
+//
+// A interface retornada por ReadNextGeneric deve possuir:
+// GetField(fieldName string) string.
+func StartFieldHashFile(controler Reader, bucketSize int64, path string, field string, hashName string) error {
+	hashFn, ok := HashFunctions[hashName]
+	if !ok {
+		return fmt.Errorf("unknown hash function '%s'", hashName)
+	}
+
+	// Inicializando hash vazia e arquivo de overflow
+	folderPath := filepath.Join(path, fieldIdentifier(field))
+	os.MkdirAll(folderPath, os.ModePerm)
+	overflowFile, err := os.Create(filepath.Join(folderPath, OVERFLOW_FILE))
+	if err != nil {
+		return err
+	}
+
+	fh := &FieldHash{
+		hash:         newHash(filepath.Join(folderPath, BUCKETS_FILE), filepath.Join(folderPath, DIRECTORY_FILE), bucketSize),
+		overflowFile: overflowFile,
+		hashName:     hashName,
+		hashFn:       hashFn,
+		freeBlocks:   -1,
+	}
+	fh.writeHeader()
+
+	for {
+		objInterface, isDead, address, err := controler.ReadNextGeneric()
+		if err != nil {
+			break
+		}
+
+		obj, ok := objInterface.(IndexableObject)
+		if !ok {
+			fh.Close()
+			return fmt.Errorf("failed to convert object to IndexableObject\n%+v", objInterface)
+		}
+
+		if !isDead {
+			fh.Insert(obj.GetField(field), address)
+		}
+	}
+
+	fh.Close()
+	return nil
+}
+
+// ======================================= Crud ======================================== //
+
+// FieldHashCreate adiciona o endereço de um objeto aos indices dos campos fornecidos
+func FieldHashCreate(obj IndexableObject, address int64, path string, fields ...string) error {
+	for _, field := range fields {
+		fh, err := LoadFieldHash(path, field)
+		if err != nil {
+			return err
+		}
+		fh.Insert(obj.GetField(field), address)
+		fh.Close()
+	}
+
+	return nil
+}
+
+// FieldHashUpdate troca, nos indices dos campos fornecidos, o valor e o
+// endereço antigos de um objeto pelos novos
+func FieldHashUpdate(old IndexableObject, new IndexableObject, oldAddress int64, newAddress int64, path string, fields ...string) error {
+	for _, field := range fields {
+		fh, err := LoadFieldHash(path, field)
+		if err != nil {
+			return err
+		}
+		fh.Remove(old.GetField(field), oldAddress)
+		fh.Insert(new.GetField(field), newAddress)
+		fh.Close()
+	}
+
+	return nil
+}
+
+// FieldHashDelete remove o endereço de um objeto dos indices dos campos fornecidos
+func FieldHashDelete(obj IndexableObject, address int64, path string, fields ...string) error {
+	for _, field := range fields {
+		fh, err := LoadFieldHash(path, field)
+		if err != nil {
+			return err
+		}
+		err = fh.Remove(obj.GetField(field), address)
+		fh.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
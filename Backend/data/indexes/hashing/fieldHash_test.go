@@ -0,0 +1,225 @@
+// Testes dos indices hash de campos: escolha da funcao hash, colisoes de
+// valores diferentes na mesma lista de overflow e manutencao do indice por
+// FieldHashCreate, FieldHashUpdate e FieldHashDelete
+package hashing
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// testObject é um objeto com os campos "id" e "nome"
+type testObject struct {
+	id   int64
+	nome string
+}
+
+func (o testObject) GetField(fieldName string) string {
+	if fieldName == "id" {
+		return strconv.FormatInt(o.id, 10)
+	}
+	return o.nome
+}
+
+// testReader percorre uma lista de objetos, usando 100*id como endereço
+type testReader struct {
+	objects []testObject
+	next    int
+}
+
+func (r *testReader) ReadNextGeneric() (any, bool, int64, error) {
+	if r.next >= len(r.objects) {
+		return nil, false, 0, errors.New("EOF")
+	}
+	o := r.objects[r.next]
+	r.next++
+	return o, false, 100 * o.id, nil
+}
+
+// sorted ordena os endereços, ja que a ordem das listas nao é garantida
+func sorted(addresses []int64) []int64 {
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+	return addresses
+}
+
+func TestHashFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int64
+	}{
+		{"int", "25", 25},
+		{"int", "-25", 25},
+		{"int", "pikachu", 0},
+		{"djb2", "", 5381},
+		{"djb2", "a", 5381*33 + 'a'},
+		{"fnv", "", 0x4bf29ce484222325},
+		{"fnv", "a", 0x2f63dc4c8601ec8c},
+	}
+
+	for _, test := range tests {
+		if got := HashFunctions[test.name](test.value); got != test.expected {
+			t.Errorf("%s(%q) = %d, expected %d", test.name, test.value, got, test.expected)
+		}
+	}
+}
+
+func TestHashSelection(t *testing.T) {
+	path := t.TempDir()
+	objects := []testObject{{1, "Bulbasaur"}, {2, "Ivysaur"}}
+
+	for _, name := range []string{"fnv", "djb2"} {
+		if err := StartFieldHashFile(&testReader{objects: objects}, 4, path, "nome", name); err != nil {
+			t.Fatal(err)
+		}
+		fh, err := LoadFieldHash(path, "nome")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fh.hashName != name {
+			t.Errorf("LoadFieldHash used %q, expected %q", fh.hashName, name)
+		}
+		if got := fh.Read("  IVYSAUR "); !reflect.DeepEqual(got, []int64{200}) {
+			t.Errorf("%s: Read(IVYSAUR) = %v, expected [200]", name, got)
+		}
+		fh.Close()
+	}
+
+	if err := StartFieldHashFile(&testReader{objects: objects}, 4, path, "nome", "md5"); err == nil {
+		t.Errorf("StartFieldHashFile accepted an unknown hash function")
+	}
+}
+
+func TestCollisions(t *testing.T) {
+	// Todos os valores colidem na mesma chave e dividem uma unica lista
+	HashFunctions["const"] = func(string) int64 { return 7 }
+	defer delete(HashFunctions, "const")
+
+	path := t.TempDir()
+	reader := &testReader{}
+	expected := make([]int64, 0)
+	for id := int64(1); id <= int64(3*OVERFLOW_SIZE+2); id++ {
+		reader.objects = append(reader.objects, testObject{id, "Pokemon " + strconv.FormatInt(id, 10)})
+		expected = append(expected, 100*id)
+	}
+	if err := StartFieldHashFile(reader, 4, path, "nome", "const"); err != nil {
+		t.Fatal(err)
+	}
+
+	fh, err := LoadFieldHash(path, "nome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+
+	if got := sorted(fh.Read("qualquer valor")); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Read = %v, expected %v", got, expected)
+	}
+
+	// Esvazia o primeiro bloco, um do meio e o ultimo da lista
+	remove := func(id int64) {
+		t.Helper()
+		if err := fh.Remove("", 100*id); err != nil {
+			t.Fatalf("Remove(%d) = %v", id, err)
+		}
+		for i, address := range expected {
+			if address == 100*id {
+				expected = append(expected[:i], expected[i+1:]...)
+				break
+			}
+		}
+		if got := sorted(fh.Read("")); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("after Remove(%d): Read = %v, expected %v", id, got, expected)
+		}
+	}
+	for id := int64(1); id <= int64(OVERFLOW_SIZE); id++ {
+		remove(id)
+	}
+	for id := int64(2*OVERFLOW_SIZE + 1); id <= int64(3*OVERFLOW_SIZE); id++ {
+		remove(id)
+	}
+	remove(int64(3*OVERFLOW_SIZE + 2))
+
+	if err := fh.Remove("", 100); err == nil {
+		t.Errorf("Remove of a missing address returned no error")
+	}
+
+	// Os blocos liberados sao reaproveitados antes de crescer o arquivo
+	info, _ := fh.overflowFile.Stat()
+	size := info.Size()
+	for id := int64(1); id <= int64(2*OVERFLOW_SIZE); id++ {
+		fh.Insert("", 100*id)
+	}
+	if info, _ := fh.overflowFile.Stat(); info.Size() != size {
+		t.Errorf("overflow file grew from %d to %d bytes with free blocks available", size, info.Size())
+	}
+}
+
+func TestFieldHashCrud(t *testing.T) {
+	path := t.TempDir()
+	objects := []testObject{{1, "Bulbasaur"}, {2, "Ivysaur"}, {3, "Venusaur"}}
+	if err := StartFieldHashFile(&testReader{objects: objects}, 4, path, "nome", "fnv"); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(value string) []int64 {
+		t.Helper()
+		fh, err := LoadFieldHash(path, "nome")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fh.Close()
+		return sorted(fh.Read(value))
+	}
+
+	steps := []struct {
+		name     string
+		run      func() error
+		expected map[string][]int64
+	}{
+		{
+			"create",
+			func() error { return FieldHashCreate(testObject{4, "bulbasaur"}, 400, path, "nome") },
+			map[string][]int64{"Bulbasaur": {100, 400}, "Ivysaur": {200}},
+		},
+		{
+			"update",
+			func() error {
+				return FieldHashUpdate(testObject{2, "Ivysaur"}, testObject{2, "Venusaur"}, 200, 250, path, "nome")
+			},
+			map[string][]int64{"Ivysaur": {}, "Venusaur": {250, 300}},
+		},
+		{
+			"delete",
+			func() error { return FieldHashDelete(testObject{1, "Bulbasaur"}, 100, path, "nome") },
+			map[string][]int64{"Bulbasaur": {400}, "Venusaur": {250, 300}},
+		},
+		{
+			"delete",
+			func() error { return FieldHashDelete(testObject{4, "bulbasaur"}, 400, path, "nome") },
+			map[string][]int64{"Bulbasaur": {}},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		for value, expected := range step.expected {
+			if got := read(value); !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s: Read(%q) = %v, expected %v", step.name, value, got, expected)
+			}
+		}
+	}
+
+	if err := FieldHashDelete(testObject{4, "bulbasaur"}, 400, path, "nome"); err == nil {
+		t.Errorf("FieldHashDelete of a removed object returned no error")
+	}
+	if err := FieldHashCreate(testObject{5, "Charmander"}, 500, path, "tipo"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("FieldHashCreate on a missing index = %v, expected %v", err, os.ErrNotExist)
+	}
+}
@@ -9,6 +9,11 @@
 // A hash dinâmica é útil em cenários onde o tamanho do conjunto de dados pode variar
 // significativamente e onde o desempenho de pesquisa é crítico.
 //
+// A hash tambem se contrai: apos remocoes, buckets irmaos pouco ocupados sao
+// juntados, o diretorio é reduzido pela metade quando nenhum bucket utiliza a
+// profundidade global e os buckets liberados sao reaproveitados ou removidos
+// do final do arquivo.
+//
 // Para começar, adapte a funcao StartHashFile() e recordToBucketRecord(registro Registro)
 // para receberem o tipo de registro que voce deseja criar a hash.
 // Para alem disso utilize uma função de leitura de arquivo para fazer o import na funcao
@@ -35,6 +40,10 @@ const (
 	DIRECTORY_FILE string = "Hash_Directory.bin"
 )
 
+// MERGE_THRESHOLD é a ocupação maxima (em fração da capacidade de um bucket)
+// que dois buckets irmaos somados podem ter para serem juntados
+const MERGE_THRESHOLD float64 = 0.5
+
 // ====================================== Structs ====================================== //
 
 // DinamicHash representa uma tabela de hash dinâmica.
@@ -192,11 +201,16 @@ func (hash *DinamicHash) Close() {
 	for i := 0; i < len(hash.directory.bucketPointer); i++ {
 		binary.Write(hash.directoryFile, binary.LittleEndian, hash.directory.bucketPointer[i])
 	}
+	hash.releaseGarbage()
 	binary.Write(hash.directoryFile, binary.LittleEndian, int64(len(hash.directory.garbage)))
 	for i := 0; i < len(hash.directory.garbage); i++ {
 		binary.Write(hash.directoryFile, binary.LittleEndian, hash.directory.garbage[i])
 	}
 
+	// Descarta o restante de um diretorio antigo maior
+	end, _ := hash.directoryFile.Seek(0, io.SeekCurrent)
+	hash.directoryFile.Truncate(end)
+
 	if err := hash.bucketFile.Close(); err != nil {
 		fmt.Printf("Erro ao fechar/salvar bucket")
 	}
@@ -219,6 +233,32 @@ func (hash *DinamicHash) increasePower() {
 	}
 }
 
+// shrinkDirectory reduz o diretorio pela metade enquanto nenhum bucket
+// utilizar a profundidade global, ou seja, enquanto as duas metades do
+// diretorio apontarem para os mesmos buckets
+func (hash *DinamicHash) shrinkDirectory() {
+	for hash.directory.p > 1 {
+		half := hash.getBucketCount() >> 1
+		for i := 0; i < half; i++ {
+			if hash.directory.bucketPointer[i] != hash.directory.bucketPointer[i+half] {
+				return
+			}
+		}
+
+		hash.directory.p--
+		hash.directory.bucketPointer = hash.directory.bucketPointer[:half]
+	}
+}
+
+// pointDirectory faz todas as entradas do diretorio cujos 'depth' bits menos
+// significativos sejam iguais a 'pattern' apontarem para o endereço fornecido
+func (hash *DinamicHash) pointDirectory(pattern int64, depth int64, address int64) {
+	step := int64(1) << depth
+	for i := pattern; i < int64(hash.getBucketCount()); i += step {
+		hash.directory.bucketPointer[i] = address
+	}
+}
+
 // PrintHash é uma funcao pertencente a struct DinamicHash
 // que permite fazer o debug da hash.
 //
@@ -309,6 +349,8 @@ func StartHashFile(controler Reader, bucketSize int64, path string, identifier s
 //
 // Caso um bucket de "localPower" == "hashPower" a hash sera aumentada e um novo bucket criado.
 // Caso o "localPower" < "hashPower" um novo bucket sera criado.
+// Apos a divisao a insercao é tentada novamente, pois todos os registros
+// podem ter caido no mesmo bucket.
 // Por fim se nao estourar apenas insere
 func (hash *DinamicHash) addRecord(r BucketRecord) {
 	// Recuperar e dar parsing no bucket a ser editado
@@ -322,21 +364,18 @@ func (hash *DinamicHash) addRecord(r BucketRecord) {
 			hash.increasePower()
 		}
 
-		// Criação do novo bucket
+		// Criação do novo bucket, que recebe todas as entradas do diretorio
+		// com o novo bit do padrao ligado
 		address := hash.initializeNewBucket(1)
+		pos %= bucket.getBucketPower()
 		newPos := bucket.getBucketPower() + pos
-		if newPos >= int64(hash.getBucketCount()) {
-			newPos = pos
-			pos %= bucket.getBucketPower()
-		}
-		hash.directory.bucketPointer[newPos] = address[0]
 		bucket.ActualPower++
+		hash.pointDirectory(newPos, bucket.ActualPower, address[0])
 
 		// Limpeza e reinsercao
 		bucket1 := newBucket(bucket.ActualPower, hash.loadFactor)
 		bucket2 := newBucket(bucket.ActualPower, hash.loadFactor)
-		bucket.Records[bucket.CurrentSize] = r
-		for i, b1, b2 := 0, 0, 0; i < len(bucket.Records); i++ {
+		for i, b1, b2 := int64(0), 0, 0; i < bucket.CurrentSize; i++ {
 			if bucket.Records[i].ID%bucket.getBucketPower() == pos {
 				bucket1.Records[b1] = bucket.Records[i]
 				bucket1.CurrentSize++
@@ -351,6 +390,9 @@ func (hash *DinamicHash) addRecord(r BucketRecord) {
 		// Gravando bucket atual e novo em arquivo
 		hash.insertIntoBucket(pos, bucket1.ActualPower, bucket1.CurrentSize, bucket1.Records)
 		hash.insertIntoBucket(newPos, bucket2.ActualPower, bucket2.CurrentSize, bucket2.Records)
+
+		// Nova tentativa de insercao, que pode gerar outra divisao
+		hash.addRecord(r)
 	} else {
 		// Apenas insere ao final
 		bucket.Records[bucket.CurrentSize] = r
@@ -421,6 +463,66 @@ func (hash *DinamicHash) initializeNewBucket(numberOfBuckets int) []int64 {
 	return bucketAddress
 }
 
+// mergeBuckets tenta juntar o bucket da posição fornecida com seu irmao, o
+// bucket de mesma profundidade local cujo padrao difere apenas no bit mais
+// significativo.
+//
+// A juncao só acontece se os dois somados ocuparem no maximo MERGE_THRESHOLD
+// da capacidade de um bucket, e é repetida em cascata enquanto for possivel.
+// O bucket liberado vai para o garbage para ser reaproveitado
+func (hash *DinamicHash) mergeBuckets(pos int64) {
+	bucket := hash.readBucket(pos)
+	limit := MERGE_THRESHOLD * float64(hash.loadFactor-1)
+
+	for bucket.ActualPower > 1 {
+		half := bucket.getBucketPower() >> 1
+		pattern := pos % bucket.getBucketPower()
+		low, high := pattern%half, pattern%half+half
+		buddy := hash.readBucket(pattern ^ half)
+
+		if buddy.ActualPower != bucket.ActualPower || float64(bucket.CurrentSize+buddy.CurrentSize) > limit {
+			return
+		}
+
+		// Junta os registros dos dois buckets em um de profundidade menor
+		merged := newBucket(bucket.ActualPower-1, hash.loadFactor)
+		for _, b := range []Bucket{bucket, buddy} {
+			for i := int64(0); i < b.CurrentSize; i++ {
+				merged.Records[merged.CurrentSize] = b.Records[i]
+				merged.CurrentSize++
+			}
+		}
+
+		// O bucket do padrao alto é liberado e o baixo passa a cobrir os dois
+		hash.directory.garbage = append(hash.directory.garbage, hash.directory.bucketPointer[high])
+		hash.pointDirectory(low, merged.ActualPower, hash.directory.bucketPointer[low])
+		hash.insertIntoBucket(low, merged.ActualPower, merged.CurrentSize, merged.Records)
+
+		pos, bucket = low, merged
+	}
+}
+
+// releaseGarbage remove do final do arquivo os buckets que estao no garbage,
+// devolvendo o espaço ao sistema de arquivos. Os demais continuam na lista
+// para serem reaproveitados em novas divisoes
+func (hash *DinamicHash) releaseGarbage() {
+	end, _ := hash.bucketFile.Seek(0, io.SeekEnd)
+
+	for found := true; found; {
+		found = false
+		for i, address := range hash.directory.garbage {
+			if address == end-hash.bucketSize {
+				hash.directory.garbage = append(hash.directory.garbage[:i], hash.directory.garbage[i+1:]...)
+				end = address
+				found = true
+				break
+			}
+		}
+	}
+
+	hash.bucketFile.Truncate(end)
+}
+
 // readBucket recebe a posição do bucket na hash, realiza o parsing
 // e retorna o bucket formatado
 func (hash *DinamicHash) readBucket(pos int64) Bucket {
@@ -535,11 +637,20 @@ func HashRead(targetID int64, path string, identifier string) (targetPos int64,
 // HashDelete remove um Record com base no ID fornecido da estrutura de hash dinâmica
 // e atualiza o arquivo Hash.
 //
+// Apos a remocao o bucket é juntado ao seu irmao se a ocupacao permitir, e o
+// diretorio é reduzido sempre que possivel.
+//
 // Retorna um erro se o Record não for encontrado.
 func HashDelete(targetID int64, path string, identifier string) error {
-	// Recuperar o bucket
 	hash, _ := LoadDinamicHash(path, identifier)
 	defer hash.Close()
+	return hash.Delete(targetID)
+}
+
+// Delete remove o registro de um determinado ID da hash, juntando buckets
+// e reduzindo o diretorio quando possivel
+func (hash *DinamicHash) Delete(targetID int64) error {
+	// Recuperar o bucket
 	pos := targetID % int64(hash.getBucketCount())
 	bucket := hash.readBucket(pos)
 
@@ -565,15 +676,9 @@ func HashDelete(targetID int64, path string, identifier string) error {
 	// Escreve novo bucket em arquivo
 	hash.insertIntoBucket(pos, bucket.ActualPower, bucket.CurrentSize, bucket.Records)
 
-	// Se estiver vazio atualiza o garbage collector
-	if bucket.CurrentSize == 0 {
-		bucket = Bucket{}
-		hash.directory.garbage = append(hash.directory.garbage, hash.directory.bucketPointer[pos])
-		hash.directory.bucketPointer[pos] = hash.directory.bucketPointer[pos>>1]
-		mergedBucket := hash.readBucket(pos)
-		mergedBucket.ActualPower--
-		hash.insertIntoBucket(pos, mergedBucket.ActualPower, mergedBucket.CurrentSize, mergedBucket.Records)
-	}
+	// Contracao da hash
+	hash.mergeBuckets(pos)
+	hash.shrinkDirectory()
 
 	return nil
 }
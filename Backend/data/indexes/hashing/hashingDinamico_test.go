@@ -0,0 +1,171 @@
+// Testes da hash dinamica: divisao de buckets com profundidade local menor
+// que a global, juncao de buckets irmaos e reducao do diretorio apos remocoes
+package hashing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestHash cria uma hash com buckets de 4 registros e insere os ids de
+// 1 a n, com o endereço de cada id igual a 10*id
+func newTestHash(t *testing.T, n int64) DinamicHash {
+	dir := t.TempDir()
+	hash := newHash(filepath.Join(dir, BUCKETS_FILE), filepath.Join(dir, DIRECTORY_FILE), 4)
+	for id := int64(1); id <= n; id++ {
+		hash.addRecord(newBucketRecord(id, 10*id))
+	}
+	return hash
+}
+
+// checkHash verifica que cada entrada do diretorio aponta para um bucket cuja
+// profundidade local nao passa da global e cujos registros pertencem ao seu
+// padrao, e que os ids presentes (e apenas eles) sao encontrados
+func checkHash(t *testing.T, hash *DinamicHash, present map[int64]bool, n int64) {
+	t.Helper()
+
+	for i := int64(0); i < int64(hash.getBucketCount()); i++ {
+		bucket := hash.readBucket(i)
+		if bucket.ActualPower > hash.directory.p {
+			t.Fatalf("bucket %d has depth %d > %d", i, bucket.ActualPower, hash.directory.p)
+		}
+		for j := int64(0); j < bucket.CurrentSize; j++ {
+			id := bucket.Records[j].ID
+			if id%bucket.getBucketPower() != i%bucket.getBucketPower() {
+				t.Fatalf("id %d is in bucket %d with depth %d", id, i, bucket.ActualPower)
+			}
+		}
+	}
+
+	for id := int64(1); id <= n; id++ {
+		pos, err := hash.Read(id)
+		if present[id] && (err != nil || pos != 10*id) {
+			t.Fatalf("Read(%d) = %d, %v, expected %d", id, pos, err, 10*id)
+		}
+		if !present[id] && err == nil {
+			t.Fatalf("Read(%d) found a deleted id", id)
+		}
+	}
+}
+
+func TestSplitShallowBucket(t *testing.T) {
+	// Os pares aprofundam o diretorio enquanto os impares continuam em um
+	// bucket de profundidade 1, que depois é dividido com varias entradas do
+	// diretorio apontando para ele
+	const n = 64
+	dir := t.TempDir()
+	hash := newHash(filepath.Join(dir, BUCKETS_FILE), filepath.Join(dir, DIRECTORY_FILE), 4)
+	present := make(map[int64]bool)
+	for _, start := range []int64{2, 1} {
+		for id := start; id <= n; id += 2 {
+			hash.addRecord(newBucketRecord(id, 10*id))
+			present[id] = true
+			checkHash(t, &hash, present, n)
+		}
+	}
+	hash.Close()
+}
+
+func TestDeleteMergeBuckets(t *testing.T) {
+	const n = 64
+
+	tests := []struct {
+		name  string
+		order func(i int64) int64
+	}{
+		{"ascending", func(i int64) int64 { return i }},
+		{"descending", func(i int64) int64 { return n + 1 - i }},
+		{"evens first", func(i int64) int64 {
+			if i <= n/2 {
+				return 2 * i
+			}
+			return 2*(i-n/2) - 1
+		}},
+	}
+
+	for _, test := range tests {
+		hash := newTestHash(t, n)
+		present := make(map[int64]bool)
+		for id := int64(1); id <= n; id++ {
+			present[id] = true
+		}
+		grown := hash.directory.p
+
+		for i := int64(1); i < n; i++ {
+			id := test.order(i)
+			if err := hash.Delete(id); err != nil {
+				t.Fatalf("%s: Delete(%d) = %v", test.name, id, err)
+			}
+			delete(present, id)
+			checkHash(t, &hash, present, n)
+		}
+
+		if hash.directory.p != 1 {
+			t.Errorf("%s: directory depth = %d after deletes, expected 1 (was %d)", test.name, hash.directory.p, grown)
+		}
+		if err := hash.Delete(test.order(1)); err == nil {
+			t.Errorf("%s: deleting a missing id returned no error", test.name)
+		}
+		hash.Close()
+	}
+}
+
+func TestShrinkDirectory(t *testing.T) {
+	hash := newTestHash(t, 32)
+	hash.increasePower()
+	hash.increasePower()
+	p := hash.directory.p
+
+	// Apenas duplicar o diretorio nao cria buckets novos, entao ele volta
+	hash.shrinkDirectory()
+	if hash.directory.p != p-2 || len(hash.directory.bucketPointer) != hash.getBucketCount() {
+		t.Errorf("shrinkDirectory: depth = %d, len = %d, expected %d, %d",
+			hash.directory.p, len(hash.directory.bucketPointer), p-2, 1<<(p-2))
+	}
+
+	// Sem remocoes nenhum bucket usa menos que a profundidade global
+	hash.shrinkDirectory()
+	if hash.directory.p != p-2 {
+		t.Errorf("shrinkDirectory reduced a directory still in use to depth %d", hash.directory.p)
+	}
+	hash.Close()
+}
+
+func TestMergeThreshold(t *testing.T) {
+	hash := newTestHash(t, 16)
+	p := hash.directory.p
+
+	// Buckets irmaos com mais que MERGE_THRESHOLD da capacidade continuam separados
+	hash.Delete(8)
+	if hash.directory.p != p {
+		t.Errorf("directory shrank to %d with full buckets", hash.directory.p)
+	}
+	hash.Close()
+}
+
+func TestReleaseGarbage(t *testing.T) {
+	hash := newTestHash(t, 64)
+	bucketPath := hash.bucketFile.Name()
+	directoryPath := hash.directoryFile.Name()
+	hash.Close()
+
+	before, _ := os.Stat(bucketPath)
+	hash, _ = LoadDinamicHash(filepath.Dir(directoryPath), "")
+	for id := int64(1); id < 64; id++ {
+		hash.Delete(id)
+	}
+	hash.Close()
+	after, _ := os.Stat(bucketPath)
+
+	if after.Size() >= before.Size() {
+		t.Errorf("bucket file size = %d after deletes, expected less than %d", after.Size(), before.Size())
+	}
+
+	hash, err := LoadDinamicHash(filepath.Dir(directoryPath), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHash(t, &hash, map[int64]bool{64: true}, 64)
+	hash.Close()
+}
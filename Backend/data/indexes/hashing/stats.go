@@ -0,0 +1,89 @@
+// O arquivo stats do pacote hashing coleta estatisticas das hashes dinamicas,
+// usadas para acompanhar o crescimento dos arquivos e detectar buckets
+// degenerados sem depender de PrintHash.
+package hashing
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// HashStats resume o estado de uma hash dinamica
+type HashStats struct {
+	Files       map[string]int64 `json:"files"`          // Tamanho de cada arquivo em bytes
+	GlobalDepth int64            `json:"globalDepth"`    // Profundidade do diretorio
+	Directory   int              `json:"directory"`      // Entradas no diretorio
+	Buckets     int              `json:"buckets"`        // Buckets distintos apontados
+	Records     int64            `json:"records"`        // Registros armazenados
+	Capacity    int64            `json:"bucketCapacity"` // Registros por bucket
+	FillFactor  float64          `json:"fillFactor"`     // Records / (Buckets * Capacity)
+	FreeBuckets int              `json:"freeBuckets"`    // Buckets na lista de reaproveitamento
+	Occupancy   map[int64]int    `json:"occupancy"`      // Registros no bucket -> quantidade de buckets
+	LocalDepths map[int64]int    `json:"localDepths"`    // Profundidade local -> quantidade de buckets
+}
+
+// FieldHashStats resume o estado de uma hash de campo
+type FieldHashStats struct {
+	HashStats
+	HashFunction   string `json:"hashFunction"`   // Nome da funcao hash
+	OverflowBlocks int64  `json:"overflowBlocks"` // Blocos no arquivo de overflow
+	FreeBlocks     int64  `json:"freeBlocks"`     // Blocos na lista de livres
+}
+
+// Stats percorre o diretorio e os buckets da hash coletando suas estatisticas
+func (hash *DinamicHash) Stats() HashStats {
+	stats := HashStats{
+		Files: map[string]int64{
+			hash.bucketFile.Name():    utils.FileSize(hash.bucketFile.Name()),
+			hash.directoryFile.Name(): utils.FileSize(hash.directoryFile.Name()),
+		},
+		GlobalDepth: hash.directory.p,
+		Directory:   len(hash.directory.bucketPointer),
+		Capacity:    hash.loadFactor - 1,
+		FreeBuckets: len(hash.directory.garbage),
+		Occupancy:   make(map[int64]int),
+		LocalDepths: make(map[int64]int),
+	}
+
+	// Varios ponteiros do diretorio podem levar ao mesmo bucket
+	visited := make(map[int64]bool)
+	for pos, address := range hash.directory.bucketPointer {
+		if visited[address] {
+			continue
+		}
+		visited[address] = true
+
+		bucket := hash.readBucket(int64(pos))
+		stats.Buckets++
+		stats.Records += bucket.CurrentSize
+		stats.Occupancy[bucket.CurrentSize]++
+		stats.LocalDepths[bucket.ActualPower]++
+	}
+
+	if stats.Buckets > 0 && stats.Capacity > 0 {
+		stats.FillFactor = float64(stats.Records) / float64(int64(stats.Buckets)*stats.Capacity)
+	}
+
+	return stats
+}
+
+// Stats coleta as estatisticas da hash de campo e de seus blocos de overflow
+func (fh *FieldHash) Stats() FieldHashStats {
+	stats := FieldHashStats{
+		HashStats:    fh.hash.Stats(),
+		HashFunction: fh.hashName,
+	}
+
+	// O inicio do arquivo é ocupado pelo cabecalho, e nao por blocos
+	overflowSize := utils.FileSize(fh.overflowFile.Name())
+	stats.Files[fh.overflowFile.Name()] = overflowSize
+	header := int64(8 + 4 + len(fh.hashName))
+	if overflowSize > header {
+		stats.OverflowBlocks = (overflowSize - header) / overflowBlockSize()
+	}
+
+	for block := fh.readBlock(fh.freeBlocks); block != nil; block = fh.readBlock(block.next) {
+		stats.FreeBlocks++
+	}
+
+	return stats
+}
@@ -0,0 +1,145 @@
+// O arquivo analyzer do pacote invertedIndex define os analisadores usados para
+// transformar o conteudo de um campo, e as consultas sobre ele, em termos.
+//
+// Cada campo é indexado com um unico analisador, gravado em seu manifesto, de
+// forma que inclusoes, alteracoes e pesquisas sempre produzam os mesmos termos
+// que a construcao do indice:
+//
+//	simple   letras minusculas (Tokenize)
+//	standard simple + remocao de acentos ("pokémon" -> "pokemon")
+//	english  standard + remocao de stopwords + radical de Porter ("burns" -> "burn")
+//	japanese kana e kanji em bigramas ("フシギダネ" -> "フシ", "シギ", "ギダ", "ダネ")
+package invertedIndex
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// DEFAULT_ANALYZER é o analisador padrao, usado quando nenhum é informado
+// na criacao do indice
+const DEFAULT_ANALYZER string = "simple"
+
+// Analyzer transforma um texto em uma lista de termos
+type Analyzer func(text string) []string
+
+// Analyzers relaciona os analisadores disponiveis pelo nome
+// gravado no manifesto de cada campo
+var Analyzers = map[string]Analyzer{
+	"simple":   Tokenize,
+	"standard": StandardAnalyzer,
+	"english":  EnglishAnalyzer,
+	"japanese": JapaneseAnalyzer,
+}
+
+// getAnalyzer retorna o analisador de nome fornecido
+func getAnalyzer(name string) (Analyzer, error) {
+	analyzer, ok := Analyzers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown analyzer '%s'", name)
+	}
+	return analyzer, nil
+}
+
+// StandardAnalyzer separa o texto em palavras minusculas e sem acentos
+func StandardAnalyzer(text string) []string {
+	tokens := Tokenize(text)
+	for i, token := range tokens {
+		tokens[i] = FoldAccents(token)
+	}
+	return tokens
+}
+
+// EnglishAnalyzer aplica o StandardAnalyzer, descarta as stopwords
+// da lingua inglesa e reduz cada palavra ao seu radical
+func EnglishAnalyzer(text string) []string {
+	tokens := make([]string, 0)
+	for _, token := range StandardAnalyzer(text) {
+		if !englishStopwords[token] {
+			tokens = append(tokens, Stem(token))
+		}
+	}
+	return tokens
+}
+
+// JapaneseAnalyzer separa as palavras em kana ou kanji em bigramas de
+// caracteres, ja que o japones nao separa palavras por espacos. Hiragana é
+// convertido para katakana antes, e palavras de um unico caractere sao
+// mantidas inteiras. Palavras em outros alfabetos seguem o StandardAnalyzer
+func JapaneseAnalyzer(text string) []string {
+	tokens := make([]string, 0)
+	for _, token := range Tokenize(text) {
+		if !isJapanese(token) {
+			tokens = append(tokens, FoldAccents(token))
+			continue
+		}
+
+		runes := []rune(strings.Map(utils.HiraganaToKatakana, token))
+		if len(runes) == 1 {
+			tokens = append(tokens, string(runes))
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			tokens = append(tokens, string(runes[i:i+2]))
+		}
+	}
+	return tokens
+}
+
+// isJapanese verifica se uma palavra contem hiragana, katakana ou kanji
+func isJapanese(token string) bool {
+	for _, r := range token {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) {
+			return true
+		}
+	}
+	return false
+}
+
+// FoldAccents substitui as letras latinas acentuadas de um texto minusculo
+// pelas suas equivalentes sem acento ("é" -> "e", "ß" -> "ss", "æ" -> "ae")
+func FoldAccents(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if folded, ok := accentFolding[r]; ok {
+			sb.WriteString(folded)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// accentFolding relaciona as letras acentuadas às suas formas sem acento
+var accentFolding = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u",
+	'ý': "y", 'ÿ': "y",
+	'ś': "s", 'š': "s", 'ź': "z", 'ż': "z", 'ž': "z", 'ł': "l", 'ř': "r",
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ð': "d", 'þ': "th",
+}
+
+// englishStopwords sao palavras comuns do ingles sem significado de busca
+var englishStopwords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true,
+	"been": true, "before": true, "but": true, "by": true, "can": true, "could": true,
+	"did": true, "do": true, "does": true, "each": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "he": true, "her": true, "his": true,
+	"how": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "may": true, "more": true, "most": true, "no": true, "not": true,
+	"of": true, "on": true, "or": true, "other": true, "our": true, "out": true,
+	"over": true, "she": true, "so": true, "some": true, "such": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "those": true, "through": true, "to": true,
+	"up": true, "very": true, "was": true, "we": true, "were": true, "what": true,
+	"when": true, "where": true, "which": true, "while": true, "who": true, "will": true,
+	"with": true, "would": true, "you": true, "your": true,
+}
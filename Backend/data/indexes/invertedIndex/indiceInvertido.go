@@ -3,24 +3,33 @@
 // de dados projetada para melhorar a velocidade das operações de pesquisa, permitindo
 // rápido acesso à lista de documentos que contêm uma determinada palavra.
 //
-// O pacote invertedIndex cria um mapa na memória principal e, em seguida, salva
-// este mapa na memória secundária para uso duradouro em formato GOB
+// O indice de cada campo é guardado em memoria secundaria como um conjunto de
+// segmentos imutaveis (ver segment.go), cada um com um dicionario ordenado de
+// termos e as listas de postings acessadas por offset. Uma pesquisa lê apenas
+// as listas dos termos procurados.
 //
-// Durante as operações, o pacote recupera os valores salvos da memória secundária
-// para a memória principal em formato GOB e realiza a operação requisitada.
+// Inclusoes, alteracoes e remocoes gravam pequenos segmentos delta, que sao
+// juntados em segundo plano quando se acumulam (ver manifest.go).
+//
+// O texto de cada campo é convertido em termos pelo analisador escolhido na
+// criacao do indice (ver analyzer.go), aplicado tambem as consultas. As
+// postings guardam a posicao de cada termo, permitindo pesquisas por frases
+// e por proximidade (ver query.go).
 //
 // Exemplo de uso:
 //
-//	invertedIndex.New(controler, "nome", binManager.FILES_PATH, 0) // Cria um novo Índice Invertido
+//	invertedIndex.New(controler, "nome", binManager.FILES_PATH, 0, "standard") // Cria um novo Índice Invertido
 //	invertedIndex.Create(pokemon, binManager.FILES_PATH, models.PokeStrings()...) // Adiciona o par documento-palavra ao índice
 //
-// O pacote tambem implementa um sistema de scoredDocument com operacao de Merge
-// para pesquisas baseadas em repeticao de campos
+// As pesquisas sao pontuadas com BM25, utilizando o tamanho dos documentos e a
+// quantidade de documentos de cada termo guardados no indice. O pacote tambem
+// implementa um sistema de scoredDocument com operacao de Merge para pesquisas
+// baseadas em repeticao de campos
 package invertedIndex
 
 import (
-	"encoding/gob"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -29,23 +38,31 @@ import (
 	"strings"
 )
 
-// Documento e a sua frequencia
+// Documento, a sua frequencia e as posicoes do termo no documento
 type Posting struct {
 	DocumentID int64
 	Frequency  int
+	Positions  []int
 }
 
-// Dados necessarios para o indice invertido
+// Indice invertido em memoria, usado para montar os segmentos
 type InvertedIndex struct {
-	Index           map[string][]Posting
-	removeThreshold float64
+	Index   map[string][]Posting
+	Lengths map[int64]int64 // Quantidade de tokens de cada documento
 }
 
+// Parametros do BM25: saturacao da frequencia do termo (K1) e
+// peso da normalizacao pelo tamanho do documento (B)
+const (
+	BM25_K1 float64 = 1.2
+	BM25_B  float64 = 0.75
+)
+
 // Documento pontuado, utilizado para ordenar os valores
-// de acordo com sua frequencia de aparição
+// de acordo com sua relevancia
 type ScoredDocument struct {
-	DocumentID int64
-	Score      int
+	DocumentID int64   `json:"id"`
+	Score      float64 `json:"score"`
 }
 
 // Interface para leitura da database
@@ -62,8 +79,8 @@ type IndexableObject interface {
 // estrutura de indice invertido
 func NewInvertedIndex() InvertedIndex {
 	return InvertedIndex{
-		Index:           make(map[string][]Posting),
-		removeThreshold: 0,
+		Index:   make(map[string][]Posting),
+		Lengths: make(map[int64]int64),
 	}
 }
 
@@ -82,20 +99,23 @@ func Tokenize(document string) []string {
 }
 
 // Esta função aceita um ID de documento e uma lista de tokens e adiciona os
-// tokens ao índice invertido, associando-os ao ID do documento.
+// tokens ao índice invertido, associando-os ao ID do documento e guardando
+// a posição de cada ocorrência.
 func (ii *InvertedIndex) AddDocument(documentID int64, tokens []string) {
-	tokenFrequency := make(map[string]int)
+	tokenPositions := make(map[string][]int)
 
-	for _, token := range tokens {
-		tokenFrequency[token]++
+	for position, token := range tokens {
+		tokenPositions[token] = append(tokenPositions[token], position)
 	}
 
-	for token, frequency := range tokenFrequency {
+	for token, positions := range tokenPositions {
 		ii.Index[token] = append(ii.Index[token], Posting{
 			DocumentID: documentID,
-			Frequency:  frequency,
+			Frequency:  len(positions),
+			Positions:  positions,
 		})
 	}
+	ii.Lengths[documentID] = int64(len(tokens))
 }
 
 // Esta função remove todas as ocorrências de um documento do índice invertido.
@@ -114,6 +134,7 @@ func (ii *InvertedIndex) RemoveDocument(documentID int64) {
 			ii.Index[token] = newPostings
 		}
 	}
+	delete(ii.Lengths, documentID)
 }
 
 // Print realiza a traducao para linguagem legivel do conteudo de um indice invertido.
@@ -133,7 +154,9 @@ func (ii *InvertedIndex) Print() {
 //
 // Deve ser fornecido um percentual de limite para a remocao.
 // O valor ideal sugerido é 0.5
-func (ii *InvertedIndex) RemoveHighFrequencyTerms(percentageThreshold float64) {
+//
+// Retorna os termos removidos
+func (ii *InvertedIndex) RemoveHighFrequencyTerms(percentageThreshold float64) (removed []string) {
 	if percentageThreshold == 0 {
 		return
 	}
@@ -158,55 +181,15 @@ func (ii *InvertedIndex) RemoveHighFrequencyTerms(percentageThreshold float64) {
 		if frequencyRatio > percentageThreshold {
 			// fmt.Printf("Removendo termo '%s' com frequência total %d (frequência relativa: %f)\n", word, wordFrequency, frequencyRatio)
 			delete(ii.Index, word)
+			removed = append(removed, word)
 		}
 	}
-}
-
-// writeFile realiza a escrita do documento de indice invertido em um path fornecido
-// e grava os arquivos em formato padrao GOB
-func (ii *InvertedIndex) writeFile(path string, field string) error {
-	filePath := filepath.Join(path, "invertedIndex")
-	os.MkdirAll(filePath, 0755)
-	fieldPath := filepath.Join(filePath, field+".bin")
-	file, err := os.Create(fieldPath)
-	if err != nil {
-		return fmt.Errorf("error creating file: %s", err)
-	}
-	defer file.Close()
-
-	enc := gob.NewEncoder(file)
-	err = enc.Encode(ii)
-	if err != nil {
-		return fmt.Errorf("error encoding: %s", err)
-	}
-
-	return nil
-}
-
-// readFile realiza a leitura de um arquivo contendo um indice invertido em formato GOB
-// e realiza o parse, retornando um ponteiro para o objeto
-func readFile(field string, path string) *InvertedIndex {
-	fieldPath := filepath.Join(path, "invertedIndex", field+".bin")
-	file, err := os.Open(fieldPath)
-	if err != nil {
-		fmt.Println("Error opening file:", err)
-		return nil
-	}
-	defer file.Close()
-
-	var invIndex InvertedIndex
-	dec := gob.NewDecoder(file)
-	err = dec.Decode(&invIndex)
-	if err != nil {
-		fmt.Println("Error decoding:", err)
-		return nil
-	}
 
-	return &invIndex
+	return
 }
 
 // NewScoredDocumentSlice é um wraper para criar um scoredDocument
-func NewScoredDocumentSlice(id int64, score int) (slice []ScoredDocument) {
+func NewScoredDocumentSlice(id int64, score float64) (slice []ScoredDocument) {
 	return append(slice, ScoredDocument{
 		DocumentID: id,
 		Score:      score,
@@ -216,7 +199,9 @@ func NewScoredDocumentSlice(id int64, score int) (slice []ScoredDocument) {
 // ======================================= Crud ======================================== //
 
 // New inicializa a criacao de um indice invertido utilizando um controler por interface o
-// campo a ser indexado, o endereco do arquivo e o threshold de limiar para palavras repetidas
+// campo a ser indexado, o endereco do arquivo, o threshold de limiar para palavras repetidas
+// e o nome do analisador do campo (ver Analyzers), que fica gravado no indice.
+// Sem analisador ("") é usado o DEFAULT_ANALYZER
 //
 // # Interfaces necessarias
 //
@@ -227,7 +212,14 @@ func NewScoredDocumentSlice(id int64, score int) (slice []ScoredDocument) {
 // Objeto:
 //
 //	GetField(fieldName string) string
-func New(controler Reader, fieldToIndex string, path string, removeFrequency float64) error {
+func New(controler Reader, fieldToIndex string, path string, removeFrequency float64, analyzer string) error {
+	if analyzer == "" {
+		analyzer = DEFAULT_ANALYZER
+	}
+	analyze, err := getAnalyzer(analyzer)
+	if err != nil {
+		return err
+	}
 	invIndex := NewInvertedIndex()
 
 	for {
@@ -243,16 +235,22 @@ func New(controler Reader, fieldToIndex string, path string, removeFrequency flo
 
 		if !isDead {
 			content := obj.GetField(fieldToIndex)
-			words := Tokenize(content)
+			words := analyze(content)
 			id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
 			invIndex.AddDocument(id, words)
 		}
 	}
 
-	invIndex.removeThreshold = removeFrequency
-	invIndex.RemoveHighFrequencyTerms(removeFrequency)
+	removed := invIndex.RemoveHighFrequencyTerms(removeFrequency)
+
+	// Substitui todos os segmentos do campo por um unico segmento base
+	lock := fieldLock(fieldDir(path, fieldToIndex))
+	lock.Lock()
+	defer lock.Unlock()
+	os.Remove(filepath.Join(path, INDEX_DIR, fieldToIndex+".bin"))
+	_, err = createSegments(path, fieldToIndex, &invIndex, analyzer, removeFrequency, removed)
 
-	return invIndex.writeFile(path, fieldToIndex)
+	return err
 }
 
 // Merge realiza a uniao de diferentes listas de scoredDocuments pelos seus IDS
@@ -260,7 +258,7 @@ func New(controler Reader, fieldToIndex string, path string, removeFrequency flo
 //
 // Por fim os documentos finais sao ordenados de acordo com o score resultado e retornados
 func Merge(scoredDocumentsLists ...[]ScoredDocument) []ScoredDocument {
-	scoreMap := make(map[int64]int)
+	scoreMap := make(map[int64]float64)
 
 	// Soma os scores dos documentos em todas as listas
 	for _, scoredDocuments := range scoredDocumentsLists {
@@ -276,117 +274,301 @@ func Merge(scoredDocumentsLists ...[]ScoredDocument) []ScoredDocument {
 	}
 
 	// Ordena o slice de acordo com a pontuação em ordem decrescente
-	sort.Slice(mergedScoredDocuments, func(i, j int) bool {
-		return mergedScoredDocuments[i].Score > mergedScoredDocuments[j].Score
-	})
+	sortScoredDocuments(mergedScoredDocuments)
 
 	return mergedScoredDocuments
 }
 
-// Create faz a abertura de um indice invertido e adiciona um valor a ele
+// Create adiciona um objeto aos indices invertidos dos campos fornecidos,
+// gravando um segmento delta para cada campo
 func Create(myObj any, path string, fields ...string) error {
 	obj, ok := myObj.(IndexableObject)
 	if !ok {
 		return fmt.Errorf("failed to convert object to IndexableObject")
 	}
 
-	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+	return writeDocument(obj, path, fields...)
+}
 
-	for _, field := range fields {
-		// Ler o índice invertido atual do arquivo
-		invIndex := readFile(field, path)
+// Read realiza a busca de um documento em um arquivo, pontuando cada
+// documento encontrado com BM25:
+//
+//	score = Σ idf(t) * tf * (K1 + 1) / (tf + K1 * (1 - B + B * dl / avgdl))
+//	idf(t) = ln(1 + (N - df + 0.5) / (df + 0.5))
+//
+// Onde tf é a frequencia do termo no documento, dl o tamanho do documento,
+// avgdl o tamanho medio, N a quantidade de documentos e df a quantidade de
+// documentos que contem o termo.
+//
+// Frases entre aspas e clausulas NEAR/n (ver query.go) sao pontuadas da mesma
+// forma, como se fossem um unico termo, e os documentos que as satisfazem
+// recebem ainda o maior score obtido apenas pelos termos soltos, ficando
+// sempre a frente dos demais
+func Read(path string, field string, keys ...string) (scoredDocuments []ScoredDocument) {
+	return search(path, field, false, keys...)
+}
+
+// Match realiza a mesma busca de Read, retornando apenas os documentos que
+// contem todos os termos e satisfazem todas as frases e clausulas NEAR/n
+func Match(path string, field string, keys ...string) (scoredDocuments []ScoredDocument) {
+	return search(path, field, true, keys...)
+}
 
-		// Adicionar o novo documento ao índice invertido
-		content := obj.GetField(field)
-		words := Tokenize(content)
-		invIndex.AddDocument(id, words)
+// search pontua os documentos de uma consulta. Se 'strict' for verdadeiro,
+// descarta os documentos que nao satisfazem todas as partes da consulta
+func search(path string, field string, strict bool, keys ...string) (scoredDocuments []ScoredDocument) {
+	segments, m, done, err := view(path, field)
+	if err != nil {
+		fmt.Println("Error reading inverted index:", err)
+		return
+	}
+	defer done()
 
-		// Escrever o índice invertido atualizado de volta ao arquivo
-		err := invIndex.writeFile(path, field)
-		if err != nil {
-			fmt.Printf("error creating field '%s': %v", field, err)
-			return fmt.Errorf("error creating field '%s': %v", field, err)
+	analyze, err := getAnalyzer(m.analyzer)
+	if err != nil {
+		fmt.Println("Error reading inverted index:", err)
+		return
+	}
+	q := parseQuery(strings.Join(keys, " "), analyze, m)
+
+	n := float64(m.documents)
+	avgdl := m.averageLength()
+	scores := make(map[int64]float64)
+	lengths := make(map[int64]float64)
+	positions := make(map[string]map[int64][]int)
+	hits := make(map[int64]int) // Partes da consulta satisfeitas por documento
+
+	// Lambda para a pontuacao BM25 de um termo, frase ou clausula
+	// dada a sua frequencia em cada documento
+	score := func(frequencies map[int64]int) {
+		df := float64(len(frequencies))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, frequency := range frequencies {
+			dl, ok := lengths[id]
+			if !ok {
+				length, _ := lengthAll(segments, id)
+				dl = float64(length)
+				lengths[id] = dl
+			}
+
+			norm := 1.0
+			if avgdl > 0 {
+				norm = 1 - BM25_B + BM25_B*dl/avgdl
+			}
+			tf := float64(frequency)
+			scores[id] += idf * tf * (BM25_K1 + 1) / (tf + BM25_K1*norm)
+			hits[id]++
 		}
 	}
 
-	return nil
-}
+	// Termos soltos
+	for _, term := range q.terms {
+		frequencies := make(map[int64]int)
+		positions[term] = make(map[int64][]int)
+		for _, posting := range lookupAll(segments, term) {
+			frequencies[posting.DocumentID] = posting.Frequency
+			positions[term][posting.DocumentID] = posting.Positions
+		}
+		score(frequencies)
+	}
 
-// Read realiza a busca de um documento em um arquivo
-func Read(path string, field string, keys ...string) (scoredDocuments []ScoredDocument) {
-	invIndex := readFile(field, path)
+	maxScore := 0.0
+	for _, s := range scores {
+		maxScore = math.Max(maxScore, s)
+	}
+
+	// Frases e proximidades
+	matched := make(map[int64]bool)
+	for _, ph := range q.phrases {
+		frequencies := make(map[int64]int)
+		for id, starts := range ph.occurrences(positions) {
+			frequencies[id] = len(starts)
+			matched[id] = true
+		}
+		score(frequencies)
+	}
+	for _, clause := range q.near {
+		frequencies := clause.matches(positions)
+		for id := range frequencies {
+			matched[id] = true
+		}
+		score(frequencies)
+	}
+	for id := range matched {
+		scores[id] += maxScore
+	}
 
-	rawKeys := strings.Join(keys, " ")
-	token := Tokenize(rawKeys)
+	// Criar um slice de ScoredDocument e ordenar por score em ordem decrescente
+	parts := len(q.terms) + len(q.phrases) + len(q.near)
+	scoredDocuments = make([]ScoredDocument, 0, len(scores))
+	for id, score := range scores {
+		if strict && hits[id] < parts {
+			continue
+		}
+		scoredDocuments = append(scoredDocuments, ScoredDocument{DocumentID: id, Score: score})
+	}
+	sortScoredDocuments(scoredDocuments)
 
-	// Armazenar a soma das frequências para cada DocumentID
-	frequencies := make(map[int64]int)
+	return scoredDocuments
+}
 
-	for _, key := range token {
-		postings, found := invIndex.Index[key]
-		if found {
-			for _, posting := range postings {
-				frequencies[posting.DocumentID] += posting.Frequency
+// Vocabulary retorna, em ordem alfabetica, os termos presentes nos segmentos
+// de um campo. Termos cujos documentos foram todos removidos podem continuar
+// na lista ate a proxima compactacao
+func Vocabulary(path string, field string) ([]string, error) {
+	segments, _, done, err := view(path, field)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	seen := make(map[string]bool)
+	terms := make([]string, 0)
+	for _, s := range segments {
+		for i := int64(0); i < s.termCount; i++ {
+			term, _, _, _ := s.entry(i)
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
 			}
 		}
 	}
+	sort.Strings(terms)
 
-	// Criar um slice de ScoredDocument e preencher com DocumentID e soma das frequências
-	scoredDocuments = make([]ScoredDocument, 0, len(frequencies))
-	for id, freq := range frequencies {
-		scoredDocuments = append(scoredDocuments, ScoredDocument{DocumentID: id, Score: freq})
-	}
+	return terms, nil
+}
 
-	// Ordenar o slice de ScoredDocument por score em ordem decrescente
-	sort.Slice(scoredDocuments, func(i, j int) bool {
-		return scoredDocuments[i].Score > scoredDocuments[j].Score
-	})
+// Count retorna, para cada termo de um campo, a quantidade de documentos do
+// conjunto fornecido que o contem. Termos sem nenhum documento do conjunto
+// nao sao retornados.
+//
+// Cada lista de postings é lida uma unica vez, percorrendo os segmentos do
+// mais novo para o mais antigo e ignorando os documentos removidos ou
+// substituidos por um segmento mais novo
+func Count(path string, field string, documents map[int64]bool) (map[string]int, error) {
+	segments, _, done, err := view(path, field)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	counts := make(map[string]int)
+	masked := make(map[int64]bool)
+	for i := len(segments) - 1; i >= 0; i-- {
+		s := segments[i]
+		for j := int64(0); j < s.termCount; j++ {
+			term, offset, size, df := s.entry(j)
+			for _, posting := range s.postings(offset, size, df) {
+				if documents[posting.DocumentID] && !masked[posting.DocumentID] {
+					counts[term]++
+				}
+			}
+		}
+		for id := range s.tombstones {
+			masked[id] = true
+		}
+	}
 
-	return scoredDocuments
+	return counts, nil
 }
 
-// Update realiza a atualizacao de um objeto indexavel no indice invertido
-func Update(obj IndexableObject, path string, fields ...string) error {
-	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+// Analyze converte um texto em termos com o analisador do indice de um campo
+func Analyze(path string, field string, text string) ([]string, error) {
+	_, m, done, err := view(path, field)
+	if err != nil {
+		return nil, err
+	}
+	done()
 
-	for _, field := range fields {
-		// Ler o índice invertido atual do arquivo
-		invIndex := readFile(field, path)
+	analyze, err := getAnalyzer(m.analyzer)
+	if err != nil {
+		return nil, err
+	}
+	return analyze(text), nil
+}
 
-		// Remover o documento existente do índice invertido
-		invIndex.RemoveDocument(id)
+// IndexedTerms retorna um Analyzer que converte um texto nos termos distintos
+// que o indice de um campo grava para ele: os termos do analisador do campo,
+// sem repeticoes e sem os termos removidos por alta frequencia
+func IndexedTerms(path string, field string) (Analyzer, error) {
+	_, m, done, err := view(path, field)
+	if err != nil {
+		return nil, err
+	}
+	done()
 
-		// Tokenizar o novo conteúdo do campo e adicionar o novo documento ao índice invertido
-		content := obj.GetField(field)
-		words := Tokenize(content)
-		invIndex.AddDocument(id, words)
+	analyze, err := getAnalyzer(m.analyzer)
+	if err != nil {
+		return nil, err
+	}
+	stop := make(map[string]bool, len(m.stopTerms))
+	for _, term := range m.stopTerms {
+		stop[term] = true
+	}
 
-		// Escrever o índice invertido atualizado de volta ao arquivo
-		invIndex.RemoveHighFrequencyTerms(invIndex.removeThreshold)
-		err := invIndex.writeFile(path, field)
-		if err != nil {
-			return fmt.Errorf("error updating field '%s': %v", field, err)
+	return func(text string) []string {
+		seen := make(map[string]bool)
+		terms := make([]string, 0)
+		for _, term := range analyze(text) {
+			if !seen[term] && !stop[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
 		}
+		return terms
+	}, nil
+}
+
+// Boost multiplica o score de todos os documentos por um peso
+func Boost(scoredDocuments []ScoredDocument, weight float64) []ScoredDocument {
+	for i := range scoredDocuments {
+		scoredDocuments[i].Score *= weight
 	}
+	return scoredDocuments
+}
 
-	return nil
+// sortScoredDocuments ordena os documentos por score decrescente,
+// desempatando pelo ID para manter o resultado deterministico
+func sortScoredDocuments(scoredDocuments []ScoredDocument) {
+	sort.Slice(scoredDocuments, func(i, j int) bool {
+		if scoredDocuments[i].Score != scoredDocuments[j].Score {
+			return scoredDocuments[i].Score > scoredDocuments[j].Score
+		}
+		return scoredDocuments[i].DocumentID < scoredDocuments[j].DocumentID
+	})
+}
+
+// Update realiza a atualizacao de um objeto indexavel no indice invertido.
+// O segmento delta remove a versao antiga do documento e contem a nova
+func Update(obj IndexableObject, path string, fields ...string) error {
+	return writeDocument(obj, path, fields...)
 }
 
-// Delete remove um objeto de um indice invertido
+// Delete remove um objeto de um indice invertido, gravando um segmento
+// delta contendo apenas sua remocao
 func Delete(obj IndexableObject, path string, fields ...string) error {
 	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
 
 	for _, field := range fields {
-		// Ler o índice invertido atual do arquivo
-		invIndex := readFile(field, path)
+		if err := appendSegment(path, field, nil, []int64{id}); err != nil {
+			return fmt.Errorf("error deleting field '%s': %v", field, err)
+		}
+	}
 
-		// Remover o documento existente do índice invertido
-		invIndex.RemoveDocument(id)
+	return nil
+}
 
-		// Escrever o índice invertido atualizado de volta ao arquivo
-		err := invIndex.writeFile(path, field)
-		if err != nil {
-			return fmt.Errorf("error deleting field '%s': %v", field, err)
+// writeDocument grava, para cada campo, um segmento delta contendo o
+// conteudo atual do documento e a remocao de qualquer versao anterior.
+// O conteudo é analisado com o analisador gravado no indice do campo
+func writeDocument(obj IndexableObject, path string, fields ...string) error {
+	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+
+	for _, field := range fields {
+		contents := map[int64]string{id: obj.GetField(field)}
+		if err := appendSegment(path, field, contents, []int64{id}); err != nil {
+			return fmt.Errorf("error writing field '%s': %v", field, err)
 		}
 	}
 
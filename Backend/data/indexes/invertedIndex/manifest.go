@@ -0,0 +1,364 @@
+// O arquivo manifest do pacote invertedIndex controla o conjunto de segmentos
+// de cada campo indexado.
+//
+// Cada campo possui um diretorio com seus segmentos e um manifesto listando os
+// segmentos ativos, do mais antigo para o mais novo. Toda escrita grava um novo
+// segmento delta e o acrescenta ao manifesto, que é substituido de forma
+// atomica. Quando a quantidade de segmentos ultrapassa MAX_SEGMENTS eles sao
+// juntados em um unico segmento em segundo plano (Compact).
+package invertedIndex
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Arquivos e limites do indice em segmentos
+const (
+	INDEX_DIR     string = "invertedIndex"
+	MANIFEST_FILE string = "manifest.bin"
+	MAX_SEGMENTS  int    = 8
+)
+
+// manifest lista os segmentos ativos de um campo e os dados
+// compartilhados entre eles
+type manifest struct {
+	removeThreshold float64  // Limiar de RemoveHighFrequencyTerms
+	nextSegment     int64    // Identificador do proximo segmento
+	segments        []int64  // Segmentos ativos, do mais antigo para o mais novo
+	stopTerms       []string // Termos removidos por alta frequencia
+	documents       int64    // Documentos indexados
+	totalLength     int64    // Soma do tamanho dos documentos
+	analyzer        string   // Nome do analisador do campo (ver Analyzers)
+}
+
+// Locks por diretorio de campo: leituras compartilham o lock, escritas e a
+// troca de segmentos da compactacao o tomam exclusivamente
+var (
+	locksMutex sync.Mutex
+	locks      = make(map[string]*sync.RWMutex)
+	compacting = make(map[string]bool)
+)
+
+// fieldDir retorna o diretorio dos segmentos de um campo
+func fieldDir(path string, field string) string {
+	return filepath.Join(path, INDEX_DIR, field)
+}
+
+// fieldLock retorna o lock do diretorio de um campo
+func fieldLock(dir string) *sync.RWMutex {
+	locksMutex.Lock()
+	defer locksMutex.Unlock()
+
+	lock, ok := locks[dir]
+	if !ok {
+		lock = &sync.RWMutex{}
+		locks[dir] = lock
+	}
+	return lock
+}
+
+// readManifest lê o manifesto de um campo. Indices de versoes anteriores,
+// sem manifesto, precisam ser recriados com /loadDatabase
+func readManifest(path string, field string) (*manifest, error) {
+	dir := fieldDir(path, field)
+	buffer, err := os.ReadFile(filepath.Join(dir, MANIFEST_FILE))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("inverted index for '%s' not found, run /loadDatabase", field)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ptr int
+	m := &manifest{}
+	m.removeThreshold, ptr = utils.BytesToFloat64(buffer, ptr)
+	m.nextSegment, ptr = utils.BytesToInt64(buffer, ptr)
+
+	count, ptr := utils.BytesToInt64(buffer, ptr)
+	m.segments = make([]int64, count)
+	for i := range m.segments {
+		m.segments[i], ptr = utils.BytesToInt64(buffer, ptr)
+	}
+
+	count, ptr = utils.BytesToInt64(buffer, ptr)
+	m.stopTerms = make([]string, count)
+	for i := range m.stopTerms {
+		m.stopTerms[i], ptr = utils.BytesToString(buffer, ptr)
+	}
+
+	m.documents, ptr = utils.BytesToInt64(buffer, ptr)
+	m.totalLength, ptr = utils.BytesToInt64(buffer, ptr)
+	m.analyzer, _ = utils.BytesToString(buffer, ptr)
+
+	return m, nil
+}
+
+// write grava o manifesto em um arquivo temporario e o renomeia por cima
+// do atual, para que um leitor nunca encontre um manifesto pela metade
+func (m *manifest) write(dir string) error {
+	tmpPath := filepath.Join(dir, MANIFEST_FILE+".tmp")
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("error creating manifest: %s", err)
+	}
+
+	w := bufio.NewWriter(file)
+	binary.Write(w, binary.LittleEndian, m.removeThreshold)
+	binary.Write(w, binary.LittleEndian, m.nextSegment)
+	binary.Write(w, binary.LittleEndian, int64(len(m.segments)))
+	binary.Write(w, binary.LittleEndian, m.segments)
+	binary.Write(w, binary.LittleEndian, int64(len(m.stopTerms)))
+	for _, term := range m.stopTerms {
+		binary.Write(w, binary.LittleEndian, int32(len(term)))
+		binary.Write(w, binary.LittleEndian, []byte(term))
+	}
+	binary.Write(w, binary.LittleEndian, m.documents)
+	binary.Write(w, binary.LittleEndian, m.totalLength)
+	binary.Write(w, binary.LittleEndian, int32(len(m.analyzer)))
+	binary.Write(w, binary.LittleEndian, []byte(m.analyzer))
+	w.Flush()
+	file.Close()
+
+	return os.Rename(tmpPath, filepath.Join(dir, MANIFEST_FILE))
+}
+
+// setTotals define os totais da colecao a partir de um indice completo
+func (m *manifest) setTotals(ii *InvertedIndex) {
+	m.documents = int64(len(ii.Lengths))
+	m.totalLength = 0
+	for _, length := range ii.Lengths {
+		m.totalLength += length
+	}
+}
+
+// averageLength retorna o tamanho medio dos documentos da colecao
+func (m *manifest) averageLength() float64 {
+	if m.documents == 0 {
+		return 0
+	}
+	return float64(m.totalLength) / float64(m.documents)
+}
+
+// isStopTerm verifica se um termo foi removido por alta frequencia
+func (m *manifest) isStopTerm(term string) bool {
+	for _, stop := range m.stopTerms {
+		if stop == term {
+			return true
+		}
+	}
+	return false
+}
+
+// addStopTerms acrescenta termos ainda nao presentes na lista de removidos
+func (m *manifest) addStopTerms(terms []string) {
+	for _, term := range terms {
+		if !m.isStopTerm(term) {
+			m.stopTerms = append(m.stopTerms, term)
+		}
+	}
+}
+
+// createSegments substitui todos os segmentos de um campo por um unico
+// segmento com o conteudo do indice em memoria, gerado pelo analisador fornecido
+func createSegments(path string, field string, ii *InvertedIndex, analyzer string, removeThreshold float64, stopTerms []string) (*manifest, error) {
+	dir := fieldDir(path, field)
+	os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	m := &manifest{
+		removeThreshold: removeThreshold,
+		nextSegment:     1,
+		segments:        []int64{0},
+		stopTerms:       stopTerms,
+		analyzer:        analyzer,
+	}
+	m.setTotals(ii)
+	if err := writeSegment(segmentPath(dir, 0), ii, nil); err != nil {
+		return nil, err
+	}
+
+	return m, m.write(dir)
+}
+
+// appendSegment grava um segmento delta com os documentos fornecidos, analisados
+// pelo analisador do campo, e as remocoes fornecidas, agendando uma compactacao
+// se necessario
+func appendSegment(path string, field string, contents map[int64]string, tombstones []int64) error {
+	dir := fieldDir(path, field)
+	lock := fieldLock(dir)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m, err := readManifest(path, field)
+	if err != nil {
+		return err
+	}
+
+	analyze, err := getAnalyzer(m.analyzer)
+	if err != nil {
+		return err
+	}
+	ii := NewInvertedIndex()
+	for id, content := range contents {
+		ii.AddDocument(id, analyze(content))
+	}
+	for _, stop := range m.stopTerms {
+		delete(ii.Index, stop)
+	}
+
+	// Atualiza os totais da colecao: documentos removidos ou substituidos
+	// deixam de contar, e os documentos do novo segmento passam a contar
+	segments, err := openSegments(dir, m.segments)
+	if err != nil {
+		return err
+	}
+	for _, removed := range tombstones {
+		if length, ok := lengthAll(segments, removed); ok {
+			m.documents--
+			m.totalLength -= length
+		}
+	}
+	closeSegments(segments)
+	for _, length := range ii.Lengths {
+		m.documents++
+		m.totalLength += length
+	}
+
+	id := m.nextSegment
+	if err := writeSegment(segmentPath(dir, id), &ii, tombstones); err != nil {
+		return err
+	}
+	m.nextSegment++
+	m.segments = append(m.segments, id)
+	if err := m.write(dir); err != nil {
+		return err
+	}
+
+	if len(m.segments) > MAX_SEGMENTS {
+		scheduleCompaction(path, field)
+	}
+
+	return nil
+}
+
+// scheduleCompaction inicia uma compactacao em segundo plano, caso
+// nao exista outra em andamento para o mesmo campo
+func scheduleCompaction(path string, field string) {
+	dir := fieldDir(path, field)
+
+	locksMutex.Lock()
+	defer locksMutex.Unlock()
+	if compacting[dir] {
+		return
+	}
+	compacting[dir] = true
+
+	go func() {
+		if err := Compact(path, field); err != nil {
+			fmt.Println("Error compacting inverted index:", err)
+		}
+
+		locksMutex.Lock()
+		delete(compacting, dir)
+		locksMutex.Unlock()
+	}()
+}
+
+// Compact junta todos os segmentos de um campo em um unico segmento,
+// aplicando as remocoes e o limiar de termos de alta frequencia.
+//
+// Os segmentos sao lidos sem bloquear novas escritas. Segmentos gravados
+// durante a compactacao sao mantidos apos o segmento resultante
+func Compact(path string, field string) error {
+	dir := fieldDir(path, field)
+	lock := fieldLock(dir)
+
+	// Reserva o identificador do novo segmento
+	lock.Lock()
+	m, err := readManifest(path, field)
+	if err != nil {
+		lock.Unlock()
+		return err
+	}
+	snapshot := m.segments
+	id := m.nextSegment
+	m.nextSegment++
+	err = m.write(dir)
+	lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Segmentos sao imutaveis, entao podem ser lidos sem o lock
+	segments, err := openSegments(dir, snapshot)
+	if err != nil {
+		return err
+	}
+	ii := loadAll(segments)
+	closeSegments(segments)
+
+	removed := ii.RemoveHighFrequencyTerms(m.removeThreshold)
+	if err := writeSegment(segmentPath(dir, id), ii, nil); err != nil {
+		return err
+	}
+
+	// Troca os segmentos compactados pelo novo segmento
+	lock.Lock()
+	defer lock.Unlock()
+
+	current, err := readManifest(path, field)
+	if err != nil || len(current.segments) < len(snapshot) {
+		os.Remove(segmentPath(dir, id))
+		return fmt.Errorf("inverted index for '%s' changed during compaction", field)
+	}
+	for i := range snapshot {
+		if current.segments[i] != snapshot[i] {
+			os.Remove(segmentPath(dir, id))
+			return fmt.Errorf("inverted index for '%s' changed during compaction", field)
+		}
+	}
+
+	current.segments = append([]int64{id}, current.segments[len(snapshot):]...)
+	current.addStopTerms(removed)
+	if err := current.write(dir); err != nil {
+		return err
+	}
+
+	for _, old := range snapshot {
+		os.Remove(segmentPath(dir, old))
+	}
+
+	return nil
+}
+
+// view abre os segmentos ativos de um campo para leitura. A funcao
+// retornada fecha os segmentos e libera o lock
+func view(path string, field string) ([]*segment, *manifest, func(), error) {
+	dir := fieldDir(path, field)
+	lock := fieldLock(dir)
+
+	lock.RLock()
+	m, err := readManifest(path, field)
+	if err != nil {
+		lock.RUnlock()
+		return nil, nil, nil, err
+	}
+	segments, err := openSegments(dir, m.segments)
+	if err != nil {
+		lock.RUnlock()
+		return nil, nil, nil, err
+	}
+
+	return segments, m, func() {
+		closeSegments(segments)
+		lock.RUnlock()
+	}, nil
+}
@@ -0,0 +1,277 @@
+// O arquivo porter do pacote invertedIndex implementa o algoritmo de stemming
+// de Martin Porter (1980) para palavras em ingles, reduzindo variacoes de uma
+// palavra ao mesmo radical: "burns", "burned" e "burning" viram "burn".
+//
+// A implementacao segue a versao de referencia em C do autor, trabalhando
+// sobre um buffer de bytes onde 'k' marca o fim da palavra e 'j' o fim do
+// radical encontrado pelo ultimo sufixo reconhecido.
+package invertedIndex
+
+// porter guarda o estado do stemming de uma palavra
+type porter struct {
+	b []byte
+	k int
+	j int
+}
+
+// Regras de cada passo, indexadas pelo penultimo (step2 e step4) ou
+// ultimo (step3) caractere da palavra
+var step2Rules = map[byte][][2]string{
+	'a': {{"ational", "ate"}, {"tional", "tion"}},
+	'c': {{"enci", "ence"}, {"anci", "ance"}},
+	'e': {{"izer", "ize"}},
+	'l': {{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"}},
+	'o': {{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}},
+	's': {{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"}},
+	't': {{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}},
+	'g': {{"logi", "log"}},
+}
+
+var step3Rules = map[byte][][2]string{
+	'e': {{"icate", "ic"}, {"ative", ""}, {"alize", "al"}},
+	'i': {{"iciti", "ic"}},
+	'l': {{"ical", "ic"}, {"ful", ""}},
+	's': {{"ness", ""}},
+}
+
+var step4Suffixes = map[byte][]string{
+	'a': {"al"},
+	'c': {"ance", "ence"},
+	'e': {"er"},
+	'i': {"ic"},
+	'l': {"able", "ible"},
+	'n': {"ant", "ement", "ment", "ent"},
+	's': {"ism"},
+	't': {"ate", "iti"},
+	'u': {"ous"},
+	'v': {"ive"},
+	'z': {"ize"},
+}
+
+// Stem retorna o radical de uma palavra em ingles minuscula. Palavras com
+// ate dois caracteres ou com caracteres fora de a-z sao retornadas intactas
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	p := &porter{b: []byte(word), k: len(word) - 1}
+	p.step1ab()
+	if p.k > 0 {
+		p.step1c()
+		p.step2()
+		p.step3()
+		p.step4()
+		p.step5()
+	}
+
+	return string(p.b[:p.k+1])
+}
+
+// cons verifica se b[i] é uma consoante
+func (p *porter) cons(i int) bool {
+	switch p.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		if i == 0 {
+			return true
+		}
+		return !p.cons(i - 1)
+	}
+	return true
+}
+
+// m conta as sequencias vogal-consoante em b[0..j]:
+//
+//	<c><v>       -> 0
+//	<c>vc<v>     -> 1
+//	<c>vcvc<v>   -> 2
+func (p *porter) m() int {
+	n, i := 0, 0
+	for {
+		if i > p.j {
+			return n
+		}
+		if !p.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > p.j {
+				return n
+			}
+			if p.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > p.j {
+				return n
+			}
+			if !p.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem verifica se b[0..j] contem uma vogal
+func (p *porter) vowelInStem() bool {
+	for i := 0; i <= p.j; i++ {
+		if !p.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doublec verifica se b[j-1..j] é uma consoante dupla
+func (p *porter) doublec(j int) bool {
+	if j < 1 || p.b[j] != p.b[j-1] {
+		return false
+	}
+	return p.cons(j)
+}
+
+// cvc verifica se b[i-2..i] é consoante-vogal-consoante e a ultima
+// consoante nao é w, x ou y, como em "hop" (hoping -> hope)
+func (p *porter) cvc(i int) bool {
+	if i < 2 || !p.cons(i) || p.cons(i-1) || !p.cons(i-2) {
+		return false
+	}
+	ch := p.b[i]
+	return ch != 'w' && ch != 'x' && ch != 'y'
+}
+
+// ends verifica se b[0..k] termina com s, posicionando j antes do sufixo
+func (p *porter) ends(s string) bool {
+	length := len(s)
+	if length > p.k+1 || string(p.b[p.k-length+1:p.k+1]) != s {
+		return false
+	}
+	p.j = p.k - length
+	return true
+}
+
+// setto substitui b[j+1..k] por s
+func (p *porter) setto(s string) {
+	p.b = append(p.b[:p.j+1], s...)
+	p.k = p.j + len(s)
+}
+
+// r substitui o sufixo por s se o radical tiver m > 0
+func (p *porter) r(s string) {
+	if p.m() > 0 {
+		p.setto(s)
+	}
+}
+
+// step1ab remove plurais e os sufixos -ed e -ing
+func (p *porter) step1ab() {
+	if p.b[p.k] == 's' {
+		if p.ends("sses") {
+			p.k -= 2
+		} else if p.ends("ies") {
+			p.setto("i")
+		} else if p.b[p.k-1] != 's' {
+			p.k--
+		}
+	}
+
+	if p.ends("eed") {
+		if p.m() > 0 {
+			p.k--
+		}
+	} else if (p.ends("ed") || p.ends("ing")) && p.vowelInStem() {
+		p.k = p.j
+		if p.ends("at") {
+			p.setto("ate")
+		} else if p.ends("bl") {
+			p.setto("ble")
+		} else if p.ends("iz") {
+			p.setto("ize")
+		} else if p.doublec(p.k) {
+			p.k--
+			ch := p.b[p.k]
+			if ch == 'l' || ch == 's' || ch == 'z' {
+				p.k++
+			}
+		} else if p.m() == 1 && p.cvc(p.k) {
+			p.setto("e")
+		}
+	}
+}
+
+// step1c troca o y final por i quando existe outra vogal no radical
+func (p *porter) step1c() {
+	if p.ends("y") && p.vowelInStem() {
+		p.b[p.k] = 'i'
+	}
+}
+
+// step2 reduz sufixos duplos a sufixos simples, como -ization para -ize
+func (p *porter) step2() {
+	p.replaceSuffix(step2Rules[p.b[p.k-1]])
+}
+
+// step3 trata os sufixos -ic-, -full, -ness etc
+func (p *porter) step3() {
+	p.replaceSuffix(step3Rules[p.b[p.k]])
+}
+
+// replaceSuffix aplica a primeira regra cujo sufixo termina a palavra
+func (p *porter) replaceSuffix(rules [][2]string) {
+	for _, rule := range rules {
+		if p.ends(rule[0]) {
+			p.r(rule[1])
+			return
+		}
+	}
+}
+
+// step4 remove -ant, -ence etc quando o radical tem m > 1
+func (p *porter) step4() {
+	found := false
+	if p.b[p.k-1] == 'o' {
+		found = (p.ends("ion") && p.j >= 0 && (p.b[p.j] == 's' || p.b[p.j] == 't')) || p.ends("ou")
+	} else {
+		for _, suffix := range step4Suffixes[p.b[p.k-1]] {
+			if p.ends(suffix) {
+				found = true
+				break
+			}
+		}
+	}
+
+	if found && p.m() > 1 {
+		p.k = p.j
+	}
+}
+
+// step5 remove o -e final e reduz -ll para -l quando m > 1
+func (p *porter) step5() {
+	p.j = p.k
+	if p.b[p.k] == 'e' {
+		a := p.m()
+		if a > 1 || a == 1 && !p.cvc(p.k-1) {
+			p.k--
+		}
+	}
+	if p.b[p.k] == 'l' && p.doublec(p.k) && p.m() > 1 {
+		p.k--
+	}
+}
@@ -0,0 +1,149 @@
+// O arquivo query do pacote invertedIndex interpreta as consultas feitas ao
+// indice. Alem de palavras soltas, uma consulta aceita:
+//
+//	"seed on its back"   frase: os termos devem aparecer em sequencia
+//	fire NEAR/3 tail     proximidade: no maximo 3 posicoes entre os termos
+//
+// Frases e proximidades sao verificadas com as posicoes guardadas nas postings.
+// Os documentos que as satisfazem sao pontuados acima de qualquer documento que
+// contenha apenas os termos soltos.
+package invertedIndex
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// phrase é uma sequencia de termos analisados e a posicao de cada um
+// em relacao ao inicio do texto consultado
+type phrase struct {
+	terms   []string
+	offsets []int
+}
+
+// nearClause exige que duas frases estejam a no maximo 'distance' posicoes
+type nearClause struct {
+	left     phrase
+	right    phrase
+	distance int
+}
+
+// query é uma consulta interpretada
+type query struct {
+	terms   []string     // Termos distintos, pontuados individualmente
+	phrases []phrase     // Frases entre aspas com mais de um termo
+	near    []nearClause // Clausulas NEAR/n
+}
+
+// Elementos de uma consulta: texto entre aspas ou palavra solta
+var (
+	queryItemRegEx = regexp.MustCompile(`"([^"]*)"|(\S+)`)
+	nearRegEx      = regexp.MustCompile(`^NEAR/(\d+)$`)
+)
+
+// parseQuery interpreta o texto de uma consulta, analisando cada elemento com o
+// analisador do campo. Termos removidos por alta frequencia nao existem no
+// indice e sao descartados, mantendo a posicao relativa dos demais
+func parseQuery(text string, analyze Analyzer, m *manifest) query {
+	q := query{}
+	seen := make(map[string]bool)
+
+	var previous *phrase
+	nearDistance := -1
+
+	for _, match := range queryItemRegEx.FindAllStringSubmatch(text, -1) {
+		quoted := match[2] == ""
+		if !quoted {
+			if groups := nearRegEx.FindStringSubmatch(match[2]); groups != nil {
+				if previous != nil {
+					nearDistance, _ = strconv.Atoi(groups[1])
+				}
+				continue
+			}
+		}
+
+		ph := phrase{}
+		for offset, term := range analyze(match[1] + match[2]) {
+			if m.isStopTerm(term) {
+				continue
+			}
+			ph.terms = append(ph.terms, term)
+			ph.offsets = append(ph.offsets, offset)
+			if !seen[term] {
+				seen[term] = true
+				q.terms = append(q.terms, term)
+			}
+		}
+		if len(ph.terms) == 0 {
+			continue
+		}
+
+		if quoted && len(ph.terms) > 1 {
+			q.phrases = append(q.phrases, ph)
+		}
+		if nearDistance >= 0 {
+			q.near = append(q.near, nearClause{left: *previous, right: ph, distance: nearDistance})
+			nearDistance = -1
+		}
+		previous = &ph
+	}
+
+	return q
+}
+
+// width retorna a distancia entre o primeiro e o ultimo termo da frase
+func (ph phrase) width() int {
+	return ph.offsets[len(ph.offsets)-1] - ph.offsets[0]
+}
+
+// occurrences retorna, para cada documento, as posicoes em que a frase começa,
+// dadas as posicoes de cada termo em cada documento
+func (ph phrase) occurrences(positions map[string]map[int64][]int) map[int64][]int {
+	result := make(map[int64][]int)
+
+	for doc, starts := range positions[ph.terms[0]] {
+		for _, start := range starts {
+			found := true
+			for i := 1; i < len(ph.terms) && found; i++ {
+				found = containsPosition(positions[ph.terms[i]][doc], start+ph.offsets[i]-ph.offsets[0])
+			}
+			if found {
+				result[doc] = append(result[doc], start)
+			}
+		}
+	}
+
+	return result
+}
+
+// matches retorna, para cada documento, a quantidade de ocorrencias da frase da
+// esquerda que possuem uma ocorrencia da frase da direita a no maximo 'distance'
+// posicoes, antes ou depois dela
+func (n nearClause) matches(positions map[string]map[int64][]int) map[int64]int {
+	result := make(map[int64]int)
+	right := n.right.occurrences(positions)
+
+	for doc, lefts := range n.left.occurrences(positions) {
+		for _, l := range lefts {
+			for _, r := range right[doc] {
+				gap := r - (l + n.left.width())
+				if r < l {
+					gap = l - (r + n.right.width())
+				}
+				if gap >= 1 && gap <= n.distance {
+					result[doc]++
+					break
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// containsPosition verifica se uma lista ordenada de posicoes contem a posicao
+func containsPosition(positions []int, position int) bool {
+	i := sort.SearchInts(positions, position)
+	return i < len(positions) && positions[i] == position
+}
@@ -0,0 +1,394 @@
+// O arquivo segment do pacote invertedIndex implementa os segmentos em disco
+// do indice invertido.
+//
+// Um segmento é imutavel depois de gravado e possui o seguinte formato:
+//
+//	[postings]    para cada termo: (DocumentID int64, Frequency int64, n int32, Positions [n]int32)...
+//	[dicionario]  para cada termo, em ordem: termo, offset e tamanho das postings, df
+//	[tabela]      offset (int64) de cada entrada do dicionario
+//	[documentos]  (DocumentID int64, tamanho int64) dos documentos do segmento
+//	[remocoes]    DocumentID (int64) dos documentos removidos
+//	[rodape]      versao e offset/quantidade de cada secao
+//
+// A tabela de offsets permite uma busca binaria no dicionario direto no
+// arquivo, de forma que uma consulta lê apenas o rodape, algumas entradas
+// do dicionario e a lista de postings do termo procurado.
+//
+// Segmentos de outra versao sao rejeitados e o indice precisa ser
+// reconstruido.
+//
+// As remocoes de um segmento escondem os documentos dos segmentos mais antigos.
+// Uma alteracao de documento grava sua remocao e seu novo conteudo no mesmo
+// segmento.
+package invertedIndex
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// SEGMENT_VERSION é a versao do formato gravada no rodape dos segmentos
+const SEGMENT_VERSION int64 = 2
+
+// FOOTER_SIZE é o tamanho em bytes do rodape de um segmento
+const FOOTER_SIZE int64 = 7 * 8
+
+// segment é um segmento aberto para leitura. Apenas o rodape e as remocoes
+// ficam em memoria
+type segment struct {
+	file        *os.File
+	id          int64
+	version     int64
+	docsOffset  int64
+	docsCount   int64
+	tableOffset int64
+	termCount   int64
+	tombOffset  int64
+	tombCount   int64
+	tombstones  map[int64]bool
+}
+
+// segmentPath retorna o caminho do arquivo de um segmento
+func segmentPath(dir string, id int64) string {
+	return filepath.Join(dir, fmt.Sprintf("seg_%06d.bin", id))
+}
+
+// writeSegment grava um novo segmento com o conteudo de um indice em memoria
+// e a lista de documentos removidos
+func writeSegment(filePath string, ii *InvertedIndex, tombstones []int64) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating segment: %s", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	offset := int64(0)
+	write := func(data any) {
+		binary.Write(w, binary.LittleEndian, data)
+		offset += int64(binary.Size(data))
+	}
+
+	terms := make([]string, 0, len(ii.Index))
+	for term := range ii.Index {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	// Postings, ordenadas por documento, com as posicoes de cada ocorrencia
+	postingOffsets := make([]int64, len(terms))
+	postingSizes := make([]int64, len(terms))
+	for i, term := range terms {
+		postings := ii.Index[term]
+		sort.Slice(postings, func(a, b int) bool { return postings[a].DocumentID < postings[b].DocumentID })
+		postingOffsets[i] = offset
+		for _, p := range postings {
+			positions := make([]int32, len(p.Positions))
+			for j, position := range p.Positions {
+				positions[j] = int32(position)
+			}
+			write(p.DocumentID)
+			write(int64(p.Frequency))
+			write(int32(len(positions)))
+			write(positions)
+		}
+		postingSizes[i] = offset - postingOffsets[i]
+	}
+
+	// Dicionario
+	entryOffsets := make([]int64, len(terms))
+	for i, term := range terms {
+		entryOffsets[i] = offset
+		write(int32(len(term)))
+		write([]byte(term))
+		write(postingOffsets[i])
+		write(postingSizes[i])
+		write(int64(len(ii.Index[term])))
+	}
+
+	// Tabela de offsets do dicionario
+	tableOffset := offset
+	write(entryOffsets)
+
+	// Documentos e seus tamanhos
+	docs := make([]int64, 0, len(ii.Lengths))
+	for id := range ii.Lengths {
+		docs = append(docs, id)
+	}
+	sort.Slice(docs, func(a, b int) bool { return docs[a] < docs[b] })
+	docsOffset := offset
+	for _, id := range docs {
+		write(id)
+		write(ii.Lengths[id])
+	}
+
+	// Remocoes
+	sort.Slice(tombstones, func(a, b int) bool { return tombstones[a] < tombstones[b] })
+	tombOffset := offset
+	write(tombstones)
+
+	// Rodape
+	write(SEGMENT_VERSION)
+	write(docsOffset)
+	write(int64(len(docs)))
+	write(tableOffset)
+	write(int64(len(terms)))
+	write(tombOffset)
+	write(int64(len(tombstones)))
+
+	return w.Flush()
+}
+
+// openSegment abre um segmento, lendo seu rodape e suas remocoes
+func openSegment(dir string, id int64) (*segment, error) {
+	file, err := os.Open(segmentPath(dir, id))
+	if err != nil {
+		return nil, err
+	}
+
+	size, _ := file.Seek(0, io.SeekEnd)
+	if size < FOOTER_SIZE {
+		file.Close()
+		return nil, fmt.Errorf("invalid segment %d", id)
+	}
+
+	footer := make([]byte, FOOTER_SIZE)
+	file.ReadAt(footer, size-FOOTER_SIZE)
+
+	s := &segment{file: file, id: id}
+	ptr := 0
+	s.version, ptr = utils.BytesToInt64(footer, ptr)
+	s.docsOffset, ptr = utils.BytesToInt64(footer, ptr)
+	s.docsCount, ptr = utils.BytesToInt64(footer, ptr)
+	s.tableOffset, ptr = utils.BytesToInt64(footer, ptr)
+	s.termCount, ptr = utils.BytesToInt64(footer, ptr)
+	s.tombOffset, ptr = utils.BytesToInt64(footer, ptr)
+	s.tombCount, _ = utils.BytesToInt64(footer, ptr)
+
+	if s.version != SEGMENT_VERSION {
+		file.Close()
+		return nil, fmt.Errorf("unsupported segment version %d", s.version)
+	}
+
+	s.tombstones = make(map[int64]bool, s.tombCount)
+	buf := make([]byte, s.tombCount*8)
+	file.ReadAt(buf, s.tombOffset)
+	for i, ptr := int64(0), 0; i < s.tombCount; i++ {
+		var id int64
+		id, ptr = utils.BytesToInt64(buf, ptr)
+		s.tombstones[id] = true
+	}
+
+	return s, nil
+}
+
+// close fecha o arquivo do segmento
+func (s *segment) close() {
+	s.file.Close()
+}
+
+// entry lê a i-esima entrada do dicionario, retornando o termo,
+// o offset e o tamanho em bytes de suas postings e a quantidade de documentos
+func (s *segment) entry(i int64) (term string, offset int64, size int64, df int64) {
+	buf := make([]byte, 8)
+	s.file.ReadAt(buf, s.tableOffset+i*8)
+	address, _ := utils.BytesToInt64(buf, 0)
+
+	s.file.ReadAt(buf[:4], address)
+	length := int64(binary.LittleEndian.Uint32(buf[:4]))
+
+	buf = make([]byte, length+24)
+	s.file.ReadAt(buf, address+4)
+	term = string(buf[:length])
+	offset, ptr := utils.BytesToInt64(buf, int(length))
+	size, ptr = utils.BytesToInt64(buf, ptr)
+	df, _ = utils.BytesToInt64(buf, ptr)
+
+	return
+}
+
+// postings lê uma lista de postings dado seu offset, tamanho em bytes
+// e quantidade de documentos
+func (s *segment) postings(offset int64, size int64, df int64) []Posting {
+	buf := make([]byte, size)
+	s.file.ReadAt(buf, offset)
+
+	postings := make([]Posting, df)
+	for i, ptr := 0, 0; i < int(df); i++ {
+		var freq int64
+		postings[i].DocumentID, ptr = utils.BytesToInt64(buf, ptr)
+		freq, ptr = utils.BytesToInt64(buf, ptr)
+		postings[i].Frequency = int(freq)
+
+		var count int32
+		count, ptr = utils.BytesToInt32(buf, ptr)
+		postings[i].Positions = make([]int, count)
+		for j := range postings[i].Positions {
+			var position int32
+			position, ptr = utils.BytesToInt32(buf, ptr)
+			postings[i].Positions[j] = int(position)
+		}
+	}
+
+	return postings
+}
+
+// lookup busca um termo no dicionario com uma busca binaria e retorna
+// suas postings, ou nil caso o termo nao exista no segmento
+func (s *segment) lookup(term string) []Posting {
+	lo, hi := int64(0), s.termCount-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		current, offset, size, df := s.entry(mid)
+		switch {
+		case current == term:
+			return s.postings(offset, size, df)
+		case current < term:
+			lo = mid + 1
+		default:
+			hi = mid - 1
+		}
+	}
+	return nil
+}
+
+// documents lê os documentos do segmento e seus tamanhos
+func (s *segment) documents() map[int64]int64 {
+	buf := make([]byte, s.docsCount*16)
+	s.file.ReadAt(buf, s.docsOffset)
+
+	docs := make(map[int64]int64, s.docsCount)
+	for i, ptr := int64(0), 0; i < s.docsCount; i++ {
+		var id, length int64
+		id, ptr = utils.BytesToInt64(buf, ptr)
+		length, ptr = utils.BytesToInt64(buf, ptr)
+		docs[id] = length
+	}
+
+	return docs
+}
+
+// length busca o tamanho de um documento do segmento com uma busca binaria
+// na secao de documentos, retornando false se ele nao estiver no segmento
+func (s *segment) length(id int64) (int64, bool) {
+	buf := make([]byte, 16)
+	lo, hi := int64(0), s.docsCount-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		s.file.ReadAt(buf, s.docsOffset+mid*16)
+		current, ptr := utils.BytesToInt64(buf, 0)
+		switch {
+		case current == id:
+			length, _ := utils.BytesToInt64(buf, ptr)
+			return length, true
+		case current < id:
+			lo = mid + 1
+		default:
+			hi = mid - 1
+		}
+	}
+	return 0, false
+}
+
+// load carrega o segmento inteiro para um indice em memoria
+func (s *segment) load() *InvertedIndex {
+	ii := NewInvertedIndex()
+	for i := int64(0); i < s.termCount; i++ {
+		term, offset, size, df := s.entry(i)
+		ii.Index[term] = s.postings(offset, size, df)
+	}
+	ii.Lengths = s.documents()
+	return &ii
+}
+
+// ==================================== Segmentos ===================================== //
+
+// lookupAll busca um termo em uma sequencia de segmentos, do mais novo para o
+// mais antigo, descartando os documentos removidos por segmentos mais novos
+func lookupAll(segments []*segment, term string) []Posting {
+	masked := make(map[int64]bool)
+	result := make([]Posting, 0)
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		for _, p := range segments[i].lookup(term) {
+			if !masked[p.DocumentID] {
+				result = append(result, p)
+			}
+		}
+		for id := range segments[i].tombstones {
+			masked[id] = true
+		}
+	}
+
+	return result
+}
+
+// lengthAll busca o tamanho atual de um documento em uma sequencia de
+// segmentos, do mais novo para o mais antigo, respeitando as remocoes
+func lengthAll(segments []*segment, id int64) (int64, bool) {
+	for i := len(segments) - 1; i >= 0; i-- {
+		if length, ok := segments[i].length(id); ok {
+			return length, true
+		}
+		if segments[i].tombstones[id] {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// loadAll carrega o conteudo efetivo de uma sequencia de segmentos
+// para um unico indice em memoria
+func loadAll(segments []*segment) *InvertedIndex {
+	masked := make(map[int64]bool)
+	result := NewInvertedIndex()
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		ii := segments[i].load()
+		for term, postings := range ii.Index {
+			for _, p := range postings {
+				if !masked[p.DocumentID] {
+					result.Index[term] = append(result.Index[term], p)
+				}
+			}
+		}
+		for id, length := range ii.Lengths {
+			if !masked[id] {
+				result.Lengths[id] = length
+			}
+		}
+		for id := range segments[i].tombstones {
+			masked[id] = true
+		}
+	}
+
+	return &result
+}
+
+// openSegments abre os segmentos fornecidos, do mais antigo para o mais novo
+func openSegments(dir string, ids []int64) ([]*segment, error) {
+	segments := make([]*segment, 0, len(ids))
+	for _, id := range ids {
+		s, err := openSegment(dir, id)
+		if err != nil {
+			closeSegments(segments)
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+// closeSegments fecha todos os segmentos abertos
+func closeSegments(segments []*segment) {
+	for _, s := range segments {
+		s.close()
+	}
+}
@@ -0,0 +1,76 @@
+// O arquivo stats do pacote invertedIndex coleta estatisticas do vocabulario
+// de um indice, como seu tamanho e os termos mais frequentes.
+package invertedIndex
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// TermStats é um termo do vocabulario e sua quantidade de documentos
+type TermStats struct {
+	Term      string `json:"term"`
+	Documents int    `json:"documents"`
+}
+
+// Stats resume o estado de um indice invertido
+type Stats struct {
+	Files      map[string]int64 `json:"files"`      // Tamanho de cada arquivo em bytes
+	Analyzer   string           `json:"analyzer"`   // Analisador do campo
+	Vocabulary int              `json:"vocabulary"` // Termos distintos
+	Documents  int              `json:"documents"`  // Documentos indexados
+	AvgLength  float64          `json:"avgLength"`  // Tamanho medio dos documentos
+	Postings   int              `json:"postings"`   // Pares termo-documento
+	Segments   int              `json:"segments"`   // Segmentos ativos
+	StopTerms  int              `json:"stopTerms"`  // Termos removidos por alta frequencia
+	TopTerms   []TermStats      `json:"topTerms"`   // Termos presentes em mais documentos
+}
+
+// ReadStats carrega o indice invertido de um campo e coleta suas
+// estatisticas, listando os 'top' termos presentes em mais documentos
+func ReadStats(path string, field string, top int) (Stats, error) {
+	segments, m, done, err := view(path, field)
+	if err != nil {
+		return Stats{}, err
+	}
+	ii := loadAll(segments)
+	done()
+
+	stats := Stats{
+		Files:      make(map[string]int64),
+		Analyzer:   m.analyzer,
+		Vocabulary: len(ii.Index),
+		Documents:  len(ii.Lengths),
+		AvgLength:  m.averageLength(),
+		Segments:   len(m.segments),
+		StopTerms:  len(m.stopTerms),
+		TopTerms:   make([]TermStats, 0, len(ii.Index)),
+	}
+
+	entries, _ := os.ReadDir(fieldDir(path, field))
+	for _, entry := range entries {
+		filePath := filepath.Join(fieldDir(path, field), entry.Name())
+		stats.Files[filePath] = utils.FileSize(filePath)
+	}
+
+	for term, postings := range ii.Index {
+		stats.Postings += len(postings)
+		stats.TopTerms = append(stats.TopTerms, TermStats{Term: term, Documents: len(postings)})
+	}
+
+	// Desempate alfabetico para manter o resultado deterministico
+	sort.Slice(stats.TopTerms, func(i, j int) bool {
+		if stats.TopTerms[i].Documents != stats.TopTerms[j].Documents {
+			return stats.TopTerms[i].Documents > stats.TopTerms[j].Documents
+		}
+		return stats.TopTerms[i].Term < stats.TopTerms[j].Term
+	})
+	if top >= 0 && top < len(stats.TopTerms) {
+		stats.TopTerms = stats.TopTerms[:top]
+	}
+
+	return stats, nil
+}
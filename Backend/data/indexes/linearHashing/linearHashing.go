@@ -0,0 +1,526 @@
+// Linear Hashing - Fornece uma implementação de hash linear.
+//
+// Diferente da hash dinamica (extensivel), a hash linear nao utiliza diretorio:
+// os buckets primarios ficam em sequencia no arquivo e crescem um de cada vez.
+// Sempre que o fator de carga ultrapassa MAX_LOAD o bucket apontado por 'next'
+// é dividido, redistribuindo seus registros entre ele e um novo bucket criado
+// no final do arquivo. Quando todos os buckets do nivel atual foram divididos o
+// nivel é incrementado e 'next' volta ao inicio.
+//
+// Como o bucket dividido nao é necessariamente o que estourou, registros
+// excedentes sao guardados em buckets de overflow encadeados, em um arquivo
+// separado. Apos remocoes, se o fator de carga ficar abaixo de MIN_LOAD, a
+// ultima divisao é desfeita e o arquivo de buckets é reduzido.
+//
+// O pacote expoe a mesma superficie de CRUD do pacote hashing:
+// LinearHashCreate, LinearHashRead, LinearHashUpdate e LinearHashDelete.
+package linearHashing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Path de arquivos necessarios
+const (
+	BUCKETS_FILE  string = "LinearHash_Buckets.bin"
+	OVERFLOW_FILE string = "LinearHash_Overflow.bin"
+	HEADER_FILE   string = "LinearHash.bin"
+)
+
+// Limites do fator de carga e quantidade inicial de buckets
+const (
+	MAX_LOAD        float64 = 0.8
+	MIN_LOAD        float64 = 0.4
+	INITIAL_BUCKETS int64   = 2
+)
+
+// NULL representa um endereço inexistente
+const NULL int64 = -1
+
+// ====================================== Structs ====================================== //
+
+// LinearHash representa uma tabela de hash linear armazenada em arquivo.
+type LinearHash struct {
+	bucketsFile  *os.File // Arquivo com os buckets primarios, em sequencia.
+	overflowFile *os.File // Arquivo com os buckets de overflow.
+	headerFile   *os.File // Arquivo com os metadados da hash.
+	level        int64    // Nivel atual da hash.
+	next         int64    // Proximo bucket a ser dividido.
+	initial      int64    // Quantidade de buckets no nivel 0.
+	capacity     int64    // Quantidade maxima de registros por bucket.
+	records      int64    // Quantidade total de registros na hash.
+	freeOverflow []int64  // Buckets de overflow livres para reuso.
+}
+
+// Bucket representa um bucket primario ou de overflow.
+type Bucket struct {
+	file     *os.File // Arquivo onde o bucket esta armazenado.
+	address  int64    // Endereço do bucket no arquivo.
+	Count    int64    // Quantidade de registros no bucket.
+	Overflow int64    // Endereço do proximo bucket de overflow, ou NULL.
+	Records  []Record // Registros do bucket.
+}
+
+// Record representa um registro armazenado em um bucket.
+type Record struct {
+	ID      int64 // ID do registro.
+	Address int64 // Endereço original do registro.
+}
+
+// Interface para leitura da database
+type Reader interface {
+	ReadNextGeneric() (any, bool, int64, error)
+}
+
+// Interface para recuperacao do campo do objeto indexavel
+type IndexableObject interface {
+	GetField(fieldName string) string
+}
+
+// ==================================== Linear Hash ==================================== //
+
+// newLinearHash cria os arquivos de uma hash linear vazia em folderPath,
+// com INITIAL_BUCKETS buckets primarios de capacidade fornecida
+func newLinearHash(folderPath string, capacity int64) *LinearHash {
+	bucketsFile, _ := os.Create(filepath.Join(folderPath, BUCKETS_FILE))
+	overflowFile, _ := os.Create(filepath.Join(folderPath, OVERFLOW_FILE))
+	headerFile, _ := os.Create(filepath.Join(folderPath, HEADER_FILE))
+
+	hash := &LinearHash{
+		bucketsFile:  bucketsFile,
+		overflowFile: overflowFile,
+		headerFile:   headerFile,
+		level:        0,
+		next:         0,
+		initial:      INITIAL_BUCKETS,
+		capacity:     capacity,
+		records:      0,
+		freeOverflow: make([]int64, 0),
+	}
+
+	for i := int64(0); i < hash.initial; i++ {
+		hash.newBucket(hash.bucketsFile, i*hash.bucketSize()).write()
+	}
+
+	return hash
+}
+
+// LoadLinearHash carrega os metadados de uma hash linear para a memoria primaria
+//
+// Exemplo: path (data/files), identifier (linearHashIndex)
+func LoadLinearHash(path string, identifier string) (*LinearHash, error) {
+	folderPath := filepath.Join(path, identifier)
+
+	headerFile, err := os.OpenFile(filepath.Join(folderPath, HEADER_FILE), os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	bucketsFile, err := os.OpenFile(filepath.Join(folderPath, BUCKETS_FILE), os.O_RDWR, 0644)
+	if err != nil {
+		headerFile.Close()
+		return nil, err
+	}
+	overflowFile, err := os.OpenFile(filepath.Join(folderPath, OVERFLOW_FILE), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		headerFile.Close()
+		bucketsFile.Close()
+		return nil, err
+	}
+
+	var ptr int
+	buffer, _ := io.ReadAll(headerFile)
+	hash := &LinearHash{
+		bucketsFile:  bucketsFile,
+		overflowFile: overflowFile,
+		headerFile:   headerFile,
+	}
+	hash.level, ptr = utils.BytesToInt64(buffer, ptr)
+	hash.next, ptr = utils.BytesToInt64(buffer, ptr)
+	hash.initial, ptr = utils.BytesToInt64(buffer, ptr)
+	hash.capacity, ptr = utils.BytesToInt64(buffer, ptr)
+	hash.records, ptr = utils.BytesToInt64(buffer, ptr)
+	freeLen, ptr := utils.BytesToInt64(buffer, ptr)
+	hash.freeOverflow = make([]int64, freeLen)
+	for i := range hash.freeOverflow {
+		hash.freeOverflow[i], ptr = utils.BytesToInt64(buffer, ptr)
+	}
+
+	return hash, nil
+}
+
+// Load é um wrapper simples da funcao LoadLinearHash
+func Load(path string, identifier string) (*LinearHash, error) {
+	return LoadLinearHash(path, identifier)
+}
+
+// Close salva os metadados da hash e fecha os arquivos abertos
+func (hash *LinearHash) Close() {
+	hash.headerFile.Seek(0, io.SeekStart)
+	hash.headerFile.Truncate(0)
+
+	binary.Write(hash.headerFile, binary.LittleEndian, hash.level)
+	binary.Write(hash.headerFile, binary.LittleEndian, hash.next)
+	binary.Write(hash.headerFile, binary.LittleEndian, hash.initial)
+	binary.Write(hash.headerFile, binary.LittleEndian, hash.capacity)
+	binary.Write(hash.headerFile, binary.LittleEndian, hash.records)
+	binary.Write(hash.headerFile, binary.LittleEndian, int64(len(hash.freeOverflow)))
+	binary.Write(hash.headerFile, binary.LittleEndian, hash.freeOverflow)
+
+	if err := hash.bucketsFile.Close(); err != nil {
+		fmt.Printf("Erro ao fechar/salvar buckets")
+	}
+	if err := hash.overflowFile.Close(); err != nil {
+		fmt.Printf("Erro ao fechar/salvar overflow")
+	}
+	if err := hash.headerFile.Close(); err != nil {
+		fmt.Printf("Erro ao fechar/salvar cabecalho")
+	}
+}
+
+// StartLinearHashFile cria uma hash linear a partir de um arquivo de dados.
+//
+// O controler deve fornecer objetos que implementem a interface
+// GetField(fieldName string) string. Onde o campo consultado sera "id"
+//
+// Deve ser fornecido um path de onde os arquivos serao colocados e um
+// identificador unico para diferenciar entre possiveis outros arquivos hash
+func StartLinearHashFile(controler Reader, bucketSize int64, path string, identifier string) {
+	folderPath := filepath.Join(path, identifier)
+	os.MkdirAll(folderPath, os.ModePerm)
+	hash := newLinearHash(folderPath, bucketSize)
+
+	for {
+		objInterface, isDead, address, err := controler.ReadNextGeneric()
+		if err != nil {
+			break
+		}
+
+		obj, ok := objInterface.(IndexableObject)
+		if !ok {
+			fmt.Printf("%+v", objInterface)
+			continue
+		}
+
+		if !isDead {
+			id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+			hash.Insert(id, address)
+		}
+	}
+
+	hash.Close()
+}
+
+// bucketSize calcula o tamanho em bytes de um bucket
+func (hash *LinearHash) bucketSize() int64 {
+	return int64(binary.Size(int64(0))*2 + binary.Size(Record{})*int(hash.capacity))
+}
+
+// bucketCount retorna a quantidade de buckets primarios
+func (hash *LinearHash) bucketCount() int64 {
+	return hash.initial<<hash.level + hash.next
+}
+
+// loadFactor calcula a ocupacao media dos buckets primarios
+func (hash *LinearHash) loadFactor() float64 {
+	return float64(hash.records) / float64(hash.bucketCount()*hash.capacity)
+}
+
+// bucketIndex calcula o bucket primario de um ID: h_level(id), ou
+// h_level+1(id) caso o bucket calculado ja tenha sido dividido neste nivel
+func (hash *LinearHash) bucketIndex(id int64) int64 {
+	m := hash.initial << hash.level
+	pos := utils.AbsInt64(id) % m
+	if pos < hash.next {
+		pos = utils.AbsInt64(id) % (m << 1)
+	}
+	return pos
+}
+
+// ====================================== Buckets ====================================== //
+
+// newBucket inicializa um bucket vazio para o endereço fornecido
+func (hash *LinearHash) newBucket(file *os.File, address int64) *Bucket {
+	b := &Bucket{
+		file:     file,
+		address:  address,
+		Count:    0,
+		Overflow: NULL,
+		Records:  make([]Record, hash.capacity),
+	}
+	for i := range b.Records {
+		b.Records[i] = Record{ID: NULL, Address: NULL}
+	}
+	return b
+}
+
+// readBucket lê um bucket de um arquivo dado seu endereço
+func (hash *LinearHash) readBucket(file *os.File, address int64) *Bucket {
+	if address == NULL {
+		return nil
+	}
+
+	buf := make([]byte, hash.bucketSize())
+	file.ReadAt(buf, address)
+
+	b := hash.newBucket(file, address)
+	var ptr int
+	b.Count, ptr = utils.BytesToInt64(buf, ptr)
+	b.Overflow, ptr = utils.BytesToInt64(buf, ptr)
+	for i := range b.Records {
+		b.Records[i].ID, ptr = utils.BytesToInt64(buf, ptr)
+		b.Records[i].Address, ptr = utils.BytesToInt64(buf, ptr)
+	}
+
+	return b
+}
+
+// readPrimary lê o bucket primario de indice pos
+func (hash *LinearHash) readPrimary(pos int64) *Bucket {
+	return hash.readBucket(hash.bucketsFile, pos*hash.bucketSize())
+}
+
+// nextBucket retorna o proximo bucket de overflow da cadeia
+func (hash *LinearHash) nextBucket(b *Bucket) *Bucket {
+	return hash.readBucket(hash.overflowFile, b.Overflow)
+}
+
+// write escreve o bucket em seu endereço no arquivo
+func (b *Bucket) write() {
+	b.file.Seek(b.address, io.SeekStart)
+	binary.Write(b.file, binary.LittleEndian, b.Count)
+	binary.Write(b.file, binary.LittleEndian, b.Overflow)
+	binary.Write(b.file, binary.LittleEndian, b.Records)
+}
+
+// allocOverflow retorna um bucket de overflow vazio, reaproveitando
+// um endereço livre ou criando um novo no final do arquivo
+func (hash *LinearHash) allocOverflow() *Bucket {
+	var address int64
+	if len(hash.freeOverflow) > 0 {
+		address = hash.freeOverflow[len(hash.freeOverflow)-1]
+		hash.freeOverflow = hash.freeOverflow[:len(hash.freeOverflow)-1]
+	} else {
+		address, _ = hash.overflowFile.Seek(0, io.SeekEnd)
+	}
+	return hash.newBucket(hash.overflowFile, address)
+}
+
+// insertInto insere um registro na cadeia do bucket primario pos,
+// no primeiro bucket com espaço livre ou em um novo bucket de overflow
+func (hash *LinearHash) insertInto(pos int64, r Record) {
+	b := hash.readPrimary(pos)
+	for b.Count == hash.capacity && b.Overflow != NULL {
+		b = hash.nextBucket(b)
+	}
+
+	if b.Count < hash.capacity {
+		b.Records[b.Count] = r
+		b.Count++
+		b.write()
+		return
+	}
+
+	overflow := hash.allocOverflow()
+	overflow.Records[0] = r
+	overflow.Count++
+	overflow.write()
+
+	b.Overflow = overflow.address
+	b.write()
+}
+
+// collect remove e retorna todos os registros da cadeia do bucket primario
+// pos, liberando seus buckets de overflow e esvaziando o primario
+func (hash *LinearHash) collect(pos int64) []Record {
+	records := make([]Record, 0)
+	primary := hash.readPrimary(pos)
+
+	for b := primary; b != nil; b = hash.nextBucket(b) {
+		records = append(records, b.Records[:b.Count]...)
+		if b != primary {
+			hash.freeOverflow = append(hash.freeOverflow, b.address)
+		}
+	}
+
+	hash.newBucket(hash.bucketsFile, primary.address).write()
+	return records
+}
+
+// unlink retira um bucket de overflow vazio da cadeia do bucket primario
+// pos, ligando o bucket anterior ao seguinte, e libera o seu endereço
+func (hash *LinearHash) unlink(pos int64, overflow *Bucket) {
+	for b := hash.readPrimary(pos); b != nil; b = hash.nextBucket(b) {
+		if b.Overflow == overflow.address {
+			b.Overflow = overflow.Overflow
+			b.write()
+			break
+		}
+	}
+	hash.freeOverflow = append(hash.freeOverflow, overflow.address)
+}
+
+// split divide o bucket apontado por next, criando um novo bucket primario
+// no final do arquivo e redistribuindo os registros com h_level+1
+func (hash *LinearHash) split() {
+	m := hash.initial << hash.level
+	records := hash.collect(hash.next)
+	hash.newBucket(hash.bucketsFile, (hash.next+m)*hash.bucketSize()).write()
+
+	hash.next++
+	if hash.next == m {
+		hash.level++
+		hash.next = 0
+	}
+
+	for _, r := range records {
+		hash.insertInto(hash.bucketIndex(r.ID), r)
+	}
+}
+
+// merge desfaz a ultima divisao, movendo os registros do ultimo bucket
+// primario de volta para o seu bucket de origem e reduzindo o arquivo
+func (hash *LinearHash) merge() {
+	if hash.bucketCount() <= hash.initial {
+		return
+	}
+
+	if hash.next == 0 {
+		hash.level--
+		hash.next = hash.initial << hash.level
+	}
+	hash.next--
+
+	last := hash.next + hash.initial<<hash.level
+	records := hash.collect(last)
+	hash.bucketsFile.Truncate(last * hash.bucketSize())
+
+	for _, r := range records {
+		hash.insertInto(hash.next, r)
+	}
+}
+
+// find procura um ID na cadeia do seu bucket, retornando o bucket que
+// o contem e a posicao do registro, ou nil caso nao exista
+func (hash *LinearHash) find(id int64) (*Bucket, int64) {
+	for b := hash.readPrimary(hash.bucketIndex(id)); b != nil; b = hash.nextBucket(b) {
+		for i := int64(0); i < b.Count; i++ {
+			if b.Records[i].ID == id {
+				return b, i
+			}
+		}
+	}
+	return nil, NULL
+}
+
+// ======================================= Crud ======================================== //
+
+// Insert adiciona um registro a hash, dividindo o proximo bucket
+// caso o fator de carga ultrapasse MAX_LOAD
+func (hash *LinearHash) Insert(id int64, address int64) {
+	hash.insertInto(hash.bucketIndex(id), Record{ID: id, Address: address})
+	hash.records++
+
+	if hash.loadFactor() > MAX_LOAD {
+		hash.split()
+	}
+}
+
+// Read retorna o endereço associado a um ID
+func (hash *LinearHash) Read(id int64) (int64, error) {
+	b, i := hash.find(id)
+	if b == nil {
+		return 0, fmt.Errorf("record not found")
+	}
+	return b.Records[i].Address, nil
+}
+
+// Update altera o endereço associado a um ID
+func (hash *LinearHash) Update(id int64, newAddress int64) error {
+	b, i := hash.find(id)
+	if b == nil {
+		return fmt.Errorf("record not found")
+	}
+	b.Records[i].Address = newAddress
+	b.write()
+	return nil
+}
+
+// Delete remove o registro de um ID, desfazendo a ultima divisao
+// caso o fator de carga fique abaixo de MIN_LOAD
+func (hash *LinearHash) Delete(id int64) error {
+	b, i := hash.find(id)
+	if b == nil {
+		return fmt.Errorf("record not found")
+	}
+
+	// O ultimo registro do bucket ocupa a posicao removida
+	b.Count--
+	b.Records[i] = b.Records[b.Count]
+	b.Records[b.Count] = Record{ID: NULL, Address: NULL}
+	if b.Count == 0 && b.file == hash.overflowFile {
+		hash.unlink(hash.bucketIndex(id), b)
+	} else {
+		b.write()
+	}
+
+	hash.records--
+	if hash.loadFactor() < MIN_LOAD {
+		hash.merge()
+	}
+
+	return nil
+}
+
+// LinearHashCreate adiciona um novo registro a hash linear e salva as alteracoes
+func LinearHashCreate(id int64, address int64, path string, identifier string) error {
+	hash, err := LoadLinearHash(path, identifier)
+	if err != nil {
+		return err
+	}
+	defer hash.Close()
+
+	hash.Insert(id, address)
+	return nil
+}
+
+// LinearHashRead busca o endereço de um registro usando a hash linear
+func LinearHashRead(id int64, path string, identifier string) (int64, error) {
+	hash, err := LoadLinearHash(path, identifier)
+	if err != nil {
+		return 0, err
+	}
+	defer hash.Close()
+
+	return hash.Read(id)
+}
+
+// LinearHashUpdate atualiza o endereço de um registro na hash linear.
+// Retorna um erro se o registro não for encontrado.
+func LinearHashUpdate(id int64, newAddress int64, path string, identifier string) error {
+	hash, err := LoadLinearHash(path, identifier)
+	if err != nil {
+		return err
+	}
+	defer hash.Close()
+
+	return hash.Update(id, newAddress)
+}
+
+// LinearHashDelete remove um registro da hash linear.
+// Retorna um erro se o registro não for encontrado.
+func LinearHashDelete(id int64, path string, identifier string) error {
+	hash, err := LoadLinearHash(path, identifier)
+	if err != nil {
+		return err
+	}
+	defer hash.Close()
+
+	return hash.Delete(id)
+}
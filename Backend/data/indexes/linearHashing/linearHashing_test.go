@@ -0,0 +1,221 @@
+// Testes da hash linear: divisao e juncao de buckets atravessando niveis,
+// cadeias de overflow e o CRUD sobre os arquivos recarregados
+package linearHashing
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// testObject é um objeto com o campo "id"
+type testObject struct {
+	id int64
+}
+
+func (o testObject) GetField(fieldName string) string {
+	return strconv.FormatInt(o.id, 10)
+}
+
+// testReader percorre os ids de 1 a n, usando 10*id como endereço
+type testReader struct {
+	next int64
+	n    int64
+}
+
+func (r *testReader) ReadNextGeneric() (any, bool, int64, error) {
+	if r.next >= r.n {
+		return nil, false, 0, errors.New("EOF")
+	}
+	r.next++
+	return testObject{r.next}, false, 10 * r.next, nil
+}
+
+// checkHash verifica que cada registro esta na cadeia do seu bucket, que
+// nenhuma cadeia possui buckets de overflow vazios ou livres, que a contagem
+// de registros bate com os buckets e que os ids presentes (e apenas eles) sao
+// encontrados com o endereço 10*id
+func checkHash(t *testing.T, hash *LinearHash, present map[int64]bool, ids []int64) {
+	t.Helper()
+
+	free := make(map[int64]bool)
+	for _, address := range hash.freeOverflow {
+		free[address] = true
+	}
+
+	records := int64(0)
+	for pos := int64(0); pos < hash.bucketCount(); pos++ {
+		primary := hash.readPrimary(pos)
+		for b := primary; b != nil; b = hash.nextBucket(b) {
+			if b != primary && (b.Count == 0 || free[b.address]) {
+				t.Fatalf("bucket %d has an empty or free overflow bucket at %d", pos, b.address)
+			}
+			for i := int64(0); i < b.Count; i++ {
+				if got := hash.bucketIndex(b.Records[i].ID); got != pos {
+					t.Fatalf("id %d is in bucket %d, expected %d", b.Records[i].ID, pos, got)
+				}
+			}
+			records += b.Count
+		}
+	}
+	if records != hash.records {
+		t.Fatalf("buckets hold %d records, expected %d", records, hash.records)
+	}
+
+	for _, id := range ids {
+		address, err := hash.Read(id)
+		if present[id] && (err != nil || address != 10*id) {
+			t.Fatalf("Read(%d) = %d, %v, expected %d", id, address, err, 10*id)
+		}
+		if !present[id] && err == nil {
+			t.Fatalf("Read(%d) found a deleted id", id)
+		}
+	}
+}
+
+func TestSplitAndMerge(t *testing.T) {
+	const n = 200
+	hash := newLinearHash(t.TempDir(), 4)
+	defer hash.Close()
+
+	present := make(map[int64]bool)
+	ids := make([]int64, 0, n)
+	for id := int64(1); id <= n; id++ {
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		hash.Insert(id, 10*id)
+		present[id] = true
+		checkHash(t, hash, present, ids)
+	}
+	if hash.level < 3 {
+		t.Fatalf("level = %d after %d inserts, expected at least 3", hash.level, n)
+	}
+
+	// Remove os ids alternando as pontas para juntar buckets em varios niveis
+	for i, j := 0, n-1; i <= j; i, j = i+1, j-1 {
+		for _, id := range []int64{ids[i], ids[j]} {
+			if !present[id] {
+				continue
+			}
+			if err := hash.Delete(id); err != nil {
+				t.Fatalf("Delete(%d) = %v", id, err)
+			}
+			delete(present, id)
+			checkHash(t, hash, present, ids)
+		}
+	}
+
+	if hash.level != 0 || hash.next != 0 || hash.bucketCount() != INITIAL_BUCKETS {
+		t.Errorf("level = %d, next = %d, buckets = %d after deleting everything", hash.level, hash.next, hash.bucketCount())
+	}
+	if info, _ := hash.bucketsFile.Stat(); info.Size() != INITIAL_BUCKETS*hash.bucketSize() {
+		t.Errorf("buckets file has %d bytes, expected %d", info.Size(), INITIAL_BUCKETS*hash.bucketSize())
+	}
+	if err := hash.Delete(1); err == nil {
+		t.Errorf("Delete of a missing id returned no error")
+	}
+}
+
+func TestOverflowChains(t *testing.T) {
+	hash := newLinearHash(t.TempDir(), 4)
+	defer hash.Close()
+
+	// Multiplos de 1024 caem no mesmo bucket ate o nivel 8, formando uma
+	// cadeia de overflow; os impares mantem o fator de carga alto
+	present := make(map[int64]bool)
+	ids := make([]int64, 0)
+	for k := int64(1); k <= 20; k++ {
+		ids = append(ids, 1024*k, 2*k+1)
+	}
+	for _, id := range ids {
+		hash.Insert(id, 10*id)
+		present[id] = true
+		checkHash(t, hash, present, ids)
+	}
+	if b := hash.readPrimary(0); b.Overflow == NULL {
+		t.Fatalf("bucket 0 has no overflow chain")
+	}
+
+	// Esvaziar a cadeia pelo inicio, pelo meio e pelo fim libera os buckets
+	// de overflow vazios
+	for _, k := range []int64{1, 2, 3, 4, 10, 11, 12, 13, 20, 19, 18, 17} {
+		if err := hash.Delete(1024 * k); err != nil {
+			t.Fatalf("Delete(%d) = %v", 1024*k, err)
+		}
+		delete(present, 1024*k)
+		checkHash(t, hash, present, ids)
+	}
+	if len(hash.freeOverflow) == 0 {
+		t.Fatalf("no overflow bucket was freed")
+	}
+
+	// Os buckets livres sao reaproveitados antes de crescer o arquivo
+	info, _ := hash.overflowFile.Stat()
+	size := info.Size()
+	for _, k := range []int64{1, 2, 3, 4} {
+		hash.Insert(1024*k, 10240*k)
+		present[1024*k] = true
+		checkHash(t, hash, present, ids)
+	}
+	if info, _ := hash.overflowFile.Stat(); info.Size() != size {
+		t.Errorf("overflow file grew from %d to %d bytes with free buckets available", size, info.Size())
+	}
+}
+
+func TestReload(t *testing.T) {
+	const n = 100
+	path := t.TempDir()
+	StartLinearHashFile(&testReader{n: n}, 4, path, "linearHashIndex")
+
+	steps := []struct {
+		name string
+		run  func() error
+		id   int64
+		want int64 // Endereço esperado, ou NULL se o id nao deve existir
+	}{
+		{"read", func() error { return nil }, 37, 370},
+		{"create", func() error { return LinearHashCreate(n+1, 5000, path, "linearHashIndex") }, n + 1, 5000},
+		{"update", func() error { return LinearHashUpdate(37, 9999, path, "linearHashIndex") }, 37, 9999},
+		{"delete", func() error { return LinearHashDelete(50, path, "linearHashIndex") }, 50, NULL},
+		{"read", func() error { return nil }, 51, 510},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got, err := LinearHashRead(step.id, path, "linearHashIndex")
+		switch {
+		case step.want == NULL && err == nil:
+			t.Errorf("%s: LinearHashRead(%d) found a deleted id", step.name, step.id)
+		case step.want != NULL && (err != nil || got != step.want):
+			t.Errorf("%s: LinearHashRead(%d) = %d, %v, expected %d", step.name, step.id, got, err, step.want)
+		}
+	}
+
+	if err := LinearHashUpdate(50, 1, path, "linearHashIndex"); err == nil {
+		t.Errorf("LinearHashUpdate of a deleted id returned no error")
+	}
+	if _, err := LoadLinearHash(filepath.Join(path, "missing"), "linearHashIndex"); err == nil {
+		t.Errorf("LoadLinearHash of a missing index returned no error")
+	}
+
+	// Todos os outros ids continuam com seus endereços apos recarregar
+	hash, err := LoadLinearHash(path, "linearHashIndex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hash.Close()
+	present := map[int64]bool{}
+	ids := make([]int64, 0, n)
+	for id := int64(1); id <= n; id++ {
+		if id != 37 && id != 50 {
+			present[id] = true
+			ids = append(ids, id)
+		}
+	}
+	checkHash(t, hash, present, ids)
+}
@@ -0,0 +1,55 @@
+// O arquivo stats do pacote linearHashing coleta estatisticas da hash linear,
+// incluindo o tamanho das cadeias de overflow de cada bucket primario.
+package linearHashing
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Stats resume o estado de uma hash linear
+type Stats struct {
+	Files          map[string]int64 `json:"files"`          // Tamanho de cada arquivo em bytes
+	Level          int64            `json:"level"`          // Nivel atual
+	Next           int64            `json:"next"`           // Proximo bucket a ser dividido
+	Buckets        int64            `json:"buckets"`        // Buckets primarios
+	OverflowBlocks int64            `json:"overflowBlocks"` // Buckets de overflow em uso
+	Records        int64            `json:"records"`        // Registros armazenados
+	Capacity       int64            `json:"bucketCapacity"` // Registros por bucket
+	FillFactor     float64          `json:"fillFactor"`     // Records / (Buckets * Capacity)
+	FreeOverflow   int              `json:"freeOverflow"`   // Buckets de overflow livres
+	Occupancy      map[int64]int    `json:"occupancy"`      // Registros na cadeia -> quantidade de buckets
+	ChainLengths   map[int64]int    `json:"chainLengths"`   // Buckets de overflow na cadeia -> quantidade de buckets
+}
+
+// Stats percorre os buckets primarios e suas cadeias coletando as estatisticas
+func (hash *LinearHash) Stats() Stats {
+	stats := Stats{
+		Files: map[string]int64{
+			hash.bucketsFile.Name():  utils.FileSize(hash.bucketsFile.Name()),
+			hash.overflowFile.Name(): utils.FileSize(hash.overflowFile.Name()),
+			hash.headerFile.Name():   utils.FileSize(hash.headerFile.Name()),
+		},
+		Level:        hash.level,
+		Next:         hash.next,
+		Buckets:      hash.bucketCount(),
+		Records:      hash.records,
+		Capacity:     hash.capacity,
+		FillFactor:   hash.loadFactor(),
+		FreeOverflow: len(hash.freeOverflow),
+		Occupancy:    make(map[int64]int),
+		ChainLengths: make(map[int64]int),
+	}
+
+	for pos := int64(0); pos < stats.Buckets; pos++ {
+		count, chain := int64(0), int64(-1)
+		for b := hash.readPrimary(pos); b != nil; b = hash.nextBucket(b) {
+			count += b.Count
+			chain++
+		}
+		stats.OverflowBlocks += chain
+		stats.Occupancy[count]++
+		stats.ChainLengths[chain]++
+	}
+
+	return stats
+}
@@ -0,0 +1,431 @@
+// O pacote ngram implementa um indice de trigramas, usado para reduzir as
+// pesquisas de substrings (KMP e Rabin-Karp) a um conjunto de documentos
+// candidatos, ao inves de percorrer toda a database.
+//
+// Cada documento é representado pelos trigramas (sequencias de 3 caracteres,
+// incluindo espacos e pontuacao) do seu campo, com as letras normalizadas da
+// mesma forma que na conferencia dos candidatos (ver utils.FoldRunes), para
+// que 'ſ' e 's' ou 'ς' e 'σ' gerem os mesmos trigramas. Um texto so pode
+// conter uma substring se contiver todos os trigramas dela, entao os
+// candidatos de uma pesquisa sao a intersecao das listas de seus trigramas.
+// Os candidatos ainda precisam ser conferidos pelo algoritmo de pattern
+// matching, ja que os trigramas podem aparecer fora de ordem.
+//
+// O indice de cada campo é gravado em um arquivo com o formato:
+//
+//	[postings]    para cada trigrama: DocumentIDs (int64) em ordem
+//	[dicionario]  para cada trigrama, em ordem: trigrama, offset das postings, quantidade
+//	[tabela]      offset (int64) de cada entrada do dicionario
+//	[documentos]  DocumentIDs (int64) indexados, em ordem
+//	[rodape]      documentos, trigramas, offset da tabela e versao (int64)
+//
+// Indices de outra versao sao rejeitados e as pesquisas percorrem toda a
+// database ate o indice ser reconstruido.
+//
+// Uma pesquisa faz uma busca binaria no dicionario direto no arquivo para cada
+// trigrama do padrao. Alteracoes carregam o indice, o modificam em memoria e
+// regravam o arquivo.
+package ngram
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Tamanho dos n-gramas e diretorio dos arquivos do indice
+const (
+	N           int    = 3
+	NGRAM_DIR   string = "ngram"
+	FOOTER_SIZE int64  = 4 * 8
+)
+
+// NGRAM_VERSION é a versao do formato gravada no fim do rodape. Indices sem
+// versao guardam nessa posicao o offset da tabela, que nunca vale 2
+const NGRAM_VERSION int64 = 2
+
+// Interface para leitura da database
+type Reader interface {
+	ReadNextGeneric() (any, bool, int64, error)
+}
+
+// Interface para recuperacao do campo do objeto indexavel
+type IndexableObject interface {
+	GetField(fieldName string) string
+}
+
+// NgramIndex é um indice de trigramas em memoria
+type NgramIndex struct {
+	Grams     map[string][]int64 // Documentos de cada trigrama
+	Documents map[int64]bool     // Documentos indexados
+}
+
+// Regravacoes dos arquivos sao feitas uma por vez
+var writeMutex sync.Mutex
+
+// NewNgramIndex cria um indice vazio
+func NewNgramIndex() *NgramIndex {
+	return &NgramIndex{
+		Grams:     make(map[string][]int64),
+		Documents: make(map[int64]bool),
+	}
+}
+
+// Grams retorna os trigramas distintos de um texto normalizado (ver
+// utils.FoldRunes)
+func Grams(text string) []string {
+	runes := utils.FoldRunes(text)
+	seen := make(map[string]bool)
+	grams := make([]string, 0)
+
+	for i := 0; i+N <= len(runes); i++ {
+		gram := string(runes[i : i+N])
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+
+	return grams
+}
+
+// ngramFile retorna o caminho do arquivo do indice de um campo
+func ngramFile(path string, field string) string {
+	return filepath.Join(path, NGRAM_DIR, field+".bin")
+}
+
+// AddDocument adiciona o documento as listas dos trigramas do texto
+func (ni *NgramIndex) AddDocument(id int64, text string) {
+	for _, gram := range Grams(text) {
+		ni.Grams[gram] = append(ni.Grams[gram], id)
+	}
+	ni.Documents[id] = true
+}
+
+// RemoveDocument remove o documento das listas dos trigramas do texto
+func (ni *NgramIndex) RemoveDocument(id int64, text string) {
+	for _, gram := range Grams(text) {
+		ids := ni.Grams[gram]
+		for i := range ids {
+			if ids[i] == id {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(ni.Grams, gram)
+		} else {
+			ni.Grams[gram] = ids
+		}
+	}
+	delete(ni.Documents, id)
+}
+
+// ===================================== Arquivo ====================================== //
+
+// write grava o indice em um arquivo temporario e o renomeia por cima do atual
+func (ni *NgramIndex) write(filePath string) error {
+	os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	tmpPath := filePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	offset := int64(0)
+	write := func(data any) {
+		binary.Write(w, binary.LittleEndian, data)
+		offset += int64(binary.Size(data))
+	}
+
+	grams := make([]string, 0, len(ni.Grams))
+	for gram := range ni.Grams {
+		grams = append(grams, gram)
+	}
+	sort.Strings(grams)
+
+	// Postings
+	postingOffsets := make([]int64, len(grams))
+	for i, gram := range grams {
+		ids := ni.Grams[gram]
+		sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+		postingOffsets[i] = offset
+		write(ids)
+	}
+
+	// Dicionario e tabela de offsets
+	entryOffsets := make([]int64, len(grams))
+	for i, gram := range grams {
+		entryOffsets[i] = offset
+		write(int32(len(gram)))
+		write([]byte(gram))
+		write(postingOffsets[i])
+		write(int64(len(ni.Grams[gram])))
+	}
+	tableOffset := offset
+	write(entryOffsets)
+
+	// Documentos indexados, guardados apos a tabela para recarregar o indice
+	ids := make([]int64, 0, len(ni.Documents))
+	for id := range ni.Documents {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	write(ids)
+
+	// Rodape
+	write(int64(len(ids)))
+	write(int64(len(grams)))
+	write(tableOffset)
+	write(NGRAM_VERSION)
+
+	w.Flush()
+	file.Close()
+
+	return os.Rename(tmpPath, filePath)
+}
+
+// diskIndex é um indice aberto para leitura direto no arquivo
+type diskIndex struct {
+	file        *os.File
+	documents   int64
+	gramCount   int64
+	tableOffset int64
+}
+
+// open abre o arquivo do indice de um campo, lendo seu rodape
+func open(filePath string) (*diskIndex, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	size, _ := file.Seek(0, io.SeekEnd)
+	if size < FOOTER_SIZE {
+		file.Close()
+		return nil, fmt.Errorf("invalid ngram index '%s'", filePath)
+	}
+
+	buf := make([]byte, FOOTER_SIZE)
+	file.ReadAt(buf, size-FOOTER_SIZE)
+	d := &diskIndex{file: file}
+	ptr := 0
+	d.documents, ptr = utils.BytesToInt64(buf, ptr)
+	d.gramCount, ptr = utils.BytesToInt64(buf, ptr)
+	d.tableOffset, ptr = utils.BytesToInt64(buf, ptr)
+	version, _ := utils.BytesToInt64(buf, ptr)
+
+	if version != NGRAM_VERSION {
+		file.Close()
+		return nil, fmt.Errorf("unsupported ngram index version %d in '%s'", version, filePath)
+	}
+
+	return d, nil
+}
+
+// entry lê a i-esima entrada do dicionario
+func (d *diskIndex) entry(i int64) (gram string, offset int64, count int64) {
+	buf := make([]byte, 8)
+	d.file.ReadAt(buf, d.tableOffset+i*8)
+	address, _ := utils.BytesToInt64(buf, 0)
+
+	d.file.ReadAt(buf[:4], address)
+	size := int64(binary.LittleEndian.Uint32(buf[:4]))
+
+	buf = make([]byte, size+16)
+	d.file.ReadAt(buf, address+4)
+	gram = string(buf[:size])
+	offset, ptr := utils.BytesToInt64(buf, int(size))
+	count, _ = utils.BytesToInt64(buf, ptr)
+
+	return
+}
+
+// ids lê uma lista de documentos dado seu offset e tamanho
+func (d *diskIndex) ids(offset int64, count int64) []int64 {
+	buf := make([]byte, count*8)
+	d.file.ReadAt(buf, offset)
+
+	ids := make([]int64, count)
+	for i, ptr := 0, 0; i < int(count); i++ {
+		ids[i], ptr = utils.BytesToInt64(buf, ptr)
+	}
+	return ids
+}
+
+// lookup busca os documentos de um trigrama com uma busca binaria no dicionario
+func (d *diskIndex) lookup(gram string) []int64 {
+	lo, hi := int64(0), d.gramCount-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		current, offset, count := d.entry(mid)
+		switch {
+		case current == gram:
+			return d.ids(offset, count)
+		case current < gram:
+			lo = mid + 1
+		default:
+			hi = mid - 1
+		}
+	}
+	return nil
+}
+
+// load carrega o indice inteiro para a memoria
+func (d *diskIndex) load() *NgramIndex {
+	ni := NewNgramIndex()
+	for i := int64(0); i < d.gramCount; i++ {
+		gram, offset, count := d.entry(i)
+		ni.Grams[gram] = d.ids(offset, count)
+	}
+
+	// Os documentos ficam entre a tabela e o rodape
+	for _, id := range d.ids(d.tableOffset+d.gramCount*8, d.documents) {
+		ni.Documents[id] = true
+	}
+
+	return ni
+}
+
+// ===================================== Pesquisa ===================================== //
+
+// Candidates retorna os documentos de um campo que contem todos os trigramas
+// do padrao, e a quantidade de documentos indexados. Padroes com menos de N
+// caracteres nao possuem trigramas e nao podem ser filtrados, caso em que
+// 'filtered' é falso e todos os documentos sao candidatos
+func Candidates(path string, field string, pattern string) (ids []int64, documents int64, filtered bool, err error) {
+	d, err := open(ngramFile(path, field))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer d.file.Close()
+
+	grams := Grams(pattern)
+	if len(grams) == 0 {
+		return nil, d.documents, false, nil
+	}
+
+	// Comeca pelas listas menores para que a intersecao encolha mais rapido
+	lists := make([][]int64, len(grams))
+	for i, gram := range grams {
+		lists[i] = d.lookup(gram)
+		if len(lists[i]) == 0 {
+			return []int64{}, d.documents, true, nil
+		}
+	}
+	sort.Slice(lists, func(a, b int) bool { return len(lists[a]) < len(lists[b]) })
+
+	ids = lists[0]
+	for _, list := range lists[1:] {
+		ids = intersect(ids, list)
+	}
+
+	return ids, d.documents, true, nil
+}
+
+// intersect retorna a intersecao de duas listas ordenadas
+func intersect(a []int64, b []int64) []int64 {
+	result := make([]int64, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return result
+}
+
+// ======================================= Crud ======================================== //
+
+// StartNgramFile cria o indice de trigramas de um campo a partir de um Reader.
+//
+// A interface retornada por ReadNextGeneric deve possuir:
+// GetField(fieldName string) string.
+func StartNgramFile(controler Reader, path string, field string) error {
+	ni := NewNgramIndex()
+
+	for {
+		objInterface, isDead, _, err := controler.ReadNextGeneric()
+		if err != nil {
+			break
+		}
+
+		obj, ok := objInterface.(IndexableObject)
+		if !ok {
+			return fmt.Errorf("failed to convert object to IndexableObject\n%+v", objInterface)
+		}
+
+		if !isDead {
+			id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+			ni.AddDocument(id, obj.GetField(field))
+		}
+	}
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+	return ni.write(ngramFile(path, field))
+}
+
+// modify carrega o indice de cada campo, aplica a alteracao e o regrava
+func modify(path string, fields []string, change func(ni *NgramIndex, field string)) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	for _, field := range fields {
+		d, err := open(ngramFile(path, field))
+		if err != nil {
+			return err
+		}
+		ni := d.load()
+		d.file.Close()
+
+		change(ni, field)
+		if err := ni.write(ngramFile(path, field)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NgramCreate adiciona um objeto aos indices dos campos fornecidos
+func NgramCreate(obj IndexableObject, path string, fields ...string) error {
+	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+	return modify(path, fields, func(ni *NgramIndex, field string) {
+		ni.AddDocument(id, obj.GetField(field))
+	})
+}
+
+// NgramUpdate troca, nos indices dos campos fornecidos, os trigramas
+// antigos de um objeto pelos novos
+func NgramUpdate(old IndexableObject, new IndexableObject, path string, fields ...string) error {
+	oldID, _ := strconv.ParseInt(old.GetField("id"), 10, 64)
+	newID, _ := strconv.ParseInt(new.GetField("id"), 10, 64)
+	return modify(path, fields, func(ni *NgramIndex, field string) {
+		ni.RemoveDocument(oldID, old.GetField(field))
+		ni.AddDocument(newID, new.GetField(field))
+	})
+}
+
+// NgramDelete remove um objeto dos indices dos campos fornecidos
+func NgramDelete(obj IndexableObject, path string, fields ...string) error {
+	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+	return modify(path, fields, func(ni *NgramIndex, field string) {
+		ni.RemoveDocument(id, obj.GetField(field))
+	})
+}
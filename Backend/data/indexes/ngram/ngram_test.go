@@ -0,0 +1,188 @@
+// Testes do indice de trigramas: trigramas normalizados de um texto,
+// candidatos de uma pesquisa, indices de outra versao, intersecao das listas
+// e o indice regravado apos remocoes, comparado com um indice criado do zero
+// com os mesmos documentos
+package ngram
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// testObject é um objeto com os campos "id" e "descricao"
+type testObject struct {
+	id   int64
+	text string
+}
+
+func (o testObject) GetField(fieldName string) string {
+	if fieldName == "id" {
+		return strconv.FormatInt(o.id, 10)
+	}
+	return o.text
+}
+
+// testReader percorre uma lista de objetos
+type testReader struct {
+	objects []testObject
+	next    int
+}
+
+func (r *testReader) ReadNextGeneric() (any, bool, int64, error) {
+	if r.next >= len(r.objects) {
+		return nil, false, 0, errors.New("EOF")
+	}
+	o := r.objects[r.next]
+	r.next++
+	return o, false, 0, nil
+}
+
+// newTestIndex grava o indice do campo "descricao" com os objetos fornecidos
+func newTestIndex(t *testing.T, objects ...testObject) string {
+	t.Helper()
+	path := t.TempDir()
+	if err := StartNgramFile(&testReader{objects: objects}, path, "descricao"); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadIndex carrega o indice gravado do campo "descricao"
+func loadIndex(t *testing.T, path string) *NgramIndex {
+	t.Helper()
+	d, err := open(ngramFile(path, "descricao"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.file.Close()
+	return d.load()
+}
+
+func TestGrams(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"Fire", []string{"FIR", "IRE"}},
+		{"FIRE fire", []string{"FIR", "IRE", "RE ", "E F", " FI"}},
+		{"aaaaa", []string{"AAA"}},
+		// Letras normalizadas como na conferencia dos candidatos
+		{"ſoſ", []string{"SOS"}},
+		{"λόγος", []string{"ΛΌΓ", "ΌΓΟ", "ΓΟΣ"}},
+		{"ΛΌΓΟΣ", []string{"ΛΌΓ", "ΌΓΟ", "ΓΟΣ"}},
+		{"ピカチュウ", []string{"ピカチ", "カチュ", "チュウ"}},
+		{"ab", []string{}},
+		{"", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := Grams(test.text); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Grams(%q) = %q, expected %q", test.text, got, test.expected)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	path := newTestIndex(t,
+		testObject{1, "A flame burns on the tip of its tail"},
+		testObject{2, "Fire Fire"},
+		testObject{3, "fir ire"},
+		testObject{4, "It stores electricity"},
+	)
+
+	tests := []struct {
+		pattern  string
+		expected []int64
+		filtered bool
+	}{
+		// Trigramas fora de ordem tambem sao candidatos (documento 3)
+		{"fire", []int64{2, 3}, true},
+		{"FLAME", []int64{1}, true},
+		{"its t", []int64{1}, true},
+		{"ſtores", []int64{4}, true},
+		{"xyz", []int64{}, true},
+		// Padroes com menos de 3 runas nao podem ser filtrados
+		{"fi", nil, false},
+		{"", nil, false},
+	}
+
+	for _, test := range tests {
+		ids, documents, filtered, err := Candidates(path, "descricao", test.pattern)
+		if err != nil {
+			t.Fatalf("Candidates(%q) = %v", test.pattern, err)
+		}
+		if !reflect.DeepEqual(ids, test.expected) || filtered != test.filtered {
+			t.Errorf("Candidates(%q) = %v, %t, expected %v, %t", test.pattern, ids, filtered, test.expected, test.filtered)
+		}
+		if documents != 4 {
+			t.Errorf("Candidates(%q) documents = %d, expected 4", test.pattern, documents)
+		}
+	}
+
+	if _, _, _, err := Candidates(path, "nome", "fire"); err == nil {
+		t.Errorf("Candidates on a missing index returned no error")
+	}
+
+	// Um indice sem versao no rodape é rejeitado
+	file := ngramFile(path, "descricao")
+	info, _ := os.Stat(file)
+	if err := os.Truncate(file, info.Size()-8); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := Candidates(path, "descricao", "fire"); err == nil {
+		t.Errorf("Candidates on an index without version returned no error")
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		a, b     []int64
+		expected []int64
+	}{
+		{[]int64{1, 3, 5, 7}, []int64{3, 4, 5, 8}, []int64{3, 5}},
+		{[]int64{1, 2, 3}, []int64{1, 2, 3}, []int64{1, 2, 3}},
+		{[]int64{1, 2}, []int64{3, 4}, []int64{}},
+		{[]int64{}, []int64{1}, []int64{}},
+		{[]int64{10}, []int64{1, 5, 10, 20}, []int64{10}},
+	}
+
+	for _, test := range tests {
+		if got := intersect(test.a, test.b); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("intersect(%v, %v) = %v, expected %v", test.a, test.b, got, test.expected)
+		}
+	}
+}
+
+func TestRemoveAndReload(t *testing.T) {
+	charmander := testObject{1, "Obviously prefers hot places"}
+	pikachu := testObject{2, "It stores electricity in its cheeks"}
+	raichu := testObject{3, "Its tail discharges electricity"}
+	path := newTestIndex(t, charmander, pikachu, raichu)
+
+	if err := NgramDelete(pikachu, path, "descricao"); err != nil {
+		t.Fatal(err)
+	}
+	if err := NgramUpdate(raichu, testObject{3, "It stores electricity in its tail"}, path, "descricao"); err != nil {
+		t.Fatal(err)
+	}
+	if err := NgramCreate(testObject{4, "Hot flames"}, path, "descricao"); err != nil {
+		t.Fatal(err)
+	}
+
+	// O indice regravado é o mesmo de um indice criado com os documentos finais,
+	// sem trigramas que ficaram sem documentos
+	expected := loadIndex(t, newTestIndex(t, charmander, testObject{3, "It stores electricity in its tail"}, testObject{4, "Hot flames"}))
+	if got := loadIndex(t, path); !reflect.DeepEqual(got, expected) {
+		t.Errorf("reloaded index = %v, expected %v", got, expected)
+	}
+
+	tests := map[string][]int64{"cheeks": {}, "electricity": {3}, "hot": {1, 4}, "tail": {3}}
+	for pattern, expected := range tests {
+		if ids, _, _, _ := Candidates(path, "descricao", pattern); !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Candidates(%q) = %v, expected %v", pattern, ids, expected)
+		}
+	}
+}
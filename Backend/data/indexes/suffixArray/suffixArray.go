@@ -0,0 +1,538 @@
+// O pacote suffixArray implementa um array de sufixos com array LCP sobre um
+// campo textual, usado para responder pesquisas de substrings sem percorrer a
+// database nem conferir candidatos.
+//
+// Os textos de todos os documentos sao concatenados, com as letras
+// normalizadas (ver utils.FoldRunes) e separados por SEPARATOR. O array de
+// sufixos guarda as posicoes de todos os sufixos do texto em ordem
+// lexicografica, entao todas as ocorrencias de um padrao sao sufixos
+// consecutivos do array, encontrados com uma busca binaria. O array LCP guarda
+// o tamanho do maior prefixo comum entre cada sufixo e o anterior, o que
+// permite percorrer as ocorrencias sem comparar cada uma com o padrao.
+//
+// Cada sufixo é comparado apenas ate o fim do seu documento, e sufixos iguais
+// sao desempatados pela posicao. Assim a ordem dos sufixos de um documento nao
+// depende dos demais, e o array é alterado incrementalmente: sufixos de um
+// documento removido sao retirados do array, e os de um documento inserido sao
+// ordenados e intercalados com os existentes, sem reordenar todo o texto. O
+// texto de um documento removido continua no arquivo ate que o texto morto
+// supere o texto vivo, quando o indice é reconstruido a partir do texto vivo.
+//
+// O indice de cada campo é gravado em um arquivo com o formato:
+//
+//	[texto]       runas (int32) dos documentos, cada um seguido de SEPARATOR
+//	[sufixos]     posicao (int32) de cada sufixo, em ordem lexicografica
+//	[lcp]         prefixo comum (int32) de cada sufixo com o anterior
+//	[documentos]  id, inicio e tamanho (int64) de cada documento, por inicio
+//	[rodape]      tamanho do texto, quantidade de sufixos e de documentos (int64)
+//
+// Uma pesquisa faz a busca binaria direto no arquivo, lendo apenas os trechos
+// do texto comparados com o padrao. Alteracoes carregam o indice, o modificam
+// em memoria e regravam o arquivo.
+package suffixArray
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Separador dos documentos no texto, diretorio dos arquivos do indice,
+// tamanhos das entradas do arquivo e quantidade de entradas do LCP lidas
+// por vez durante uma pesquisa
+const (
+	SEPARATOR     rune   = 0
+	SUFFIX_DIR    string = "suffixArray"
+	FOOTER_SIZE   int64  = 3 * 8
+	ENTRY_SIZE    int64  = 4
+	DOCUMENT_SIZE int64  = 3 * 8
+	LCP_BLOCK     int64  = 1024
+)
+
+// Interface para leitura da database
+type Reader interface {
+	ReadNextGeneric() (any, bool, int64, error)
+}
+
+// Interface para recuperacao do campo do objeto indexavel
+type IndexableObject interface {
+	GetField(fieldName string) string
+}
+
+// Document é o trecho [Start, Start+Length) do texto com o campo de um documento
+type Document struct {
+	ID     int64
+	Start  int64
+	Length int64
+}
+
+// Occurrence é uma ocorrencia de um padrao no campo de um documento,
+// na posicao Offset (em runas) do campo
+type Occurrence struct {
+	DocumentID int64
+	Offset     int
+}
+
+// SuffixArray é um array de sufixos em memoria
+type SuffixArray struct {
+	Text      []rune     // Textos dos documentos, separados por SEPARATOR
+	Suffixes  []int32    // Posicao de cada sufixo, em ordem
+	LCP       []int32    // Prefixo comum de cada sufixo com o anterior
+	Documents []Document // Documentos vivos, por inicio
+}
+
+// Regravacoes dos arquivos sao feitas uma por vez
+var writeMutex sync.Mutex
+
+// NewSuffixArray cria um indice vazio
+func NewSuffixArray() *SuffixArray {
+	return &SuffixArray{
+		Text:      make([]rune, 0),
+		Suffixes:  make([]int32, 0),
+		LCP:       make([]int32, 0),
+		Documents: make([]Document, 0),
+	}
+}
+
+// suffixFile retorna o caminho do arquivo do indice de um campo
+func suffixFile(path string, field string) string {
+	return filepath.Join(path, SUFFIX_DIR, field+".bin")
+}
+
+// ===================================== Sufixos ====================================== //
+
+// less compara os sufixos que comecam nas posicoes a e b ate o fim de seus
+// documentos. O separador é menor que qualquer caractere, e sufixos iguais
+// sao ordenados pela posicao
+func (sa *SuffixArray) less(a int32, b int32) bool {
+	for i, j := a, b; ; i, j = i+1, j+1 {
+		ca, cb := sa.Text[i], sa.Text[j]
+		if ca != cb {
+			return ca < cb
+		}
+		if ca == SEPARATOR {
+			return a < b
+		}
+	}
+}
+
+// lcp retorna o tamanho do maior prefixo comum de dois sufixos
+func (sa *SuffixArray) lcp(a int32, b int32) int32 {
+	k := int32(0)
+	for sa.Text[a+k] == sa.Text[b+k] && sa.Text[a+k] != SEPARATOR {
+		k++
+	}
+	return k
+}
+
+// computeLCP recalcula o array LCP a partir do array de sufixos
+func (sa *SuffixArray) computeLCP() {
+	sa.LCP = make([]int32, len(sa.Suffixes))
+	for i := 1; i < len(sa.Suffixes); i++ {
+		sa.LCP[i] = sa.lcp(sa.Suffixes[i-1], sa.Suffixes[i])
+	}
+}
+
+// appendText adiciona o campo de um documento ao fim do texto e retorna as
+// posicoes dos seus sufixos, ainda fora do array
+func (sa *SuffixArray) appendText(id int64, text string) []int32 {
+	runes := utils.FoldRunes(text)
+	start := int32(len(sa.Text))
+	for _, r := range runes {
+		// O separador nao pode aparecer dentro de um documento
+		if r == SEPARATOR {
+			r = ' '
+		}
+		sa.Text = append(sa.Text, r)
+	}
+	sa.Text = append(sa.Text, SEPARATOR)
+	sa.Documents = append(sa.Documents, Document{ID: id, Start: int64(start), Length: int64(len(runes))})
+
+	positions := make([]int32, len(runes))
+	for i := range positions {
+		positions[i] = start + int32(i)
+	}
+	return positions
+}
+
+// sortSuffixes ordena posicoes de sufixos
+func (sa *SuffixArray) sortSuffixes(positions []int32) {
+	sort.Slice(positions, func(i, j int) bool { return sa.less(positions[i], positions[j]) })
+}
+
+// AddDocument insere os sufixos do campo de um documento no array. Os novos
+// sufixos sao ordenados e intercalados com os existentes em O(n + k log k),
+// sendo n o tamanho do array e k o do campo
+func (sa *SuffixArray) AddDocument(id int64, text string) {
+	positions := sa.appendText(id, text)
+	sa.sortSuffixes(positions)
+
+	merged := make([]int32, 0, len(sa.Suffixes)+len(positions))
+	i, j := 0, 0
+	for i < len(sa.Suffixes) && j < len(positions) {
+		if sa.less(positions[j], sa.Suffixes[i]) {
+			merged = append(merged, positions[j])
+			j++
+		} else {
+			merged = append(merged, sa.Suffixes[i])
+			i++
+		}
+	}
+	merged = append(merged, sa.Suffixes[i:]...)
+	merged = append(merged, positions[j:]...)
+
+	sa.Suffixes = merged
+	sa.computeLCP()
+}
+
+// RemoveDocument retira os sufixos do campo de um documento do array. Quando
+// o texto morto supera o texto vivo, o indice é reconstruido
+func (sa *SuffixArray) RemoveDocument(id int64) {
+	index := -1
+	for i, doc := range sa.Documents {
+		if doc.ID == id {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return
+	}
+	doc := sa.Documents[index]
+	sa.Documents = append(sa.Documents[:index], sa.Documents[index+1:]...)
+
+	// A ordem relativa dos demais sufixos nao muda
+	suffixes := sa.Suffixes[:0]
+	for _, p := range sa.Suffixes {
+		if int64(p) < doc.Start || int64(p) >= doc.Start+doc.Length {
+			suffixes = append(suffixes, p)
+		}
+	}
+	sa.Suffixes = suffixes
+
+	if live := int64(len(sa.Suffixes) + len(sa.Documents)); int64(len(sa.Text))-live > live {
+		sa.rebuild()
+	} else {
+		sa.computeLCP()
+	}
+}
+
+// rebuild reconstroi o indice apenas com o texto dos documentos vivos
+func (sa *SuffixArray) rebuild() {
+	texts := make([]string, len(sa.Documents))
+	for i, doc := range sa.Documents {
+		texts[i] = string(sa.Text[doc.Start : doc.Start+doc.Length])
+	}
+	documents := sa.Documents
+
+	*sa = *NewSuffixArray()
+	for i, doc := range documents {
+		sa.Suffixes = append(sa.Suffixes, sa.appendText(doc.ID, texts[i])...)
+	}
+	sa.sortSuffixes(sa.Suffixes)
+	sa.computeLCP()
+}
+
+// ===================================== Arquivo ====================================== //
+
+// write grava o indice em um arquivo temporario e o renomeia por cima do atual
+func (sa *SuffixArray) write(filePath string) error {
+	os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	tmpPath := filePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	text := make([]int32, len(sa.Text))
+	for i, r := range sa.Text {
+		text[i] = int32(r)
+	}
+	binary.Write(w, binary.LittleEndian, text)
+	binary.Write(w, binary.LittleEndian, sa.Suffixes)
+	binary.Write(w, binary.LittleEndian, sa.LCP)
+	for _, doc := range sa.Documents {
+		binary.Write(w, binary.LittleEndian, doc)
+	}
+
+	// Rodape
+	binary.Write(w, binary.LittleEndian, int64(len(sa.Text)))
+	binary.Write(w, binary.LittleEndian, int64(len(sa.Suffixes)))
+	binary.Write(w, binary.LittleEndian, int64(len(sa.Documents)))
+
+	w.Flush()
+	file.Close()
+
+	return os.Rename(tmpPath, filePath)
+}
+
+// diskArray é um indice aberto para leitura direto no arquivo
+type diskArray struct {
+	file      *os.File
+	textLen   int64
+	suffixes  int64
+	documents []Document
+}
+
+// open abre o arquivo do indice de um campo, lendo seu rodape e seus documentos
+func open(filePath string) (*diskArray, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	size, _ := file.Seek(0, io.SeekEnd)
+	if size < FOOTER_SIZE {
+		file.Close()
+		return nil, fmt.Errorf("invalid suffix array '%s'", filePath)
+	}
+
+	buf := make([]byte, FOOTER_SIZE)
+	file.ReadAt(buf, size-FOOTER_SIZE)
+	d := &diskArray{file: file}
+	ptr := 0
+	var count int64
+	d.textLen, ptr = utils.BytesToInt64(buf, ptr)
+	d.suffixes, ptr = utils.BytesToInt64(buf, ptr)
+	count, _ = utils.BytesToInt64(buf, ptr)
+
+	buf = make([]byte, count*DOCUMENT_SIZE)
+	file.ReadAt(buf, d.documentsOffset())
+	d.documents = make([]Document, count)
+	ptr = 0
+	for i := range d.documents {
+		d.documents[i].ID, ptr = utils.BytesToInt64(buf, ptr)
+		d.documents[i].Start, ptr = utils.BytesToInt64(buf, ptr)
+		d.documents[i].Length, ptr = utils.BytesToInt64(buf, ptr)
+	}
+
+	return d, nil
+}
+
+// Offsets de cada secao do arquivo
+func (d *diskArray) suffixesOffset() int64  { return d.textLen * ENTRY_SIZE }
+func (d *diskArray) lcpOffset() int64       { return d.suffixesOffset() + d.suffixes*ENTRY_SIZE }
+func (d *diskArray) documentsOffset() int64 { return d.lcpOffset() + d.suffixes*ENTRY_SIZE }
+
+// int32s lê 'count' inteiros de 32 bits a partir de um offset
+func (d *diskArray) int32s(offset int64, count int64) []int32 {
+	buf := make([]byte, count*ENTRY_SIZE)
+	d.file.ReadAt(buf, offset)
+
+	values := make([]int32, count)
+	for i := range values {
+		values[i] = int32(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return values
+}
+
+// suffix lê a posicao do i-esimo sufixo do array
+func (d *diskArray) suffix(i int64) int64 {
+	return int64(d.int32s(d.suffixesOffset()+i*ENTRY_SIZE, 1)[0])
+}
+
+// compare compara o sufixo na posicao p com o padrao, lendo apenas os
+// caracteres necessarios. Retorna 0 quando o padrao é prefixo do sufixo
+func (d *diskArray) compare(p int64, pattern []rune) int {
+	count := int64(len(pattern))
+	if p+count > d.textLen {
+		count = d.textLen - p
+	}
+	text := d.int32s(p*ENTRY_SIZE, count)
+
+	for k, r := range pattern {
+		if k >= len(text) || rune(text[k]) == SEPARATOR {
+			return -1
+		}
+		if rune(text[k]) != r {
+			if rune(text[k]) < r {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// load carrega o indice inteiro para a memoria
+func (d *diskArray) load() *SuffixArray {
+	sa := NewSuffixArray()
+	for _, r := range d.int32s(0, d.textLen) {
+		sa.Text = append(sa.Text, rune(r))
+	}
+	sa.Suffixes = d.int32s(d.suffixesOffset(), d.suffixes)
+	sa.LCP = d.int32s(d.lcpOffset(), d.suffixes)
+	sa.Documents = d.documents
+	return sa
+}
+
+// document retorna o documento que contem uma posicao do texto
+func (d *diskArray) document(p int64) Document {
+	i := sort.Search(len(d.documents), func(i int) bool { return d.documents[i].Start > p })
+	return d.documents[i-1]
+}
+
+// ===================================== Pesquisa ===================================== //
+
+// Search retorna todas as ocorrencias de um padrao no campo dos documentos,
+// ignorando a diferenca entre letras maiusculas e minusculas, ordenadas por
+// documento e posicao, e a quantidade de documentos indexados.
+//
+// A primeira ocorrencia é encontrada com uma busca binaria no array de
+// sufixos, em O(m log n) para um padrao de tamanho m. As demais sao os
+// sufixos seguintes cujo LCP com o anterior é pelo menos m, entao nao
+// precisam ser comparadas com o padrao
+func Search(path string, field string, pattern string) (occurrences []Occurrence, documents int64, err error) {
+	d, err := open(suffixFile(path, field))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer d.file.Close()
+
+	occurrences = make([]Occurrence, 0)
+	folded := utils.FoldRunes(pattern)
+	for _, r := range folded {
+		if r == SEPARATOR {
+			return occurrences, int64(len(d.documents)), nil
+		}
+	}
+	if len(folded) == 0 {
+		return occurrences, int64(len(d.documents)), nil
+	}
+
+	// Primeiro sufixo maior ou igual ao padrao
+	lo, hi := int64(0), d.suffixes
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if d.compare(d.suffix(mid), folded) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == d.suffixes || d.compare(d.suffix(lo), folded) != 0 {
+		return occurrences, int64(len(d.documents)), nil
+	}
+
+	// Sufixos seguintes que compartilham o padrao inteiro
+	end := lo + 1
+	m := int32(len(folded))
+	for done := false; !done && end < d.suffixes; {
+		count := LCP_BLOCK
+		if end+count > d.suffixes {
+			count = d.suffixes - end
+		}
+		for _, l := range d.int32s(d.lcpOffset()+end*ENTRY_SIZE, count) {
+			if l < m {
+				done = true
+				break
+			}
+			end++
+		}
+	}
+
+	for _, p := range d.int32s(d.suffixesOffset()+lo*ENTRY_SIZE, end-lo) {
+		doc := d.document(int64(p))
+		occurrences = append(occurrences, Occurrence{DocumentID: doc.ID, Offset: int(int64(p) - doc.Start)})
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		if occurrences[i].DocumentID != occurrences[j].DocumentID {
+			return occurrences[i].DocumentID < occurrences[j].DocumentID
+		}
+		return occurrences[i].Offset < occurrences[j].Offset
+	})
+
+	return occurrences, int64(len(d.documents)), nil
+}
+
+// ======================================= Crud ======================================== //
+
+// StartSuffixArrayFile cria o array de sufixos de um campo a partir de um
+// Reader, ordenando todos os sufixos de uma vez.
+//
+// A interface retornada por ReadNextGeneric deve possuir:
+// GetField(fieldName string) string.
+func StartSuffixArrayFile(controler Reader, path string, field string) error {
+	sa := NewSuffixArray()
+
+	for {
+		objInterface, isDead, _, err := controler.ReadNextGeneric()
+		if err != nil {
+			break
+		}
+
+		obj, ok := objInterface.(IndexableObject)
+		if !ok {
+			return fmt.Errorf("failed to convert object to IndexableObject\n%+v", objInterface)
+		}
+
+		if !isDead {
+			id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+			sa.Suffixes = append(sa.Suffixes, sa.appendText(id, obj.GetField(field))...)
+		}
+	}
+	sa.sortSuffixes(sa.Suffixes)
+	sa.computeLCP()
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+	return sa.write(suffixFile(path, field))
+}
+
+// modify carrega o indice de cada campo, aplica a alteracao e o regrava
+func modify(path string, fields []string, change func(sa *SuffixArray, field string)) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	for _, field := range fields {
+		d, err := open(suffixFile(path, field))
+		if err != nil {
+			return err
+		}
+		sa := d.load()
+		d.file.Close()
+
+		change(sa, field)
+		if err := sa.write(suffixFile(path, field)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SuffixArrayCreate adiciona um objeto aos indices dos campos fornecidos
+func SuffixArrayCreate(obj IndexableObject, path string, fields ...string) error {
+	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+	return modify(path, fields, func(sa *SuffixArray, field string) {
+		sa.AddDocument(id, obj.GetField(field))
+	})
+}
+
+// SuffixArrayUpdate troca, nos indices dos campos fornecidos, os sufixos
+// antigos de um objeto pelos novos
+func SuffixArrayUpdate(old IndexableObject, new IndexableObject, path string, fields ...string) error {
+	oldID, _ := strconv.ParseInt(old.GetField("id"), 10, 64)
+	newID, _ := strconv.ParseInt(new.GetField("id"), 10, 64)
+	return modify(path, fields, func(sa *SuffixArray, field string) {
+		sa.RemoveDocument(oldID)
+		sa.AddDocument(newID, new.GetField(field))
+	})
+}
+
+// SuffixArrayDelete remove um objeto dos indices dos campos fornecidos
+func SuffixArrayDelete(obj IndexableObject, path string, fields ...string) error {
+	id, _ := strconv.ParseInt(obj.GetField("id"), 10, 64)
+	return modify(path, fields, func(sa *SuffixArray, field string) {
+		sa.RemoveDocument(id)
+	})
+}
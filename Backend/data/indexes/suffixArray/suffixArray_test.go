@@ -0,0 +1,147 @@
+// Testes do array de sufixos: depois da criacao e de cada insercao,
+// alteracao e remocao, as ocorrencias encontradas devem ser as mesmas de uma
+// busca ingenua em todos os documentos
+package suffixArray
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// testDocument é um objeto com os campos "id" e "descricao"
+type testDocument struct {
+	id   int64
+	text string
+}
+
+func (d testDocument) GetField(fieldName string) string {
+	if fieldName == "id" {
+		return strconv.FormatInt(d.id, 10)
+	}
+	return d.text
+}
+
+// testReader percorre uma lista de documentos, dos quais os de id
+// presente em dead estao removidos
+type testReader struct {
+	documents []testDocument
+	dead      map[int64]bool
+	next      int
+}
+
+func (r *testReader) ReadNextGeneric() (any, bool, int64, error) {
+	if r.next >= len(r.documents) {
+		return nil, false, 0, errors.New("EOF")
+	}
+	d := r.documents[r.next]
+	r.next++
+	return d, r.dead[d.id], 0, nil
+}
+
+// bruteForce procura o padrao em cada posicao de cada documento
+func bruteForce(documents map[int64]string, pattern string) []Occurrence {
+	occurrences := make([]Occurrence, 0)
+	p := utils.FoldRunes(pattern)
+	if len(p) == 0 {
+		return occurrences
+	}
+
+	for id, text := range documents {
+		t := utils.FoldRunes(text)
+		for i := 0; i+len(p) <= len(t); i++ {
+			if reflect.DeepEqual(t[i:i+len(p)], p) {
+				occurrences = append(occurrences, Occurrence{DocumentID: id, Offset: i})
+			}
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		if occurrences[i].DocumentID != occurrences[j].DocumentID {
+			return occurrences[i].DocumentID < occurrences[j].DocumentID
+		}
+		return occurrences[i].Offset < occurrences[j].Offset
+	})
+	return occurrences
+}
+
+func TestSuffixArray(t *testing.T) {
+	const field = "descricao"
+	path := t.TempDir()
+	patterns := []string{
+		"a", "fire", "FIRE", "tail", "the", "s t", "flame", "ピカ", "チュウ",
+		"ß", "ss", "água", "it", "itsu", "xyz", "", "burns on its tail",
+	}
+
+	documents := map[int64]string{
+		1: "A flame burns on the tip of its tail from birth.",
+		2: "It stores electricity in its cheeks. ピカチュウ",
+		3: "Obviously prefers hot places. When it rains, steam is said to spout from the tip of its tail.",
+		4: "Fire Fire fire",
+		5: "ẞ ß ss SS",
+	}
+	reader := &testReader{dead: map[int64]bool{3: true}}
+	for id := int64(1); id <= 5; id++ {
+		reader.documents = append(reader.documents, testDocument{id, documents[id]})
+	}
+	delete(documents, 3)
+
+	if err := StartSuffixArrayFile(reader, path, field); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(step string) {
+		t.Helper()
+		for _, pattern := range patterns {
+			got, total, err := Search(path, field, pattern)
+			if err != nil {
+				t.Fatalf("%s: Search(%q) = %v", step, pattern, err)
+			}
+			expected := bruteForce(documents, pattern)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s: Search(%q) = %v, expected %v", step, pattern, got, expected)
+			}
+			if total != int64(len(documents)) {
+				t.Errorf("%s: Search(%q) documents = %d, expected %d", step, pattern, total, len(documents))
+			}
+		}
+	}
+	check("start")
+
+	steps := []struct {
+		name string
+		old  *testDocument
+		new  *testDocument
+	}{
+		{"create", nil, &testDocument{6, "Its tail burns like a fire. ライチュウ"}},
+		{"create", nil, &testDocument{7, ""}},
+		{"update", &testDocument{1, documents[1]}, &testDocument{1, "The flame on its tail shows its life force."}},
+		{"delete", &testDocument{4, documents[4]}, nil},
+		{"update", &testDocument{2, documents[2]}, &testDocument{2, "ピカピカ"}},
+		{"delete", &testDocument{5, documents[5]}, nil},
+		{"delete", &testDocument{6, "Its tail burns like a fire. ライチュウ"}, nil},
+		{"create", nil, &testDocument{8, "Fire burns, FIRE!"}},
+	}
+
+	for _, step := range steps {
+		var err error
+		switch {
+		case step.old == nil:
+			err = SuffixArrayCreate(*step.new, path, field)
+			documents[step.new.id] = step.new.text
+		case step.new == nil:
+			err = SuffixArrayDelete(*step.old, path, field)
+			delete(documents, step.old.id)
+		default:
+			err = SuffixArrayUpdate(*step.old, *step.new, path, field)
+			documents[step.new.id] = step.new.text
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		check(step.name)
+	}
+}
@@ -0,0 +1,394 @@
+// O pacote trie implementa uma arvore de prefixos (trie) persistente, usada
+// para sugerir palavras enquanto o usuario digita.
+//
+// Cada campo indexado possui um arquivo com as palavras de seus documentos.
+// A chave de cada palavra é a sua forma minuscula e sem acentos, e cada palavra
+// guarda a grafia original com que foi inserida e a quantidade de documentos em
+// que aparece, usada para ordenar as sugestoes.
+//
+// Os nós sao gravados em pos-ordem, com os filhos de cada nó ordenados pelo
+// caractere, de forma que uma pesquisa desce do nó raiz direto no arquivo
+// com buscas binarias e lê apenas a subarvore do prefixo procurado:
+//
+//	[cabecalho]  endereco da raiz (int64)
+//	[nó]         documentos (int64), grafia (int32 + bytes),
+//	             filhos (int32), (caractere int32, endereco int64)...
+//
+// Alteracoes carregam a arvore, a modificam em memoria e regravam o arquivo.
+//
+// Exemplo de uso:
+//
+//	trie.StartTrieFile(controler, binManager.FILES_PATH, "nome")
+//	trie.Suggest(binManager.FILES_PATH, "nome", "Char", 10)
+package trie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Diretorio dos arquivos da trie
+const TRIE_DIR string = "trie"
+
+// Interface para leitura da database
+type Reader interface {
+	ReadNextGeneric() (any, bool, int64, error)
+}
+
+// Interface para recuperacao do campo do objeto indexavel
+type IndexableObject interface {
+	GetField(fieldName string) string
+}
+
+// Suggestion é uma palavra sugerida e a quantidade de documentos que a contem
+type Suggestion struct {
+	Term      string `json:"term"`
+	Documents int64  `json:"documents"`
+}
+
+// Trie é uma arvore de prefixos em memoria
+type Trie struct {
+	root *node
+}
+
+// node é um nó da trie. Os nós com documentos > 0 terminam uma palavra
+type node struct {
+	documents int64
+	display   string
+	children  map[rune]*node
+}
+
+// Regravacoes dos arquivos sao feitas uma por vez
+var writeMutex sync.Mutex
+
+// Palavras de um texto, preservando maiusculas e acentos
+var wordRegEx = regexp.MustCompile(`\p{L}+`)
+
+// newNode cria um nó vazio
+func newNode() *node {
+	return &node{children: make(map[rune]*node)}
+}
+
+// NewTrie cria uma trie vazia
+func NewTrie() *Trie {
+	return &Trie{root: newNode()}
+}
+
+// normalize retorna a chave de uma palavra: minuscula e sem acentos
+func normalize(word string) string {
+	return invertedIndex.FoldAccents(strings.ToLower(word))
+}
+
+// words retorna as palavras distintas de um texto, indexadas pela chave
+func words(text string) map[string]string {
+	result := make(map[string]string)
+	for _, word := range wordRegEx.FindAllString(text, -1) {
+		key := normalize(word)
+		if _, ok := result[key]; !ok {
+			result[key] = word
+		}
+	}
+	return result
+}
+
+// trieFile retorna o caminho do arquivo da trie de um campo
+func trieFile(path string, field string) string {
+	return filepath.Join(path, TRIE_DIR, field+".bin")
+}
+
+// AddDocument soma um documento a cada palavra distinta do texto
+func (t *Trie) AddDocument(text string) {
+	for key, word := range words(text) {
+		n := t.root
+		for _, r := range key {
+			child, ok := n.children[r]
+			if !ok {
+				child = newNode()
+				n.children[r] = child
+			}
+			n = child
+		}
+		if n.documents == 0 {
+			n.display = word
+		}
+		n.documents++
+	}
+}
+
+// RemoveDocument desconta um documento de cada palavra distinta do texto,
+// removendo os nós que deixam de levar a alguma palavra
+func (t *Trie) RemoveDocument(text string) {
+	for key := range words(text) {
+		t.root.remove([]rune(key))
+	}
+}
+
+// remove desconta um documento da palavra formada pelos caracteres
+// restantes e retorna se o nó ficou vazio
+func (n *node) remove(key []rune) bool {
+	if len(key) == 0 {
+		if n.documents > 0 {
+			n.documents--
+		}
+		if n.documents == 0 {
+			n.display = ""
+		}
+	} else if child, ok := n.children[key[0]]; ok && child.remove(key[1:]) {
+		delete(n.children, key[0])
+	}
+
+	return n.documents == 0 && len(n.children) == 0
+}
+
+// ===================================== Arquivo ====================================== //
+
+// write grava a trie em um arquivo temporario e o renomeia por cima do atual
+func (t *Trie) write(filePath string) error {
+	os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	tmpPath := filePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	offset := int64(8)
+	binary.Write(w, binary.LittleEndian, int64(0)) // reservado para a raiz
+	root := writeNode(w, t.root, &offset)
+	w.Flush()
+	file.WriteAt(utils.Int64ToBytes(root), 0)
+	file.Close()
+
+	return os.Rename(tmpPath, filePath)
+}
+
+// writeNode grava os filhos de um nó e depois o proprio nó,
+// retornando o seu endereco
+func writeNode(w *bufio.Writer, n *node, offset *int64) int64 {
+	keys := make([]rune, 0, len(n.children))
+	for r := range n.children {
+		keys = append(keys, r)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	addresses := make([]int64, len(keys))
+	for i, r := range keys {
+		addresses[i] = writeNode(w, n.children[r], offset)
+	}
+
+	address := *offset
+	write := func(data any) {
+		binary.Write(w, binary.LittleEndian, data)
+		*offset += int64(binary.Size(data))
+	}
+	write(n.documents)
+	write(int32(len(n.display)))
+	write([]byte(n.display))
+	write(int32(len(keys)))
+	for i, r := range keys {
+		write(int32(r))
+		write(addresses[i])
+	}
+
+	return address
+}
+
+// diskNode é um nó lido do arquivo, com os filhos ainda nao carregados
+type diskNode struct {
+	documents int64
+	display   string
+	keys      []rune
+	addresses []int64
+}
+
+// readNode lê o nó de um endereco do arquivo
+func readNode(file *os.File, address int64) diskNode {
+	buf := make([]byte, 12)
+	file.ReadAt(buf, address)
+	documents, ptr := utils.BytesToInt64(buf, 0)
+	size, _ := utils.BytesToInt32(buf, ptr)
+
+	buf = make([]byte, int(size)+4)
+	file.ReadAt(buf, address+12)
+	n := diskNode{documents: documents, display: string(buf[:size])}
+	count, _ := utils.BytesToInt32(buf, int(size))
+
+	buf = make([]byte, count*12)
+	file.ReadAt(buf, address+16+int64(size))
+	n.keys = make([]rune, count)
+	n.addresses = make([]int64, count)
+	for i, ptr := 0, 0; i < int(count); i++ {
+		var r int32
+		r, ptr = utils.BytesToInt32(buf, ptr)
+		n.keys[i] = rune(r)
+		n.addresses[i], ptr = utils.BytesToInt64(buf, ptr)
+	}
+
+	return n
+}
+
+// child retorna o endereco do filho de um caractere, ou -1 se nao existir
+func (n diskNode) child(r rune) int64 {
+	i := sort.Search(len(n.keys), func(i int) bool { return n.keys[i] >= r })
+	if i < len(n.keys) && n.keys[i] == r {
+		return n.addresses[i]
+	}
+	return -1
+}
+
+// rootAddress lê o endereco da raiz no cabecalho do arquivo
+func rootAddress(file *os.File) int64 {
+	buf := make([]byte, 8)
+	file.ReadAt(buf, 0)
+	address, _ := utils.BytesToInt64(buf, 0)
+	return address
+}
+
+// load carrega a trie de um arquivo para a memoria
+func load(filePath string) (*Trie, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var build func(address int64) *node
+	build = func(address int64) *node {
+		d := readNode(file, address)
+		n := &node{documents: d.documents, display: d.display, children: make(map[rune]*node, len(d.keys))}
+		for i, r := range d.keys {
+			n.children[r] = build(d.addresses[i])
+		}
+		return n
+	}
+
+	return &Trie{root: build(rootAddress(file))}, nil
+}
+
+// ===================================== Pesquisa ===================================== //
+
+// Suggest retorna ate 'limit' palavras de um campo que comecam com o prefixo,
+// da mais frequente para a menos frequente. O prefixo é comparado sem
+// diferenciar maiusculas, minusculas e acentos
+func Suggest(path string, field string, prefix string, limit int) ([]Suggestion, error) {
+	file, err := os.Open(trieFile(path, field))
+	if err != nil {
+		return nil, fmt.Errorf("trie for '%s' not found", field)
+	}
+	defer file.Close()
+
+	// Desce ate o nó do prefixo
+	address := rootAddress(file)
+	for _, r := range normalize(prefix) {
+		address = readNode(file, address).child(r)
+		if address == -1 {
+			return []Suggestion{}, nil
+		}
+	}
+
+	// Coleta as palavras da subarvore
+	suggestions := make([]Suggestion, 0)
+	stack := []int64{address}
+	for len(stack) > 0 {
+		n := readNode(file, stack[len(stack)-1])
+		stack = stack[:len(stack)-1]
+		if n.documents > 0 {
+			suggestions = append(suggestions, Suggestion{Term: n.display, Documents: n.documents})
+		}
+		stack = append(stack, n.addresses...)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Documents != suggestions[j].Documents {
+			return suggestions[i].Documents > suggestions[j].Documents
+		}
+		return normalize(suggestions[i].Term) < normalize(suggestions[j].Term)
+	})
+	if limit >= 0 && limit < len(suggestions) {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
+
+// ======================================= Crud ======================================== //
+
+// StartTrieFile cria a trie de um campo a partir de um Reader.
+//
+// A interface retornada por ReadNextGeneric deve possuir:
+// GetField(fieldName string) string.
+func StartTrieFile(controler Reader, path string, field string) error {
+	t := NewTrie()
+
+	for {
+		objInterface, isDead, _, err := controler.ReadNextGeneric()
+		if err != nil {
+			break
+		}
+
+		obj, ok := objInterface.(IndexableObject)
+		if !ok {
+			return fmt.Errorf("failed to convert object to IndexableObject\n%+v", objInterface)
+		}
+
+		if !isDead {
+			t.AddDocument(obj.GetField(field))
+		}
+	}
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+	return t.write(trieFile(path, field))
+}
+
+// modify carrega a trie de cada campo, aplica a alteracao e a regrava
+func modify(path string, fields []string, change func(t *Trie, field string)) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	for _, field := range fields {
+		t, err := load(trieFile(path, field))
+		if err != nil {
+			return err
+		}
+		change(t, field)
+		if err := t.write(trieFile(path, field)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TrieCreate adiciona as palavras de um objeto as tries dos campos fornecidos
+func TrieCreate(obj IndexableObject, path string, fields ...string) error {
+	return modify(path, fields, func(t *Trie, field string) {
+		t.AddDocument(obj.GetField(field))
+	})
+}
+
+// TrieUpdate troca, nas tries dos campos fornecidos, as palavras
+// antigas de um objeto pelas novas
+func TrieUpdate(old IndexableObject, new IndexableObject, path string, fields ...string) error {
+	return modify(path, fields, func(t *Trie, field string) {
+		t.RemoveDocument(old.GetField(field))
+		t.AddDocument(new.GetField(field))
+	})
+}
+
+// TrieDelete remove as palavras de um objeto das tries dos campos fornecidos
+func TrieDelete(obj IndexableObject, path string, fields ...string) error {
+	return modify(path, fields, func(t *Trie, field string) {
+		t.RemoveDocument(obj.GetField(field))
+	})
+}
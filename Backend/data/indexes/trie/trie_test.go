@@ -0,0 +1,159 @@
+// Testes da trie: ordenacao e limite das sugestoes, prefixos sem diferenciar
+// maiusculas, minusculas e acentos e remocao de palavras apos TrieDelete
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testObject é um objeto com um unico campo textual
+type testObject struct {
+	text string
+}
+
+func (o testObject) GetField(fieldName string) string {
+	return o.text
+}
+
+// testReader percorre uma lista de objetos
+type testReader struct {
+	objects []testObject
+	next    int
+}
+
+func (r *testReader) ReadNextGeneric() (any, bool, int64, error) {
+	if r.next >= len(r.objects) {
+		return nil, false, 0, errors.New("EOF")
+	}
+	o := r.objects[r.next]
+	r.next++
+	return o, false, int64(r.next), nil
+}
+
+// newTestTrie grava a trie do campo "descricao" com os textos fornecidos
+func newTestTrie(t *testing.T, texts ...string) string {
+	t.Helper()
+	path := t.TempDir()
+	reader := &testReader{}
+	for _, text := range texts {
+		reader.objects = append(reader.objects, testObject{text})
+	}
+	if err := StartTrieFile(reader, path, "descricao"); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSuggest(t *testing.T) {
+	path := newTestTrie(t,
+		"Charmander breathes fire",
+		"Charmeleon breathes fire, fire and fire",
+		"Charizard flies and breathes fire",
+		"Chansey",
+		"Flabébé e Pokémon",
+		"FLABÉBÉ",
+		"Pokemon",
+	)
+
+	tests := []struct {
+		prefix   string
+		limit    int
+		expected []Suggestion
+	}{
+		// Mais documentos primeiro; palavras repetidas em um documento contam uma vez
+		{"", 3, []Suggestion{{"breathes", 3}, {"fire", 3}, {"and", 2}}},
+		{"char", -1, []Suggestion{{"Charizard", 1}, {"Charmander", 1}, {"Charmeleon", 1}}},
+		{"charm", 1, []Suggestion{{"Charmander", 1}}},
+		{"ch", 0, []Suggestion{}},
+		// Maiusculas, minusculas e acentos sao ignorados no prefixo e nas
+		// palavras, que mantem a primeira grafia inserida
+		{"CHAR", 10, []Suggestion{{"Charizard", 1}, {"Charmander", 1}, {"Charmeleon", 1}}},
+		{"flabe", 10, []Suggestion{{"Flabébé", 2}}},
+		{"FLABÉ", 10, []Suggestion{{"Flabébé", 2}}},
+		{"pokè", 10, []Suggestion{{"Pokémon", 2}}},
+		{"chx", 10, []Suggestion{}},
+	}
+
+	for _, test := range tests {
+		got, err := Suggest(path, "descricao", test.prefix, test.limit)
+		if err != nil {
+			t.Fatalf("Suggest(%q) = %v", test.prefix, err)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Suggest(%q, %d) = %v, expected %v", test.prefix, test.limit, got, test.expected)
+		}
+	}
+
+	if _, err := Suggest(path, "nome", "char", 10); err == nil {
+		t.Errorf("Suggest on a missing trie returned no error")
+	}
+}
+
+func TestTrieCrud(t *testing.T) {
+	path := newTestTrie(t, "Charmander breathes fire", "Charmeleon breathes fire")
+
+	steps := []struct {
+		name     string
+		run      func() error
+		prefix   string
+		expected []Suggestion
+	}{
+		{
+			"create",
+			func() error { return TrieCreate(testObject{"Charizard breathes fire"}, path, "descricao") },
+			"",
+			[]Suggestion{{"breathes", 3}, {"fire", 3}, {"Charizard", 1}, {"Charmander", 1}, {"Charmeleon", 1}},
+		},
+		{
+			"update",
+			func() error {
+				return TrieUpdate(testObject{"Charizard breathes fire"}, testObject{"Charizard flies"}, path, "descricao")
+			},
+			"",
+			[]Suggestion{{"breathes", 2}, {"fire", 2}, {"Charizard", 1}, {"Charmander", 1}, {"Charmeleon", 1}, {"flies", 1}},
+		},
+		{
+			// A palavra sai da trie junto com os nós que so levavam a ela
+			"delete",
+			func() error { return TrieDelete(testObject{"Charmeleon breathes fire"}, path, "descricao") },
+			"charm",
+			[]Suggestion{{"Charmander", 1}},
+		},
+		{
+			"delete",
+			func() error { return TrieDelete(testObject{"Charmander breathes fire"}, path, "descricao") },
+			"",
+			[]Suggestion{{"Charizard", 1}, {"flies", 1}},
+		},
+		{
+			"delete",
+			func() error { return TrieDelete(testObject{"Charizard flies"}, path, "descricao") },
+			"",
+			[]Suggestion{},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got, err := Suggest(path, "descricao", step.prefix, -1)
+		if err != nil {
+			t.Fatalf("%s: Suggest(%q) = %v", step.name, step.prefix, err)
+		}
+		if !reflect.DeepEqual(got, step.expected) {
+			t.Errorf("%s: Suggest(%q) = %v, expected %v", step.name, step.prefix, got, step.expected)
+		}
+	}
+
+	// Sem palavras, a trie fica apenas com a raiz
+	tr, err := load(trieFile(path, "descricao"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.root.children) != 0 {
+		t.Errorf("empty trie still has %d children at the root", len(tr.root.children))
+	}
+}
@@ -0,0 +1,162 @@
+// Package ahoCorasick implementa o algoritmo de busca de multiplos padrões
+// Aho-Corasick
+//
+// Os padrões sao inseridos em uma trie, e cada nó recebe um link de falha
+// para o nó do maior sufixo proprio do seu prefixo que também é prefixo de
+// algum padrão. O automato resultante percorre o texto uma unica vez,
+// seguindo os links de falha quando o proximo caractere nao continua o
+// prefixo atual, e reporta todas as ocorrências de todos os padrões em
+// O(n + m + z), sendo n o tamanho do texto, m a soma dos tamanhos dos
+// padrões e z a quantidade de ocorrências.
+//
+// O automato percorre as runas dos textos com as letras normalizadas (ver
+// utils.FoldRunes), e as posições retornadas sao indices de runas, como no KMP.
+//
+// Este pacote fornece uma função SearchPokemon feita especificamente para o
+// processamento do banco de dados de Pokemons do trabalho original
+//
+// Exemplo de uso:
+//
+//	a := ahoCorasick.New([]string{"he", "she", "hers"})
+//	matches := a.Search("ushers")
+//	// matches: [{1 1} {0 2} {2 2}]
+package ahoCorasick
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Match é uma ocorrência do padrão de indice Pattern na posição Position do texto
+type Match struct {
+	Pattern  int `json:"pattern"`
+	Position int `json:"position"`
+}
+
+// Automaton é o automato de Aho-Corasick de um conjunto de padrões
+type Automaton struct {
+	nodes    []node
+	patterns []string
+	lengths  []int // Tamanho, em runas, de cada padrão
+}
+
+// node é um estado do automato
+type node struct {
+	next   map[rune]int // Transicoes da trie
+	fail   int          // Link de falha
+	output []int        // Padrões que terminam neste estado, incluindo os dos links de falha
+}
+
+// New constroi o automato dos padrões fornecidos, ignorando a diferença entre
+// letras maiúsculas e minúsculas. Padrões vazios sao ignorados
+func New(patterns []string) *Automaton {
+	a := &Automaton{
+		nodes:    []node{{next: make(map[rune]int)}},
+		patterns: make([]string, len(patterns)),
+		lengths:  make([]int, len(patterns)),
+	}
+
+	// Trie dos padrões
+	for p, pattern := range patterns {
+		folded := utils.FoldRunes(pattern)
+		a.patterns[p] = pattern
+		a.lengths[p] = len(folded)
+		if len(folded) == 0 {
+			continue
+		}
+
+		state := 0
+		for _, c := range folded {
+			child, ok := a.nodes[state].next[c]
+			if !ok {
+				child = len(a.nodes)
+				a.nodes = append(a.nodes, node{next: make(map[rune]int)})
+				a.nodes[state].next[c] = child
+			}
+			state = child
+		}
+		a.nodes[state].output = append(a.nodes[state].output, p)
+	}
+
+	// Links de falha em largura, de forma que o link de cada nó
+	// ja esteja pronto quando seus filhos forem processados
+	queue := make([]int, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for c, child := range a.nodes[state].next {
+			fail := a.nodes[state].fail
+			for fail != 0 && !a.has(fail, c) {
+				fail = a.nodes[fail].fail
+			}
+			if next, ok := a.nodes[fail].next[c]; ok && next != child {
+				fail = next
+			} else {
+				fail = 0
+			}
+
+			a.nodes[child].fail = fail
+			a.nodes[child].output = append(a.nodes[child].output, a.nodes[fail].output...)
+			queue = append(queue, child)
+		}
+	}
+
+	return a
+}
+
+// has testa se um estado possui transicao para o caractere
+func (a *Automaton) has(state int, c rune) bool {
+	_, ok := a.nodes[state].next[c]
+	return ok
+}
+
+// Patterns retorna os padrões do automato, como foram fornecidos
+func (a *Automaton) Patterns() []string {
+	return a.patterns
+}
+
+// Search percorre o texto uma unica vez e retorna as ocorrências de todos os
+// padrões, na ordem em que terminam no texto
+func (a *Automaton) Search(text string) (matches []Match) {
+	state := 0
+	for i, c := range utils.FoldRunes(text) {
+		for state != 0 && !a.has(state, c) {
+			state = a.nodes[state].fail
+		}
+		if next, ok := a.nodes[state].next[c]; ok {
+			state = next
+		}
+
+		for _, p := range a.nodes[state].output {
+			matches = append(matches, Match{Pattern: p, Position: i + 1 - a.lengths[p]})
+		}
+	}
+
+	return matches
+}
+
+// SearchPokemon realiza uma busca por varios termos (searches) ao mesmo tempo em um campo
+// específico (field) dos registros de Pokemon, retornando os documentos que contêm o Id do
+// pokemon que possui algum dos termos e a soma das frequencias de aparição de todos eles.
+func SearchPokemon(searches []string, field string) (scoredDocuments []invertedIndex.ScoredDocument) {
+	controller, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	defer controller.Close()
+	scoredDocuments = make([]invertedIndex.ScoredDocument, 0)
+	a := New(searches)
+
+	for err := controller.ReadNext(); err == nil; err = controller.ReadNext() {
+		if !controller.RegistroAtual.IsDead() {
+			needle := a.Search(controller.RegistroAtual.Pokemon.GetField(field))
+			if len(needle) > 0 {
+				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: float64(len(needle))})
+			}
+		}
+	}
+
+	return scoredDocuments
+}
@@ -0,0 +1,43 @@
+// Testes do automato de Aho-Corasick: as ocorrências de cada padrão devem
+// ser as mesmas de uma busca individual, em runas e sem diferenciar
+// maiúsculas de minúsculas
+package ahoCorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		text     string
+		expected []Match
+	}{
+		{[]string{"he", "she", "hers"}, "ushers", []Match{{1, 1}, {0, 2}, {2, 2}}},
+		{[]string{"HE", "She"}, "uSHErs", []Match{{1, 1}, {0, 2}}},
+		{[]string{"a", "aa"}, "aaa", []Match{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}}},
+		{[]string{"fogo", "agua"}, "Tipo AGUA e fogo", []Match{{1, 5}, {0, 12}}},
+		{[]string{"fogo", "água"}, "Tipo ÁGUA e fogo", []Match{{1, 5}, {0, 12}}},
+		{[]string{"ピカ", "チュウ"}, "ライチュウとピカチュウ", []Match{{1, 2}, {0, 6}, {1, 8}}},
+		{[]string{"ß"}, "ss SS ß ẞ", []Match{{0, 6}, {0, 8}}},
+		{[]string{"", "b"}, "abc", []Match{{1, 1}}},
+		{[]string{"xyz"}, "abc", nil},
+		{[]string{"abc"}, "", nil},
+	}
+
+	for _, test := range tests {
+		got := New(test.patterns).Search(test.text)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("New(%q).Search(%q) = %v, expected %v", test.patterns, test.text, got, test.expected)
+		}
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	expected := []string{"Pikachu", "ÁGUA", ""}
+	got := New(expected).Patterns()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Patterns() = %q, expected %q", got, expected)
+	}
+}
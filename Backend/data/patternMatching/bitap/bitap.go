@@ -0,0 +1,308 @@
+// Package bitap implementa a pesquisa aproximada de substrings com o algoritmo
+// Bitap (Shift-And), na extensao de Wu e Manber para ate k erros
+//
+// O Bitap representa os prefixos do padrao que terminam na posicao atual do
+// texto como bits de um inteiro: o bit i indica que os i+1 primeiros
+// caracteres do padrao casam com o texto ate ali. A cada caractere lido o
+// estado é deslocado um bit e combinado com a mascara das posicoes em que o
+// caractere aparece no padrao, em tempo O(n⌈m/64⌉).
+//
+// Na extensao de Wu-Manber é mantido um estado R[d] para cada quantidade de
+// erros d ate k, e cada estado recebe tambem as transicoes do estado R[d-1]
+// que gastam um erro: substituicao, insercao ou remocao de um caractere. Uma
+// ocorrencia com d erros termina onde o bit m-1 de R[d] esta ligado, e o seu
+// inicio é recuperado com a distancia de edicao entre o padrao e o trecho do
+// texto que termina ali.
+//
+// Os estados usam quantas palavras de 64 bits forem necessarias, entao o
+// padrao pode ter qualquer tamanho. A pesquisa ignora a diferenca entre letras
+// maiusculas e minusculas (ver utils.FoldRunes) e as posicoes sao indices de
+// runas, como no pacote kmp.
+//
+// Este pacote fornece uma função SearchPokemon feita especificamente para o
+// processamento do banco de dados de Pokemons do trabalho original
+//
+// Exemplo de uso:
+//
+//	matches := bitap.Bitap("seed on its back", "the sed on its bakc", 3)
+//	// matches: [{Start: 4, End: 18, Errors: 2}]
+//	matches = bitap.Bitap("Pokémon", "a POKEMON and a pokémon", 1)
+//	// matches: [{Start: 2, End: 9, Errors: 1} {Start: 16, End: 23, Errors: 0}]
+package bitap
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// WORD_SIZE é a quantidade de bits de cada palavra dos estados
+const WORD_SIZE int = 64
+
+// Match é uma ocorrencia aproximada do padrao no intervalo [Start, End) do
+// texto, em runas, com a quantidade de erros (distancia de edicao) do trecho
+type Match struct {
+	Start  int `json:"start"`
+	End    int `json:"end"`
+	Errors int `json:"errors"`
+}
+
+// MaxErrors retorna a quantidade de erros tolerada para um padrao: 0 para
+// padroes de ate 2 caracteres, 1 ate 5, 2 ate 10 e 3 para os demais
+func MaxErrors(pattern string) int {
+	switch n := len([]rune(pattern)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	case n <= 10:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Pieces divide o padrao em k+1 partes de tamanhos proximos. Cada erro altera
+// no maximo uma parte, entao toda ocorrencia com ate k erros contem ao menos
+// uma das partes sem alteracao, o que permite filtrar os candidatos de uma
+// pesquisa por indices de pesquisa exata
+func Pieces(pattern string, k int) []string {
+	runes := []rune(pattern)
+	if k < 0 {
+		k = 0
+	}
+	if k >= len(runes) {
+		return nil
+	}
+
+	pieces := make([]string, 0, k+1)
+	for i := 0; i <= k; i++ {
+		start := i * len(runes) / (k + 1)
+		end := (i + 1) * len(runes) / (k + 1)
+		pieces = append(pieces, string(runes[start:end]))
+	}
+	return pieces
+}
+
+// shiftOne guarda em dst o estado src deslocado um bit, com o bit 0 ligado:
+// (src << 1) | 1
+func shiftOne(dst []uint64, src []uint64) {
+	carry := uint64(1)
+	for w := range src {
+		dst[w] = src[w]<<1 | carry
+		carry = src[w] >> (WORD_SIZE - 1)
+	}
+}
+
+// Bitap retorna as ocorrencias do padrao no texto com ate k erros, sem
+// sobreposicao, da esquerda para a direita. Entre os fins proximos de uma
+// mesma ocorrencia é escolhido o de menos erros. Com k igual ou maior que o
+// tamanho do padrao qualquer trecho seria uma ocorrencia, entao k é limitado
+// ao tamanho do padrao menos 1
+func Bitap(pattern string, text string, k int) (matches []Match) {
+	p := utils.FoldRunes(pattern)
+	t := utils.FoldRunes(text)
+	if len(p) == 0 || k < 0 {
+		return nil
+	}
+	if k >= len(p) {
+		k = len(p) - 1
+	}
+	errors := endErrors(p, t, k)
+
+	// Escolha das ocorrencias: uma ocorrencia exata de "fire" tambem termina
+	// com 1 erro em "fir", entao o melhor fim é procurado nos k seguintes
+	end := 0
+	for j := 0; j < len(t); j++ {
+		if errors[j] < 0 {
+			continue
+		}
+		best := j
+		for x := j + 1; x <= j+k && x < len(t); x++ {
+			if errors[x] >= 0 && errors[x] < errors[best] {
+				best = x
+			}
+		}
+
+		start, e := alignStart(p, t, best, errors[best])
+		if start < end {
+			continue
+		}
+		matches = append(matches, Match{Start: start, End: best + 1, Errors: e})
+		end = best + 1
+		j = best
+	}
+
+	return matches
+}
+
+// endErrors executa o Bitap com ate k erros e retorna, para cada posicao do
+// texto, a menor quantidade de erros de uma ocorrencia do padrao que termina
+// nela, ou -1 se nao houver ocorrencia com ate k erros
+func endErrors(p []rune, t []rune, k int) []int {
+	m := len(p)
+
+	// Mascara das posicoes de cada caractere no padrao
+	words := (m + WORD_SIZE - 1) / WORD_SIZE
+	masks := make(map[rune][]uint64)
+	for i, r := range p {
+		if masks[r] == nil {
+			masks[r] = make([]uint64, words)
+		}
+		masks[r][i/WORD_SIZE] |= 1 << (i % WORD_SIZE)
+	}
+	empty := make([]uint64, words)
+	last, lastBit := (m-1)/WORD_SIZE, uint64(1)<<((m-1)%WORD_SIZE)
+
+	// Estados com d erros, que comecam com os d primeiros caracteres
+	// do padrao removidos
+	state := make([][]uint64, k+1)
+	next := make([][]uint64, k+1)
+	for d := range state {
+		state[d] = make([]uint64, words)
+		next[d] = make([]uint64, words)
+		for i := 0; i < d; i++ {
+			state[d][i/WORD_SIZE] |= 1 << (i % WORD_SIZE)
+		}
+	}
+	shifted := make([]uint64, words)
+	errors := make([]int, len(t))
+
+	for j, c := range t {
+		mask, ok := masks[c]
+		if !ok {
+			mask = empty
+		}
+
+		for d := 0; d <= k; d++ {
+			// Caractere casado
+			shiftOne(shifted, state[d])
+			for w := range shifted {
+				next[d][w] = shifted[w] & mask[w]
+			}
+			if d == 0 {
+				continue
+			}
+			// Insercao de um caractere no texto
+			for w := range shifted {
+				next[d][w] |= state[d-1][w]
+			}
+			// Substituicao
+			shiftOne(shifted, state[d-1])
+			for w := range shifted {
+				next[d][w] |= shifted[w]
+			}
+			// Remocao de um caractere do padrao
+			shiftOne(shifted, next[d-1])
+			for w := range shifted {
+				next[d][w] |= shifted[w]
+			}
+		}
+		state, next = next, state
+
+		errors[j] = -1
+		for d := 0; d <= k; d++ {
+			if state[d][last]&lastBit != 0 {
+				errors[j] = d
+				break
+			}
+		}
+	}
+
+	return errors
+}
+
+// alignStart encontra o inicio de uma ocorrencia que termina na posicao
+// 'last' do texto com 'k' erros, calculando a distancia de edicao entre o
+// padrao e cada trecho do texto que termina ali, com tamanho de ate m+k.
+// Entre os trechos de menor distancia é escolhido o de tamanho mais proximo
+// do padrao
+func alignStart(p []rune, t []rune, last int, k int) (start int, errors int) {
+	m := len(p)
+	size := m + k
+	if size > last+1 {
+		size = last + 1
+	}
+
+	// dist[i][l]: distancia entre os i ultimos caracteres do padrao e os
+	// l caracteres do texto que terminam em 'last'
+	dist := make([][]int, m+1)
+	for i := range dist {
+		dist[i] = make([]int, size+1)
+		dist[i][0] = i
+	}
+	for l := 0; l <= size; l++ {
+		dist[0][l] = l
+	}
+	for i := 1; i <= m; i++ {
+		for l := 1; l <= size; l++ {
+			cost := 1
+			if p[m-i] == t[last-l+1] {
+				cost = 0
+			}
+			dist[i][l] = min(dist[i-1][l]+1, dist[i][l-1]+1, dist[i-1][l-1]+cost)
+		}
+	}
+
+	best := 0
+	for l := 1; l <= size; l++ {
+		if dist[m][l] < dist[m][best] || (dist[m][l] == dist[m][best] && abs(l-m) < abs(best-m)) {
+			best = l
+		}
+	}
+
+	return last - best + 1, dist[m][best]
+}
+
+// SearchPokemon realiza uma busca aproximada por um termo (search) em um campo específico
+// (field) dos registros de Pokemon, tolerando ate MaxErrors(search) erros. Cada ocorrencia
+// soma 1/(1 + erros) ao score do documento, de forma que as ocorrencias exatas fiquem a
+// frente das ocorrencias com erros
+func SearchPokemon(search string, field string) (scoredDocuments []invertedIndex.ScoredDocument) {
+	// Abertura do controlador de leitura
+	controller, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	defer controller.Close()
+	scoredDocuments = make([]invertedIndex.ScoredDocument, 0)
+	k := MaxErrors(search)
+
+	// Ler enquanto nao acontecer FEOF
+	for err := controller.ReadNext(); err == nil; err = controller.ReadNext() {
+		// Se nao possuir lapide pesquisar
+		if !controller.RegistroAtual.IsDead() {
+			matches := Bitap(search, controller.RegistroAtual.Pokemon.GetField(field), k)
+			if len(matches) > 0 {
+				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: Score(matches)})
+			}
+		}
+	}
+
+	return scoredDocuments
+}
+
+// Score retorna o score das ocorrencias de um documento: a soma de
+// 1/(1 + erros) de cada ocorrencia
+func Score(matches []Match) (score float64) {
+	for _, m := range matches {
+		score += 1 / float64(1+m.Errors)
+	}
+	return score
+}
+
+// min retorna o menor dos valores fornecidos
+func min(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
+
+// abs retorna o valor absoluto de um inteiro
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
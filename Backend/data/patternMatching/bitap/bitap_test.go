@@ -0,0 +1,152 @@
+// Testes do Bitap com erros: ocorrencias aproximadas, padroes maiores que
+// uma palavra de 64 bits e os erros de cada fim comparados com a distancia de
+// edicao calculada por programacao dinamica
+package bitap
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+func TestBitap(t *testing.T) {
+	// Padroes de 70 e 130 runas ocupam duas e tres palavras
+	long := "A flame burns on the tip of its tail from birth. It is said to die if"
+	longer := strings.Repeat("ピカチュウ", 26)
+	typo := strings.Replace(long, "birth", "brith", 1)
+	typo = strings.Replace(typo, "flame", "flam", 1)
+
+	tests := []struct {
+		pattern  string
+		text     string
+		k        int
+		expected []Match
+	}{
+		{"seed on its back", "the sed on its bakc", 3, []Match{{4, 18, 2}}},
+		{"Pokémon", "a POKEMON and a pokémon", 1, []Match{{2, 9, 1}, {16, 23, 0}}},
+		{"fire", "fire fir fre", 1, []Match{{0, 4, 0}, {5, 8, 1}, {9, 12, 1}}},
+		{"fire", "water", 1, nil},
+		{"abc", "abc", 5, []Match{{0, 3, 0}}},
+		{"", "abc", 1, nil},
+		{long, "xx " + long + " xx", 0, []Match{{3, 3 + len(long), 0}}},
+		{long, "xx " + typo + " xx", 3, []Match{{3, 3 + len(typo), 3}}},
+		{long, typo, 2, nil},
+		{longer, "ピカ" + longer, 0, []Match{{2, 132, 0}}},
+		{longer, strings.Replace(longer, "ュ", "ユ", 2), 2, []Match{{0, 130, 2}}},
+	}
+
+	for _, test := range tests {
+		got := Bitap(test.pattern, test.text, test.k)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Bitap(%q, %q, %d) = %v, expected %v", test.pattern, test.text, test.k, got, test.expected)
+		}
+	}
+}
+
+// editEnds calcula, para cada posicao do texto, a menor distancia de edicao
+// entre o padrao e um trecho do texto que termina nela
+func editEnds(p []rune, t []rune) []int {
+	prev := make([]int, len(p)+1)
+	cur := make([]int, len(p)+1)
+	for i := range prev {
+		prev[i] = i
+	}
+
+	ends := make([]int, len(t))
+	for j := range t {
+		cur[0] = 0
+		for i := 1; i <= len(p); i++ {
+			cost := 1
+			if p[i-1] == t[j] {
+				cost = 0
+			}
+			cur[i] = min(prev[i]+1, cur[i-1]+1, prev[i-1]+cost)
+		}
+		ends[j] = cur[len(p)]
+		prev, cur = cur, prev
+	}
+	return ends
+}
+
+func TestEndErrors(t *testing.T) {
+	random := rand.New(rand.NewSource(46))
+	alphabet := []rune("abcé")
+	word := func(n int) []rune {
+		w := make([]rune, n)
+		for i := range w {
+			w[i] = alphabet[random.Intn(len(alphabet))]
+		}
+		return w
+	}
+
+	for _, m := range []int{1, 5, 63, 64, 65, 100, 129} {
+		for k := 0; k <= 3 && k < m; k++ {
+			p := word(m)
+			// Texto com copias do padrao alteradas, para que haja ocorrencias
+			t0 := append(word(20), p...)
+			t0 = append(t0, word(10)...)
+			t0 = append(t0, p...)
+			for i := 0; i < k; i++ {
+				t0[20+random.Intn(m)] = 'x'
+			}
+
+			got := endErrors(p, t0, k)
+			for j, d := range editEnds(p, t0) {
+				if d > k {
+					d = -1
+				}
+				if got[j] != d {
+					t.Fatalf("m = %d, k = %d: errors at %d = %d, expected %d", m, k, j, got[j], d)
+				}
+			}
+		}
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	tests := map[string]int{"mr": 0, "fire": 1, "água": 1, "charizard": 2, "seed on its back": 3}
+	for pattern, expected := range tests {
+		if got := MaxErrors(pattern); got != expected {
+			t.Errorf("MaxErrors(%q) = %d, expected %d", pattern, got, expected)
+		}
+	}
+}
+
+func TestPieces(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		k        int
+		expected []string
+	}{
+		{"charizard", 2, []string{"cha", "riz", "ard"}},
+		{"fire", 1, []string{"fi", "re"}},
+		{"ピカチュウ", 0, []string{"ピカチュウ"}},
+		{"ab", 2, nil},
+	}
+
+	for _, test := range tests {
+		if got := Pieces(test.pattern, test.k); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Pieces(%q, %d) = %q, expected %q", test.pattern, test.k, got, test.expected)
+		}
+	}
+
+	// Toda ocorrencia com ate k erros contem uma das partes
+	pattern, k := "A flame burns on the tip of its tail", 3
+	text := []rune("a flme brns on the tip of its tail")
+	matches := Bitap(pattern, string(text), k)
+	if len(matches) == 0 {
+		t.Fatalf("Bitap(%q, %q, %d) found no match", pattern, string(text), k)
+	}
+	for _, m := range matches {
+		found := false
+		for _, piece := range Pieces(pattern, k) {
+			found = found || strings.Contains(string(utils.FoldRunes(string(text[m.Start:m.End]))), string(utils.FoldRunes(piece)))
+		}
+		if !found {
+			t.Errorf("match %v of %q contains no piece", m, pattern)
+		}
+	}
+}
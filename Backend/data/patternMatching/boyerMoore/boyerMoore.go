@@ -0,0 +1,180 @@
+// Package boyerMoore implementa o algoritmo de busca de padrões Boyer-Moore
+//
+// O algoritmo compara o padrão com o texto da direita para a esquerda e, a
+// cada diferença encontrada, desloca o padrão pelo maior dos saltos dados por
+// duas heurísticas pré-processadas sobre o padrão:
+//
+//	Caractere ruim (bad character): alinha o caractere do texto que causou a
+//	diferença com a sua última ocorrência no padrão.
+//
+//	Sufixo bom (good suffix): alinha o trecho ja casado com a sua próxima
+//	ocorrência no padrão, ou com o maior prefixo do padrão que é sufixo dele.
+//
+// Por pular trechos do texto sem compará-los, o Boyer-Moore é sublinear no caso
+// médio, O(n/m), e fica mais rápido quanto maior o padrão, sendo indicado para
+// textos longos como as descrições dos pokemons.
+//
+// A busca é feita sobre as runas dos textos com as letras normalizadas (ver
+// utils.FoldRunes), e as posições retornadas sao indices de runas, como no KMP.
+//
+// Este pacote fornece uma função SearchPokemon feita especificamente para o
+// processamento do banco de dados de Pokemons do trabalho original
+//
+// Exemplo de uso:
+//
+//	indexes := boyerMoore.BoyerMoore("abc", "abcdeabcdabcabc")
+//	// indexes: [0 5 9 12]
+package boyerMoore
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// BoyerMoore retorna as posições de todas as ocorrências do padrão no texto,
+// ignorando a diferença entre letras maiúsculas e minúsculas
+func BoyerMoore(pattern string, text string) (indexes []int) {
+	x := utils.FoldRunes(pattern)
+	y := utils.FoldRunes(text)
+	m := len(x)
+	n := len(y)
+
+	if m == 0 || n < m {
+		return nil
+	}
+
+	badChar := preBadCharacter(x)
+	goodSuffix := preGoodSuffix(x)
+
+	for j := 0; j <= n-m; {
+		// Compara da direita para a esquerda
+		i := m - 1
+		for i >= 0 && x[i] == y[i+j] {
+			i--
+		}
+
+		if i < 0 {
+			indexes = append(indexes, j)
+			j += goodSuffix[0]
+		} else {
+			j += max(goodSuffix[i], badChar.shift(y[i+j], m)-m+1+i)
+		}
+	}
+
+	return indexes
+}
+
+// badCharacter guarda o salto do caractere ruim das runas do padrão. Como o
+// alfabeto de runas é grande, apenas as runas presentes no padrão sao guardadas
+type badCharacter map[rune]int
+
+// shift retorna o salto de uma runa, ou o tamanho do padrão se ela nao
+// aparecer nele
+func (badChar badCharacter) shift(c rune, m int) int {
+	if s, ok := badChar[c]; ok {
+		return s
+	}
+	return m
+}
+
+// preBadCharacter calcula, para cada runa do padrão, a distância entre a sua
+// última ocorrência (desconsiderando o último caractere) e o fim do padrão.
+// Runas que nao aparecem no padrão permitem saltar o padrão inteiro
+func preBadCharacter(x []rune) badCharacter {
+	m := len(x)
+	badChar := make(badCharacter, m)
+
+	for i := 0; i < m-1; i++ {
+		badChar[x[i]] = m - 1 - i
+	}
+
+	return badChar
+}
+
+// suffixes calcula, para cada posição i do padrão, o tamanho do maior
+// trecho terminado em i que também é sufixo do padrão
+func suffixes(x []rune) []int {
+	m := len(x)
+	suff := make([]int, m)
+	suff[m-1] = m
+
+	g := m - 1
+	f := 0
+	for i := m - 2; i >= 0; i-- {
+		if i > g && suff[i+m-1-f] < i-g {
+			suff[i] = suff[i+m-1-f]
+		} else {
+			if i < g {
+				g = i
+			}
+			f = i
+			for g >= 0 && x[g] == x[g+m-1-f] {
+				g--
+			}
+			suff[i] = f - g
+		}
+	}
+
+	return suff
+}
+
+// preGoodSuffix calcula o salto do sufixo bom para uma diferença em cada
+// posição do padrão. A posição 0 guarda também o salto após uma ocorrência
+func preGoodSuffix(x []rune) []int {
+	m := len(x)
+	suff := suffixes(x)
+	goodSuffix := make([]int, m)
+
+	// Sem outra ocorrência do sufixo, salta o padrão inteiro
+	for i := range goodSuffix {
+		goodSuffix[i] = m
+	}
+
+	// Prefixos do padrão que sao sufixos dele
+	j := 0
+	for i := m - 1; i >= 0; i-- {
+		if suff[i] == i+1 {
+			for ; j < m-1-i; j++ {
+				if goodSuffix[j] == m {
+					goodSuffix[j] = m - 1 - i
+				}
+			}
+		}
+	}
+
+	// Outras ocorrências do sufixo dentro do padrão
+	for i := 0; i <= m-2; i++ {
+		goodSuffix[m-1-suff[i]] = m - 1 - i
+	}
+
+	return goodSuffix
+}
+
+// SearchPokemon realiza uma busca por um termo específico (search) em um campo específico
+// (field) dos registros de Pokemon, retornando os documentos que contêm o Id do pokemon
+// que possui aquele termo e a frequencia de aparição.
+func SearchPokemon(search string, field string) (scoredDocuments []invertedIndex.ScoredDocument) {
+	controller, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	defer controller.Close()
+	scoredDocuments = make([]invertedIndex.ScoredDocument, 0)
+
+	for err := controller.ReadNext(); err == nil; err = controller.ReadNext() {
+		if !controller.RegistroAtual.IsDead() {
+			needle := BoyerMoore(search, controller.RegistroAtual.Pokemon.GetField(field))
+			if len(needle) > 0 {
+				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: float64(len(needle))})
+			}
+		}
+	}
+
+	return scoredDocuments
+}
+
+// max retorna o maior de dois inteiros
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,47 @@
+// Testes da busca do Boyer-Moore: os casos exercitam os saltos do sufixo bom,
+// que o Horspool nao possui, alem de ocorrências sobrepostas e posições em
+// runas normalizadas
+package boyerMoore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBoyerMoore(t *testing.T) {
+	long := strings.Repeat("ピカチュウ", 30)
+
+	tests := []struct {
+		pattern  string
+		text     string
+		expected []int
+	}{
+		// Sufixo bom repetido dentro do padrão
+		{"anpanman", "anpanman anpanman", []int{0, 9}},
+		{"abcxxxabc", "abcxxxabcxxxabc", []int{0, 6}},
+		{"cabab", "abababcababcabab", []int{6, 11}},
+		// Sufixo bom que é prefixo do padrão: salto pelo periodo apos cada ocorrência
+		{"abab", "abababab", []int{0, 2, 4}},
+		{"abcab", "abcabcabcab", []int{0, 3, 6}},
+		// Caractere ruim que nao aparece no padrão
+		{"abc", "xyzxyzabc", []int{6}},
+		{"ABC", "xabcxAbC", []int{1, 5}},
+		// Runas normalizadas, com o sufixo bom tambem em runas
+		{"ÉBÉ", "Flabébé", []int{4}},
+		{"ß", "ss SS ß ẞ", []int{6, 8}},
+		{"チュウ", "ライチュウとピカチュウ", []int{2, 8}},
+		{long[len("ピカチュウ"):], long, []int{0, 5}},
+		{"ピカチュウ", "ピカ", nil},
+		{"fogo", "agua", nil},
+		{"", "abc", nil},
+		{"abc", "", nil},
+	}
+
+	for _, test := range tests {
+		got := BoyerMoore(test.pattern, test.text)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("BoyerMoore(%q, %q) = %v, expected %v", test.pattern, test.text, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// O arquivo bktree do pacote fuzzy implementa uma BK-tree (Burkhard-Keller),
+// arvore que indexa palavras por uma metrica de distancia.
+//
+// Cada filho de um no é rotulado com a distancia entre a sua palavra e a do
+// no. Pela desigualdade triangular, ao procurar palavras a no maximo 'n' de
+// uma palavra p, apenas os filhos com rotulo entre d-n e d+n precisam ser
+// visitados, onde d é a distancia entre p e a palavra do no.
+//
+// Cada no guarda tambem a quantidade de documentos que contem a sua palavra.
+// Uma palavra sem documentos continua na arvore, ja que os rotulos dos seus
+// filhos dependem dela, mas deixa de ser retornada nas pesquisas ate a
+// proxima reconstrucao.
+//
+// A arvore de cada campo é gravada em um arquivo com os nos em pre-ordem:
+//
+//	[no]  rotulo (int32), palavra, documentos (int64), filhos (int32), [filhos]...
+//
+// As arvores lidas ficam em memoria, e as alteracoes sao aplicadas na arvore
+// em memoria e regravadas no arquivo, de forma que uma pesquisa nao precisa
+// ler o arquivo nem recalcular distancias.
+package fuzzy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Diretorio dos arquivos das arvores
+const BKTREE_DIR string = "bktree"
+
+// DistanceFunction calcula a distancia entre duas palavras
+type DistanceFunction func(a string, b string) int
+
+// BKTree é uma arvore de palavras organizada por distancia de edicao
+type BKTree struct {
+	root     *bkNode
+	distance DistanceFunction
+	size     int
+}
+
+// bkNode é uma palavra da arvore, a quantidade de documentos que a contem
+// e seus filhos, indexados pela distancia
+type bkNode struct {
+	term     string
+	count    int64
+	children map[int]*bkNode
+}
+
+// Interface para leitura da database
+type Reader interface {
+	ReadNextGeneric() (any, bool, int64, error)
+}
+
+// Interface para recuperacao do campo do objeto indexavel
+type IndexableObject interface {
+	GetField(fieldName string) string
+}
+
+// Arvores em memoria, por arquivo. Pesquisas compartilham o lock e
+// alteracoes o tomam exclusivamente
+var (
+	treesMutex sync.RWMutex
+	trees      = make(map[string]*BKTree)
+)
+
+// NewBKTree cria uma arvore vazia que utiliza a funcao de distancia fornecida
+func NewBKTree(distance DistanceFunction) *BKTree {
+	return &BKTree{distance: distance}
+}
+
+// Size retorna a quantidade de palavras da arvore com algum documento
+func (t *BKTree) Size() int {
+	return t.size
+}
+
+// Insert adiciona um documento a uma palavra da arvore, inserindo a palavra
+// caso ela ainda nao exista
+func (t *BKTree) Insert(term string) {
+	if t.root == nil {
+		t.root = &bkNode{term: term, children: make(map[int]*bkNode)}
+	}
+
+	node := t.root
+	for {
+		d := t.distance(term, node.term)
+		if d == 0 {
+			break
+		}
+
+		child, ok := node.children[d]
+		if !ok {
+			child = &bkNode{term: term, children: make(map[int]*bkNode)}
+			node.children[d] = child
+		}
+		node = child
+	}
+
+	if node.count == 0 {
+		t.size++
+	}
+	node.count++
+}
+
+// Remove retira um documento de uma palavra da arvore. A palavra continua
+// na arvore mesmo sem documentos, mas deixa de ser pesquisada
+func (t *BKTree) Remove(term string) {
+	node := t.root
+	for node != nil {
+		d := t.distance(term, node.term)
+		if d == 0 {
+			if node.count > 0 {
+				node.count--
+				if node.count == 0 {
+					t.size--
+				}
+			}
+			return
+		}
+		node = node.children[d]
+	}
+}
+
+// Search retorna as palavras da arvore a no maximo maxDistance da palavra
+// fornecida, ordenadas por distancia
+func (t *BKTree) Search(term string, maxDistance int) []Candidate {
+	candidates := make([]Candidate, 0)
+	if t.root == nil {
+		return candidates
+	}
+
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := t.distance(term, node.term)
+		if d <= maxDistance && node.count > 0 {
+			candidates = append(candidates, Candidate{Term: node.term, Distance: d})
+		}
+
+		for label, child := range node.children {
+			if label >= d-maxDistance && label <= d+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	sortCandidates(candidates)
+	return candidates
+}
+
+// ===================================== Arquivo ====================================== //
+
+// bktreeFile retorna o caminho do arquivo da arvore de um campo
+func bktreeFile(path string, field string) string {
+	return filepath.Join(path, BKTREE_DIR, field+".bin")
+}
+
+// write grava a arvore em um arquivo temporario e o renomeia por cima do atual
+func (t *BKTree) write(filePath string) error {
+	os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	tmpPath := filePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	var writeNode func(label int, node *bkNode)
+	writeNode = func(label int, node *bkNode) {
+		binary.Write(w, binary.LittleEndian, int32(label))
+		binary.Write(w, binary.LittleEndian, int32(len(node.term)))
+		binary.Write(w, binary.LittleEndian, []byte(node.term))
+		binary.Write(w, binary.LittleEndian, node.count)
+		binary.Write(w, binary.LittleEndian, int32(len(node.children)))
+
+		// Filhos em ordem de rotulo para que o arquivo seja deterministico
+		labels := make([]int, 0, len(node.children))
+		for l := range node.children {
+			labels = append(labels, l)
+		}
+		sort.Ints(labels)
+		for _, l := range labels {
+			writeNode(l, node.children[l])
+		}
+	}
+	if t.root != nil {
+		writeNode(0, t.root)
+	}
+
+	w.Flush()
+	file.Close()
+
+	return os.Rename(tmpPath, filePath)
+}
+
+// readBKTree lê a arvore gravada por write, usando a distancia de Damerau
+func readBKTree(filePath string) (*BKTree, error) {
+	buffer, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	t := NewBKTree(Damerau)
+	ptr := 0
+	var readNode func() (int, *bkNode)
+	readNode = func() (int, *bkNode) {
+		var label, children int32
+		node := &bkNode{}
+		label, ptr = utils.BytesToInt32(buffer, ptr)
+		node.term, ptr = utils.BytesToString(buffer, ptr)
+		node.count, ptr = utils.BytesToInt64(buffer, ptr)
+		children, ptr = utils.BytesToInt32(buffer, ptr)
+
+		node.children = make(map[int]*bkNode, children)
+		for i := int32(0); i < children; i++ {
+			l, child := readNode()
+			node.children[l] = child
+		}
+		if node.count > 0 {
+			t.size++
+		}
+		return int(label), node
+	}
+	if len(buffer) > 0 {
+		_, t.root = readNode()
+	}
+
+	return t, nil
+}
+
+// load retorna a arvore de um campo, lendo o arquivo apenas na primeira vez.
+// Deve ser chamada com treesMutex travado
+func load(path string, field string) (*BKTree, error) {
+	filePath := bktreeFile(path, field)
+	if t, ok := trees[filePath]; ok {
+		return t, nil
+	}
+
+	t, err := readBKTree(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("bk-tree for '%s' not found", field)
+	}
+	trees[filePath] = t
+	return t, nil
+}
+
+// ======================================= Crud ======================================== //
+
+// StartBKTreeFile cria a arvore de um campo com os termos que o indice
+// invertido do campo grava para cada objeto (ver invertedIndex.IndexedTerms),
+// que precisa ter sido criado antes.
+//
+// A interface retornada por ReadNextGeneric deve possuir:
+// GetField(fieldName string) string.
+func StartBKTreeFile(controler Reader, path string, field string) error {
+	terms, err := invertedIndex.IndexedTerms(path, field)
+	if err != nil {
+		return err
+	}
+	t := NewBKTree(Damerau)
+
+	for {
+		objInterface, isDead, _, err := controler.ReadNextGeneric()
+		if err != nil {
+			break
+		}
+
+		obj, ok := objInterface.(IndexableObject)
+		if !ok {
+			return fmt.Errorf("failed to convert object to IndexableObject\n%+v", objInterface)
+		}
+
+		if !isDead {
+			for _, term := range terms(obj.GetField(field)) {
+				t.Insert(term)
+			}
+		}
+	}
+
+	treesMutex.Lock()
+	defer treesMutex.Unlock()
+	filePath := bktreeFile(path, field)
+	trees[filePath] = t
+	return t.write(filePath)
+}
+
+// modify aplica a alteracao na arvore de cada campo e a regrava, passando
+// os termos do objeto antigo e do novo (nil quando nao existirem)
+func modify(path string, fields []string, old IndexableObject, new IndexableObject) error {
+	treesMutex.Lock()
+	defer treesMutex.Unlock()
+
+	for _, field := range fields {
+		terms, err := invertedIndex.IndexedTerms(path, field)
+		if err != nil {
+			return err
+		}
+		t, err := load(path, field)
+		if err != nil {
+			return err
+		}
+
+		if old != nil {
+			for _, term := range terms(old.GetField(field)) {
+				t.Remove(term)
+			}
+		}
+		if new != nil {
+			for _, term := range terms(new.GetField(field)) {
+				t.Insert(term)
+			}
+		}
+		if err := t.write(bktreeFile(path, field)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BKTreeCreate adiciona os termos de um objeto as arvores dos campos fornecidos
+func BKTreeCreate(obj IndexableObject, path string, fields ...string) error {
+	return modify(path, fields, nil, obj)
+}
+
+// BKTreeUpdate troca, nas arvores dos campos fornecidos, os termos antigos
+// de um objeto pelos novos
+func BKTreeUpdate(old IndexableObject, new IndexableObject, path string, fields ...string) error {
+	return modify(path, fields, old, new)
+}
+
+// BKTreeDelete remove os termos de um objeto das arvores dos campos fornecidos
+func BKTreeDelete(obj IndexableObject, path string, fields ...string) error {
+	return modify(path, fields, obj, nil)
+}
+
+// searchTree pesquisa as palavras proximas de 'word' na arvore de um campo
+func searchTree(path string, field string, word string, maxDistance int) ([]Candidate, error) {
+	treesMutex.Lock()
+	t, err := load(path, field)
+	treesMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	treesMutex.RLock()
+	defer treesMutex.RUnlock()
+	return t.Search(word, maxDistance), nil
+}
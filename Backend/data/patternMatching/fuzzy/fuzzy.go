@@ -0,0 +1,195 @@
+// Package fuzzy implementa a pesquisa aproximada por distancia de edicao,
+// tolerando erros de digitacao como "Pikachoo" ou "Charizrd".
+//
+// A distancia entre duas palavras é a quantidade minima de operacoes de edicao
+// (insercao, remocao ou substituicao de um caractere) necessarias para
+// transformar uma na outra (Levenshtein). A distancia de Damerau-Levenshtein
+// considera tambem a troca de dois caracteres adjacentes ("pikahcu").
+//
+// Os termos do dicionario de um campo do indice invertido sao organizados em
+// uma BK-tree (ver bktree.go), que encontra os termos proximos da palavra
+// pesquisada sem calcular a distancia para todo o dicionario. A arvore é
+// criada junto com os demais indices e mantida nas inclusoes, alteracoes e
+// remocoes (BKTreeCreate, BKTreeUpdate e BKTreeDelete).
+//
+// Este pacote fornece uma função SearchPokemon feita especificamente para o
+// processamento do banco de dados de Pokemons do trabalho original
+package fuzzy
+
+import (
+	"sort"
+
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+)
+
+// Candidate é um termo do dicionario e sua distancia ate a palavra pesquisada
+type Candidate struct {
+	Term     string `json:"term"`
+	Distance int    `json:"distance"`
+}
+
+// Levenshtein calcula a distancia de edicao entre duas palavras
+// com programacao dinamica, mantendo apenas duas linhas da matriz
+func Levenshtein(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(t)]
+}
+
+// Damerau calcula a distancia de Damerau-Levenshtein entre duas palavras,
+// onde a troca de dois caracteres adjacentes conta como uma unica operacao.
+//
+// É utilizada a versao completa do algoritmo (Lowrance-Wagner), que ao
+// contrario da versao restrita respeita a desigualdade triangular, condicao
+// necessaria para a BK-tree
+func Damerau(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	maxDist := len(s) + len(t)
+
+	// d[i+1][j+1] é a distancia entre s[:i] e t[:j]
+	d := make([][]int, len(s)+2)
+	for i := range d {
+		d[i] = make([]int, len(t)+2)
+	}
+	d[0][0] = maxDist
+	for i := 0; i <= len(s); i++ {
+		d[i+1][0] = maxDist
+		d[i+1][1] = i
+	}
+	for j := 0; j <= len(t); j++ {
+		d[0][j+1] = maxDist
+		d[1][j+1] = j
+	}
+
+	// Ultima linha em que cada caractere apareceu em s
+	lastRow := make(map[rune]int)
+	for i := 1; i <= len(s); i++ {
+		lastCol := 0
+		for j := 1; j <= len(t); j++ {
+			k := lastRow[t[j-1]]
+			l := lastCol
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+				lastCol = j
+			}
+			d[i+1][j+1] = min(
+				d[i][j]+cost,              // substituicao
+				d[i+1][j]+1,               // insercao
+				d[i][j+1]+1,               // remocao
+				d[k][l]+(i-k-1)+1+(j-l-1), // transposicao
+			)
+		}
+		lastRow[s[i-1]] = i
+	}
+
+	return d[len(s)+1][len(t)+1]
+}
+
+// MaxDistance retorna a distancia tolerada para uma palavra: 0 para
+// palavras de ate 2 caracteres, 1 ate 5 caracteres e 2 para as demais
+func MaxDistance(word string) int {
+	switch n := len([]rune(word)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Search procura, no dicionario do indice invertido de um campo, os termos
+// proximos de cada palavra do texto. Se maxDistance for negativo a distancia
+// tolerada de cada palavra é dada por MaxDistance.
+//
+// Os candidatos sao retornados em ordem de distancia e depois alfabetica
+func Search(path string, field string, text string, maxDistance int) ([]Candidate, error) {
+	words, err := invertedIndex.Analyze(path, field, text)
+	if err != nil {
+		return nil, err
+	}
+
+	distances := make(map[string]int)
+	for _, word := range words {
+		tolerance := maxDistance
+		if tolerance < 0 {
+			tolerance = MaxDistance(word)
+		}
+		found, err := searchTree(path, field, word, tolerance)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range found {
+			if d, ok := distances[c.Term]; !ok || c.Distance < d {
+				distances[c.Term] = c.Distance
+			}
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(distances))
+	for term, distance := range distances {
+		candidates = append(candidates, Candidate{Term: term, Distance: distance})
+	}
+	sortCandidates(candidates)
+
+	return candidates, nil
+}
+
+// SearchPokemon realiza uma pesquisa aproximada em um campo do indice
+// invertido. Cada termo candidato é pesquisado no indice e o score de seus
+// documentos é dividido por (1 + distancia), de forma que os termos exatos
+// fiquem a frente dos termos com erros
+func SearchPokemon(search string, field string) (scoredDocuments []invertedIndex.ScoredDocument) {
+	candidates, err := Search(binManager.FILES_PATH, field, search, -1)
+	if err != nil {
+		return make([]invertedIndex.ScoredDocument, 0)
+	}
+
+	lists := make([][]invertedIndex.ScoredDocument, 0, len(candidates))
+	for _, c := range candidates {
+		docs := invertedIndex.Read(binManager.FILES_PATH, field, c.Term)
+		lists = append(lists, invertedIndex.Boost(docs, 1/float64(1+c.Distance)))
+	}
+
+	return invertedIndex.Merge(lists...)
+}
+
+// sortCandidates ordena os candidatos por distancia e depois alfabeticamente
+func sortCandidates(candidates []Candidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		return candidates[i].Term < candidates[j].Term
+	})
+}
+
+// min retorna o menor dos valores fornecidos
+func min(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+// Testes das distancias de edicao e da BK-tree: a pesquisa na arvore deve
+// encontrar os mesmos termos que uma comparacao com todo o dicionario,
+// inclusive apos remocoes e depois de gravada e lida do arquivo
+package fuzzy
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDistances(t *testing.T) {
+	tests := []struct {
+		a, b        string
+		levenshtein int
+		damerau     int
+	}{
+		{"", "", 0, 0},
+		{"pikachu", "", 7, 7},
+		{"pikachu", "pikachu", 0, 0},
+		{"pikachu", "pikachoo", 2, 2},
+		{"charizard", "charizrd", 1, 1},
+		{"pikachu", "pikahcu", 2, 1},
+		{"ca", "abc", 3, 2},
+		{"flabébé", "flabebe", 2, 2},
+		{"ピカチュウ", "ピカチュー", 1, 1},
+	}
+
+	for _, test := range tests {
+		if got := Levenshtein(test.a, test.b); got != test.levenshtein {
+			t.Errorf("Levenshtein(%q, %q) = %d, expected %d", test.a, test.b, got, test.levenshtein)
+		}
+		if got := Damerau(test.a, test.b); got != test.damerau {
+			t.Errorf("Damerau(%q, %q) = %d, expected %d", test.a, test.b, got, test.damerau)
+		}
+	}
+}
+
+func TestMaxDistance(t *testing.T) {
+	tests := map[string]int{"mr": 0, "pika": 1, "ピカチュウ": 1, "pikachu": 2}
+	for word, expected := range tests {
+		if got := MaxDistance(word); got != expected {
+			t.Errorf("MaxDistance(%q) = %d, expected %d", word, got, expected)
+		}
+	}
+}
+
+// linearSearch compara a palavra com todos os termos, como referencia
+func linearSearch(terms map[string]int, word string, maxDistance int) []Candidate {
+	candidates := make([]Candidate, 0)
+	for term, count := range terms {
+		if d := Damerau(word, term); count > 0 && d <= maxDistance {
+			candidates = append(candidates, Candidate{Term: term, Distance: d})
+		}
+	}
+	sortCandidates(candidates)
+	return candidates
+}
+
+func TestBKTree(t *testing.T) {
+	words := []string{
+		"pikachu", "raichu", "pichu", "charizard", "charmander", "charmeleon",
+		"bulbasaur", "ivysaur", "venusaur", "squirtle", "wartortle", "blastoise",
+		"mew", "mewtwo", "eevee", "jolteon", "flareon", "vaporeon", "pikachu",
+	}
+	queries := []string{"pikachoo", "charizrd", "mewto", "eeve", "zubat", "saur", "chu"}
+
+	tree := NewBKTree(Damerau)
+	terms := make(map[string]int)
+	for _, word := range words {
+		tree.Insert(word)
+		terms[word]++
+	}
+
+	check := func(tree *BKTree, step string) {
+		t.Helper()
+		live := 0
+		for _, count := range terms {
+			if count > 0 {
+				live++
+			}
+		}
+		if tree.Size() != live {
+			t.Errorf("%s: Size() = %d, expected %d", step, tree.Size(), live)
+		}
+		for _, q := range queries {
+			for distance := 0; distance <= 3; distance++ {
+				got := tree.Search(q, distance)
+				expected := linearSearch(terms, q, distance)
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("%s: Search(%q, %d) = %v, expected %v", step, q, distance, got, expected)
+				}
+			}
+		}
+	}
+	check(tree, "insert")
+
+	// "pikachu" tem dois documentos e continua apos uma remocao
+	for _, word := range []string{"pikachu", "charizard", "mew", "eevee", "missingno"} {
+		tree.Remove(word)
+		if terms[word] > 0 {
+			terms[word]--
+		}
+	}
+	check(tree, "remove")
+
+	tree.Insert("eevee")
+	terms["eevee"]++
+	check(tree, "reinsert")
+
+	filePath := filepath.Join(t.TempDir(), "nome.bin")
+	if err := tree.write(filePath); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readBKTree(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(read, "read")
+}
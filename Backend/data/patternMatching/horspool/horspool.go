@@ -0,0 +1,104 @@
+// Package horspool implementa o algoritmo de busca de padrões Boyer-Moore-Horspool
+//
+// O Horspool é uma simplificação do Boyer-Moore que usa apenas a heurística do
+// caractere ruim. O padrão é comparado com o texto e, ao final de cada
+// tentativa, é deslocado de acordo com o caractere do texto alinhado ao último
+// caractere do padrão, ate a sua última ocorrência no padrão.
+//
+// Sem a tabela do sufixo bom o pré-processamento é mais simples e o laço
+// principal mais leve, o que costuma torná-lo mais rápido que o Boyer-Moore
+// completo em alfabetos grandes como o de textos em linguagem natural, apesar
+// do pior caso O(nm).
+//
+// A busca é feita sobre as runas dos textos com as letras normalizadas (ver
+// utils.FoldRunes), e as posições retornadas sao indices de runas, como no KMP.
+//
+// Este pacote fornece uma função SearchPokemon feita especificamente para o
+// processamento do banco de dados de Pokemons do trabalho original
+//
+// Exemplo de uso:
+//
+//	indexes := horspool.Horspool("abc", "abcdeabcdabcabc")
+//	// indexes: [0 5 9 12]
+package horspool
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Horspool retorna as posições de todas as ocorrências do padrão no texto,
+// ignorando a diferença entre letras maiúsculas e minúsculas
+func Horspool(pattern string, text string) (indexes []int) {
+	x := utils.FoldRunes(pattern)
+	y := utils.FoldRunes(text)
+	m := len(x)
+	n := len(y)
+
+	if m == 0 || n < m {
+		return nil
+	}
+
+	shift := preShift(x)
+
+	for j := 0; j <= n-m; j += shift.of(y[j+m-1], m) {
+		// Compara da direita para a esquerda
+		i := m - 1
+		for i >= 0 && x[i] == y[i+j] {
+			i--
+		}
+		if i < 0 {
+			indexes = append(indexes, j)
+		}
+	}
+
+	return indexes
+}
+
+// shiftTable guarda o salto das runas do padrão. Como o alfabeto de runas
+// é grande, apenas as runas presentes no padrão sao guardadas
+type shiftTable map[rune]int
+
+// of retorna o salto de uma runa, ou o tamanho do padrão se ela nao
+// aparecer nele
+func (shift shiftTable) of(c rune, m int) int {
+	if s, ok := shift[c]; ok {
+		return s
+	}
+	return m
+}
+
+// preShift calcula o salto para cada runa alinhada ao último caractere do
+// padrão: a distância da sua última ocorrência no padrão (desconsiderando o
+// último caractere) ate o fim, ou o tamanho do padrão se nao aparecer nele
+func preShift(x []rune) shiftTable {
+	m := len(x)
+	shift := make(shiftTable, m)
+
+	for i := 0; i < m-1; i++ {
+		shift[x[i]] = m - 1 - i
+	}
+
+	return shift
+}
+
+// SearchPokemon realiza uma busca por um termo específico (search) em um campo específico
+// (field) dos registros de Pokemon, retornando os documentos que contêm o Id do pokemon
+// que possui aquele termo e a frequencia de aparição.
+func SearchPokemon(search string, field string) (scoredDocuments []invertedIndex.ScoredDocument) {
+	controller, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	defer controller.Close()
+	scoredDocuments = make([]invertedIndex.ScoredDocument, 0)
+
+	for err := controller.ReadNext(); err == nil; err = controller.ReadNext() {
+		if !controller.RegistroAtual.IsDead() {
+			needle := Horspool(search, controller.RegistroAtual.Pokemon.GetField(field))
+			if len(needle) > 0 {
+				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: float64(len(needle))})
+			}
+		}
+	}
+
+	return scoredDocuments
+}
@@ -0,0 +1,42 @@
+// Testes da busca do Horspool: os casos exercitam o salto dado apenas pelo
+// caractere do texto alinhado ao fim do padrão, em runas normalizadas
+package horspool
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHorspool(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		text     string
+		expected []int
+	}{
+		// Ultimo caractere que tambem aparece no meio do padrão
+		{"abcb", "abcbcbabcb", []int{0, 6}},
+		{"abc", "abcdeabcdabcabc", []int{0, 5, 9, 12}},
+		// Caractere fora do padrão: salto do tamanho do padrão inteiro
+		{"fire", "xxxxxxxxfire", []int{8}},
+		{"fire", "firxfirefir", []int{4}},
+		// Salto de uma posição quando o ultimo caractere se repete
+		{"aa", "aaaa", []int{0, 1, 2}},
+		{"aab", "aaaab", []int{2}},
+		{"Fire", "FIRE fire", []int{0, 5}},
+		// Runas normalizadas
+		{"ピカ", "ピカチュウ ピカピカ", []int{0, 6, 8}},
+		{"pokémon", "POKéMON", []int{0}},
+		{"straße", "STRASSE straße STRAẞE", []int{8, 15}},
+		{"água", "agua ÁGUA", []int{5}},
+		// Padrão maior que o texto
+		{"fire", "fir", nil},
+		{"", "", nil},
+	}
+
+	for _, test := range tests {
+		got := Horspool(test.pattern, test.text)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Horspool(%q, %q) = %v, expected %v", test.pattern, test.text, got, test.expected)
+		}
+	}
+}
@@ -12,23 +12,21 @@
 //
 //	SearchNext (busca a ultima ocorrencia)
 //	SearchString (busca todas as ocorrencias)
+//
+// A busca é feita sobre as runas dos textos, e nao sobre seus bytes, de forma
+// que padrões de qualquer tamanho e textos com acentos ou em japones sao
+// tratados corretamente, e as posições retornadas sao indices de runas
 package kmp
 
 import (
-	"strings"
-
 	"github.com/Bernardo46-2/AEDS-III/data/binManager"
 	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
-)
-
-const (
-	// PatternSize é o tamanho máximo do padrão a ser procurado. É usado para definir o tamanho do array no pré-processamento KMP.
-	PatternSize int = 100
+	"github.com/Bernardo46-2/AEDS-III/utils"
 )
 
 // SearchNext busca a última ocorrência da string de busca (needle) na string de destino (haystack).
 func SearchNext(haystack string, needle string) int {
-	retSlice := kmp(haystack, needle)
+	retSlice := kmp([]rune(haystack), []rune(needle))
 	if len(retSlice) > 0 {
 		return retSlice[len(retSlice)-1]
 	}
@@ -37,9 +35,10 @@ func SearchNext(haystack string, needle string) int {
 }
 
 // SearchString realiza a busca da string de busca (needle) na string de destino (haystack),
-// ignorando a diferença entre letras maiúsculas e minúsculas.
+// ignorando a diferença entre letras maiúsculas e minúsculas, inclusive fora do ASCII
+// (ver utils.FoldRunes).
 func SearchString(haystack string, needle string) []int {
-	return kmp(strings.ToLower(haystack), strings.ToLower(needle))
+	return kmp(utils.FoldRunes(haystack), utils.FoldRunes(needle))
 }
 
 // kmp realiza a busca do algoritmo Knuth-Morris-Pratt (KMP).
@@ -62,16 +61,11 @@ func SearchString(haystack string, needle string) []int {
 //	needle: "ABABCABAB"
 //
 //	Saída: [10, 24]
-func kmp(haystack string, needle string) []int {
-	// Criação da maquina de estados com os prefixos
-	next := preKMP(needle)
-	i := 0
-	j := 0
+func kmp(haystack []rune, needle []rune) []int {
 	m := len(needle)
 	n := len(haystack)
-
-	x := []byte(needle)
-	y := []byte(haystack)
+	x := needle
+	y := haystack
 	var ret []int
 
 	// se algum dos valores sao nulos
@@ -84,6 +78,11 @@ func kmp(haystack string, needle string) []int {
 		return ret
 	}
 
+	// Criação da maquina de estados com os prefixos
+	next := preKMP(x)
+	i := 0
+	j := 0
+
 	// Percorre os caracteres na string haystack
 	for j < n {
 		// Se o caractere atual na haystack não for igual ao da needle,
@@ -105,10 +104,14 @@ func kmp(haystack string, needle string) []int {
 	return ret
 }
 
-// preKMP realiza o pré-processamento da string de busca e retorna um array que contém
+// preKMP realiza o pré-processamento da string de busca e retorna uma tabela que contém
 // a maior borda própria de cada prefixo da string de busca. Esta tabela será usada
 // pelo algoritmo KMP para pular as comparações de caracteres que já foram comparados.
 //
+// A tabela possui m+1 posições, sendo m o tamanho da string de busca: a ultima
+// posição guarda a borda da string inteira, usada para continuar a busca apos
+// uma ocorrência sem perder as ocorrências sobrepostas.
+//
 // A função inicializa i e j com 0 e -1 respectivamente e define o primeiro valor de
 // kmpNext como -1. A variável i é o índice para percorrer os caracteres na string de busca,
 // enquanto j mantém a maior borda própria do prefixo atual.
@@ -119,10 +122,10 @@ func kmp(haystack string, needle string) []int {
 // Se o caractere for igual, incrementa i e j, e se o próximo caractere também for igual,
 // define o valor de kmpNext na posição i como o valor de kmpNext na posição j. Caso contrário,
 // define o valor de kmpNext na posição i como j.
-func preKMP(x string) [PatternSize]int {
+func preKMP(x []rune) []int {
 	var i, j int
-	length := len(x) - 1
-	var kmpNext [PatternSize]int
+	length := len(x)
+	kmpNext := make([]int, length+1)
 	i = 0
 	j = -1
 	kmpNext[0] = -1 // A borda própria mais longa de uma string vazia é -1
@@ -139,7 +142,7 @@ func preKMP(x string) [PatternSize]int {
 
 		// Se o próximo caractere também for igual, define o valor de kmpNext na posição i como o valor de kmpNext na posição j
 		// Caso contrário, define o valor de kmpNext na posição i como j
-		if x[i] == x[j] {
+		if i < length && x[i] == x[j] {
 			kmpNext[i] = kmpNext[j]
 		} else {
 			kmpNext[i] = j
@@ -179,7 +182,7 @@ func SearchPokemon(search string, field string) (scoredDocuments []invertedIndex
 		if !controller.RegistroAtual.IsDead() {
 			needle := SearchString(controller.RegistroAtual.Pokemon.GetField(field), search)
 			if len(needle) > 0 {
-				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: len(needle)})
+				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: float64(len(needle))})
 			}
 		}
 	}
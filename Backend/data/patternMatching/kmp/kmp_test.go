@@ -0,0 +1,47 @@
+// Testes da busca do KMP sobre runas: nomes japoneses, acentos,
+// padrões longos e ocorrências sobrepostas
+package kmp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchString(t *testing.T) {
+	long := strings.Repeat("ピカチュウ", 30)
+
+	tests := []struct {
+		haystack string
+		needle   string
+		expected []int
+	}{
+		{"ピカチュウ", "ピカ", []int{0}},
+		{"ピカチュウ", "チュウ", []int{2}},
+		{"フシギダネ", "ダネ", []int{3}},
+		{"フシギダネ", "ピカ", nil},
+		{"ライチュウとピカチュウ", "チュウ", []int{2, 8}},
+		{"Flabébé", "ÉBÉ", []int{4}},
+		{"POKéMON", "pokémon", []int{0}},
+		{"aaa", "aa", []int{0, 1}},
+		{long, long[len("ピカチュウ"):], []int{0, 5}},
+		{"ピカ", "ピカチュウ", nil},
+		{"", "ピカ", nil},
+	}
+
+	for _, test := range tests {
+		got := SearchString(test.haystack, test.needle)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("SearchString(%q, %q) = %v, expected %v", test.haystack, test.needle, got, test.expected)
+		}
+	}
+}
+
+func TestSearchNext(t *testing.T) {
+	if got := SearchNext("ピカチュウピカチュウ", "ピカ"); got != 5 {
+		t.Errorf("SearchNext = %d, expected 5", got)
+	}
+	if got := SearchNext("フシギダネ", "ピカ"); got != -1 {
+		t.Errorf("SearchNext = %d, expected -1", got)
+	}
+}
@@ -19,18 +19,18 @@
 //	// indexes: [0 5 9 12]
 //
 // A função RabinKarp retorna um slice vazio se o padrão não for encontrado no texto. Este pacote
-// converte tanto o texto quanto o padrão para minúsculas antes de realizar a comparação, portanto,
-// a busca é insensível a maiúsculas e minúsculas.
+// normaliza tanto o texto quanto o padrão antes de realizar a comparação (ver utils.FoldRunes),
+// portanto, a busca é insensível a maiúsculas e minúsculas, inclusive em letras acentuadas.
 //
-// Este pacote não oferece suporte à busca de padrões que contenham caracteres especiais.
+// O hash e as comparações sao feitos sobre as runas dos textos, e nao sobre seus bytes, de
+// forma que textos com acentos ou em japones sao tratados corretamente e os índices retornados
+// sao índices de runas.
 package rabinKarp
 
 import (
-	"math"
-	"strings"
-
 	"github.com/Bernardo46-2/AEDS-III/data/binManager"
 	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/utils"
 )
 
 const (
@@ -42,9 +42,9 @@ const (
 	Q int = 997
 )
 
-// hash recebe uma string e calcula um valor que será
+// hash recebe uma sequencia de runas e calcula um valor que será
 // usado durante o casamento de padrões
-func hash(str string) (h int) {
+func hash(str []rune) (h int) {
 	for _, c := range str {
 		h = (h*D + int(c)) % Q
 	}
@@ -70,21 +70,25 @@ func hash(str string) (h int) {
 // coincidência de hash. Nestes casos de colisao os caracteres são testados manualmente
 // um a um.
 func RabinKarp(pattern string, text string) (indexes []int) {
-	pattern = strings.ToLower(pattern)
-	text = strings.ToLower(text)
+	p := utils.FoldRunes(pattern)
+	t := utils.FoldRunes(text)
 
-	pl := len(pattern) // Tamanho do padrão
-	tl := len(text)    // Tamanho do texto
+	pl := len(p) // Tamanho do padrão
+	tl := len(t) // Tamanho do texto
 
 	if tl < pl || tl == 0 || pl == 0 {
 		return nil
 	}
 
-	ph := hash(pattern)   // Hash do padrão
-	th := hash(text[:pl]) // Hash do primeiro slice do texto
+	ph := hash(p)      // Hash do padrão
+	th := hash(t[:pl]) // Hash do primeiro slice do texto
 
-	// Valor de hash usado para reajustar a hash do slice a cada iteração
-	h := int(math.Pow(float64(D), float64(pl)-1)) % Q
+	// Valor de hash usado para reajustar a hash do slice a cada iteração,
+	// D^(pl-1) mod Q, calculado sem estourar o tamanho do inteiro
+	h := 1
+	for i := 1; i < pl; i++ {
+		h = (h * D) % Q
+	}
 
 	for i := 0; i <= tl-pl; i++ {
 		// Se hash do texto e do slice são iguais, comparar
@@ -95,7 +99,7 @@ func RabinKarp(pattern string, text string) (indexes []int) {
 			// Iterando pelo slice testando se o padrão e slice
 			// são iguais
 			for j := 0; j < pl && contains; j++ {
-				contains = text[i+j] == pattern[j]
+				contains = t[i+j] == p[j]
 			}
 
 			// Se `contains` continuar como true até esse ponto,
@@ -108,7 +112,7 @@ func RabinKarp(pattern string, text string) (indexes []int) {
 		// Testando se não é a ultima iteração
 		if i != tl-pl {
 			// Recalculando Hash para próxima posição no texto
-			th = (D*(th-int(text[i])*h) + int(text[i+pl])) % Q
+			th = (D*(th-int(t[i])*h%Q) + int(t[i+pl])) % Q
 
 			// Tratando caso o valor da hash ficar negativo
 			if th < 0 {
@@ -148,7 +152,7 @@ func SearchPokemon(search string, field string) (scoredDocuments []invertedIndex
 		if !controller.RegistroAtual.IsDead() {
 			needle := RabinKarp(search, controller.RegistroAtual.Pokemon.GetField(field))
 			if len(needle) > 0 {
-				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: len(needle)})
+				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: float64(len(needle))})
 			}
 		}
 	}
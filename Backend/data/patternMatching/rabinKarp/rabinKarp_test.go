@@ -0,0 +1,38 @@
+// Testes da busca do Rabin-Karp sobre runas: nomes japoneses, acentos,
+// padrões longos e ocorrências sobrepostas
+package rabinKarp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRabinKarp(t *testing.T) {
+	long := strings.Repeat("ピカチュウ", 30)
+
+	tests := []struct {
+		haystack string
+		needle   string
+		expected []int
+	}{
+		{"ピカチュウ", "ピカ", []int{0}},
+		{"ピカチュウ", "チュウ", []int{2}},
+		{"フシギダネ", "ダネ", []int{3}},
+		{"フシギダネ", "ピカ", nil},
+		{"ライチュウとピカチュウ", "チュウ", []int{2, 8}},
+		{"Flabébé", "ÉBÉ", []int{4}},
+		{"POKéMON", "pokémon", []int{0}},
+		{"aaa", "aa", []int{0, 1}},
+		{long, long[len("ピカチュウ"):], []int{0, 5}},
+		{"ピカ", "ピカチュウ", nil},
+		{"", "ピカ", nil},
+	}
+
+	for _, test := range tests {
+		got := RabinKarp(test.needle, test.haystack)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("RabinKarp(%q, %q) = %v, expected %v", test.needle, test.haystack, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package regex
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// nodeKind é o tipo de um no da arvore sintatica da expressao
+type nodeKind uint8
+
+const (
+	nodeLiteral   nodeKind = iota // Um caractere
+	nodeAny                       // '.'
+	nodeClass                     // [...], \d, \w, \s
+	nodeBegin                     // '^'
+	nodeEnd                       // '$'
+	nodeEmpty                     // Expressao vazia, como em "(|a)"
+	nodeConcat                    // Filhos em sequencia
+	nodeAlternate                 // Filhos separados por '|'
+	nodeGroup                     // (...), capturando quando index > 0
+	nodeStar                      // '*'
+	nodePlus                      // '+'
+	nodeQuest                     // '?'
+)
+
+// node é um no da arvore sintatica da expressao
+type node struct {
+	kind     nodeKind
+	r        rune       // Caractere de nodeLiteral
+	class    *charClass // Classe de nodeClass
+	children []*node
+	index    int  // Indice do grupo de captura, 0 nos grupos sem captura
+	lazy     bool // Repeticao nao gulosa ("*?", "+?", "??")
+}
+
+// runeRange é um intervalo [lo, hi] de caracteres
+type runeRange struct {
+	lo rune
+	hi rune
+}
+
+// charClass é um conjunto de caracteres, possivelmente negado
+type charClass struct {
+	ranges  []runeRange
+	negated bool
+}
+
+// Classes das sequencias de escape \d, \w e \s
+var (
+	digitRanges = []runeRange{{'0', '9'}}
+	wordRanges  = []runeRange{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}
+	spaceRanges = []runeRange{{'\t', '\r'}, {' ', ' '}}
+)
+
+// matches retorna se um caractere pertence a classe, ignorando a diferenca
+// entre letras maiusculas e minusculas: o caractere pertence a classe quando
+// qualquer runa da sua orbita de case folding pertence
+func (c *charClass) matches(r rune) bool {
+	found := c.contains(r)
+	for f := unicode.SimpleFold(r); !found && f != r; f = unicode.SimpleFold(f) {
+		found = c.contains(f)
+	}
+	return found != c.negated
+}
+
+// contains retorna se um caractere esta em algum intervalo da classe
+func (c *charClass) contains(r rune) bool {
+	for _, rr := range c.ranges {
+		if rr.lo <= r && r <= rr.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// parser é o analisador descendente recursivo das expressoes:
+//
+//	alternate := concat { "|" concat }
+//	concat    := { repeat }
+//	repeat    := atom { ("*" | "+" | "?") ["?"] }
+//	atom      := "(" ["?:"] alternate ")" | "[" classe "]" | "." | "^" | "$" | "\" escape | caractere
+type parser struct {
+	pattern []rune
+	pos     int
+	groups  int // Quantidade de grupos de captura encontrados
+}
+
+// parse converte uma expressao em sua arvore sintatica e retorna
+// tambem a quantidade de grupos de captura
+func parse(pattern string) (*node, int, error) {
+	p := &parser{pattern: []rune(pattern)}
+	root, err := p.alternate()
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.pos < len(p.pattern) {
+		return nil, 0, fmt.Errorf("unexpected ')' at position %d", p.pos)
+	}
+	return root, p.groups, nil
+}
+
+// more retorna se ainda ha caracteres na expressao
+func (p *parser) more() bool {
+	return p.pos < len(p.pattern)
+}
+
+// peek retorna o caractere atual sem consumi-lo
+func (p *parser) peek() rune {
+	return p.pattern[p.pos]
+}
+
+func (p *parser) alternate() (*node, error) {
+	first, err := p.concat()
+	if err != nil {
+		return nil, err
+	}
+	if !p.more() || p.peek() != '|' {
+		return first, nil
+	}
+
+	alt := &node{kind: nodeAlternate, children: []*node{first}}
+	for p.more() && p.peek() == '|' {
+		p.pos++
+		next, err := p.concat()
+		if err != nil {
+			return nil, err
+		}
+		alt.children = append(alt.children, next)
+	}
+	return alt, nil
+}
+
+func (p *parser) concat() (*node, error) {
+	concat := &node{kind: nodeConcat}
+	for p.more() && p.peek() != '|' && p.peek() != ')' {
+		n, err := p.repeat()
+		if err != nil {
+			return nil, err
+		}
+		concat.children = append(concat.children, n)
+	}
+
+	switch len(concat.children) {
+	case 0:
+		return &node{kind: nodeEmpty}, nil
+	case 1:
+		return concat.children[0], nil
+	}
+	return concat, nil
+}
+
+func (p *parser) repeat() (*node, error) {
+	n, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.more() {
+		var kind nodeKind
+		switch p.peek() {
+		case '*':
+			kind = nodeStar
+		case '+':
+			kind = nodePlus
+		case '?':
+			kind = nodeQuest
+		default:
+			return n, nil
+		}
+		p.pos++
+		n = &node{kind: kind, children: []*node{n}}
+		if p.more() && p.peek() == '?' {
+			n.lazy = true
+			p.pos++
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) atom() (*node, error) {
+	start := p.pos
+	r := p.peek()
+	p.pos++
+
+	switch r {
+	case '(':
+		group := &node{kind: nodeGroup}
+		if p.pos+1 < len(p.pattern) && p.pattern[p.pos] == '?' && p.pattern[p.pos+1] == ':' {
+			p.pos += 2
+		} else {
+			p.groups++
+			group.index = p.groups
+		}
+		child, err := p.alternate()
+		if err != nil {
+			return nil, err
+		}
+		if !p.more() || p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' for group at position %d", start)
+		}
+		p.pos++
+		group.children = []*node{child}
+		return group, nil
+	case '[':
+		class, err := p.class()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeClass, class: class}, nil
+	case '.':
+		return &node{kind: nodeAny}, nil
+	case '^':
+		return &node{kind: nodeBegin}, nil
+	case '$':
+		return &node{kind: nodeEnd}, nil
+	case '\\':
+		return p.escape()
+	case '*', '+', '?':
+		return nil, fmt.Errorf("missing argument to repetition operator '%c' at position %d", r, start)
+	}
+	return &node{kind: nodeLiteral, r: r}, nil
+}
+
+// escape interpreta a sequencia apos uma '\': uma classe (\d, \w, \s e suas
+// negacoes \D, \W, \S), um caractere de controle (\t, \n, \r) ou o proprio
+// caractere escapado, como em "\." ou "\("
+func (p *parser) escape() (*node, error) {
+	if !p.more() {
+		return nil, fmt.Errorf("trailing '\\' at end of pattern")
+	}
+	r := p.peek()
+	p.pos++
+
+	if ranges, negated, ok := escapeClass(r); ok {
+		return &node{kind: nodeClass, class: &charClass{ranges: ranges, negated: negated}}, nil
+	}
+	return &node{kind: nodeLiteral, r: escapeRune(r)}, nil
+}
+
+// class interpreta uma classe de caracteres apos o '['. Um ']' logo no
+// inicio da classe e um '-' nas pontas sao tratados como caracteres comuns
+func (p *parser) class() (*charClass, error) {
+	start := p.pos - 1
+	class := &charClass{}
+	if p.more() && p.peek() == '^' {
+		class.negated = true
+		p.pos++
+	}
+
+	first := true
+	for p.more() && (first || p.peek() != ']') {
+		first = false
+		lo := p.peek()
+		p.pos++
+
+		if lo == '\\' {
+			if !p.more() {
+				break
+			}
+			r := p.peek()
+			p.pos++
+			if ranges, negated, ok := escapeClass(r); ok {
+				if negated {
+					return nil, fmt.Errorf("negated class '\\%c' inside brackets at position %d", r, p.pos-2)
+				}
+				class.ranges = append(class.ranges, ranges...)
+				continue
+			}
+			lo = escapeRune(r)
+		}
+
+		hi := lo
+		if p.pos+1 < len(p.pattern) && p.peek() == '-' && p.pattern[p.pos+1] != ']' {
+			p.pos++
+			hi = p.peek()
+			p.pos++
+			if hi == '\\' {
+				if !p.more() {
+					break
+				}
+				hi = escapeRune(p.peek())
+				p.pos++
+			}
+			if hi < lo {
+				return nil, fmt.Errorf("invalid class range '%c-%c' at position %d", lo, hi, start)
+			}
+		}
+		class.ranges = append(class.ranges, runeRange{lo, hi})
+	}
+
+	if !p.more() {
+		return nil, fmt.Errorf("missing ']' for class at position %d", start)
+	}
+	p.pos++
+	return class, nil
+}
+
+// escapeClass retorna os intervalos das classes \d, \w e \s e de suas negacoes
+func escapeClass(r rune) (ranges []runeRange, negated bool, ok bool) {
+	switch r {
+	case 'd', 'D':
+		ranges = digitRanges
+	case 'w', 'W':
+		ranges = wordRanges
+	case 's', 'S':
+		ranges = spaceRanges
+	default:
+		return nil, false, false
+	}
+	return ranges, unicode.IsUpper(r), true
+}
+
+// escapeRune retorna o caractere representado por uma sequencia de escape
+func escapeRune(r rune) rune {
+	switch r {
+	case 't':
+		return '\t'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	}
+	return r
+}
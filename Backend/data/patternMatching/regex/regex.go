@@ -0,0 +1,292 @@
+// Package regex implementa a pesquisa por expressoes regulares com um
+// automato de Thompson
+//
+// A expressao é convertida em um automato finito nao deterministico (NFA),
+// que é simulado sobre o texto com todos os seus estados ativos ao mesmo tempo
+// (maquina de Pike). Cada caractere do texto é lido uma unica vez, entao a
+// pesquisa tem tempo O(nm), sendo n o tamanho do texto e m o da expressao, e
+// nao sofre do retrocesso exponencial de implementacoes por backtracking em
+// expressoes como "(a*)*b".
+//
+// Sintaxe suportada:
+//
+//	.          qualquer caractere
+//	[abc]      classe de caracteres, com intervalos ([a-z]) e negacao ([^0-9])
+//	\d \w \s   digitos, letras/digitos/'_' e espacos (\D \W \S negam)
+//	^ $        inicio e fim do texto
+//	a|b        alternativa
+//	(a)        grupo de captura
+//	(?:a)      grupo sem captura
+//	* + ?      repeticoes, nao gulosas quando seguidas de '?'
+//	\.         caractere especial escapado
+//
+// A pesquisa ignora a diferenca entre letras maiusculas e minusculas,
+// inclusive fora do ASCII (ver utils.FoldRunes), e as posicoes retornadas sao
+// indices de runas, como no pacote kmp.
+//
+// Este pacote fornece uma função SearchPokemon feita especificamente para o
+// processamento do banco de dados de Pokemons do trabalho original
+//
+// Exemplo de uso:
+//
+//	re, _ := regex.Compile("(fire|flame) (\\w+)")
+//	matches := re.FindAll("A flame burns on its tail")
+//	// matches: [{Start: 2, End: 13, Groups: [{2 7} {8 13}]}]
+package regex
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+)
+
+// MAX_LITERALS é a quantidade maxima de literais extraidos de uma
+// expressao para a filtragem de candidatos (ver Literals)
+const MAX_LITERALS int = 16
+
+// Regex é uma expressao regular compilada
+type Regex struct {
+	pattern  string
+	prog     []inst
+	groups   int
+	literals []string
+}
+
+// Group é o intervalo [Start, End) de um grupo de captura, em runas. Os
+// grupos que nao participaram da ocorrencia possuem Start e End iguais a -1
+type Group struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Match é uma ocorrencia da expressao no intervalo [Start, End) do texto, em
+// runas, com o intervalo capturado por cada grupo da expressao, na ordem em
+// que os parenteses sao abertos
+type Match struct {
+	Start  int     `json:"start"`
+	End    int     `json:"end"`
+	Groups []Group `json:"groups,omitempty"`
+}
+
+// Compile interpreta uma expressao e constroi o seu automato
+func Compile(pattern string) (*Regex, error) {
+	root, groups, err := parse(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	re := &Regex{pattern: pattern, prog: compile(root), groups: groups}
+	re.literals = requiredLiterals(root)
+	return re, nil
+}
+
+// String retorna a expressao original
+func (re *Regex) String() string {
+	return re.pattern
+}
+
+// Groups retorna a quantidade de grupos de captura da expressao
+func (re *Regex) Groups() int {
+	return re.groups
+}
+
+// Literals retorna textos dos quais ao menos um aparece em toda ocorrencia da
+// expressao, ou nil quando nao é possivel garantir nenhum. Em "^Mega .*"
+// retorna ["Mega "], e em "(fire|flame)" retorna ["fire", "flame"]. Os textos
+// podem ser usados para filtrar os candidatos em um indice antes da pesquisa
+func (re *Regex) Literals() []string {
+	return re.literals
+}
+
+// FindAll retorna todas as ocorrencias da expressao no texto, sem sobreposicao,
+// da esquerda para a direita. Depois de uma ocorrencia vazia a pesquisa
+// continua na posicao seguinte, e ocorrencias vazias logo apos o fim de outra
+// ocorrencia sao ignoradas, como no pacote regexp da biblioteca padrao
+func (re *Regex) FindAll(text string) (matches []Match) {
+	runes := []rune(text)
+
+	for pos, last := 0, -1; pos <= len(runes); {
+		caps := re.match(runes, pos)
+		if caps == nil {
+			break
+		}
+
+		m := Match{Start: caps[0], End: caps[1]}
+		pos = m.End
+		if m.End == m.Start {
+			pos++
+			if m.Start == last {
+				continue
+			}
+		}
+		last = m.End
+
+		for i := 1; i <= re.groups; i++ {
+			m.Groups = append(m.Groups, Group{Start: caps[2*i], End: caps[2*i+1]})
+		}
+		matches = append(matches, m)
+	}
+
+	return matches
+}
+
+// MatchString retorna se a expressao ocorre em algum ponto do texto
+func (re *Regex) MatchString(text string) bool {
+	return re.match([]rune(text), 0) != nil
+}
+
+// requiredLiterals retorna os literais obrigatorios da expressao, com ao
+// menos um caractere cada (ver Literals)
+func requiredLiterals(root *node) []string {
+	_, _, required := literals(root)
+	if score(required) == 0 {
+		return nil
+	}
+	return required
+}
+
+// literals analisa um trecho da expressao e retorna todos os textos que ele
+// reconhece (exact), quando sao no maximo MAX_LITERALS, e textos dos quais ao
+// menos um aparece em toda ocorrencia do trecho (required)
+func literals(n *node) (exact []string, ok bool, required []string) {
+	switch n.kind {
+	case nodeLiteral:
+		exact = []string{string(n.r)}
+		return exact, true, exact
+	case nodeBegin, nodeEnd, nodeEmpty:
+		return []string{""}, true, nil
+	case nodeGroup:
+		return literals(n.children[0])
+	case nodePlus:
+		// O trecho aparece ao menos uma vez, mas nao se sabe quantas
+		childExact, childOk, childRequired := literals(n.children[0])
+		if childOk {
+			return nil, false, childExact
+		}
+		return nil, false, childRequired
+	case nodeConcat:
+		// Sequencias de trechos exatos sao combinadas, e o melhor conjunto
+		// obrigatorio entre as sequencias e os demais trechos é mantido
+		current := []string{""}
+		complete := true
+		for _, child := range n.children {
+			childExact, childOk, childRequired := literals(child)
+			if childOk && len(current)*len(childExact) <= MAX_LITERALS {
+				current = cross(current, childExact)
+				continue
+			}
+			complete = false
+			required = best(required, current)
+			required = best(required, childRequired)
+			current = []string{""}
+			if childOk {
+				current = childExact
+			}
+		}
+		if complete {
+			return current, true, current
+		}
+		return nil, false, best(required, current)
+	case nodeAlternate:
+		// Ao menos uma das alternativas aparece
+		all := true
+		union := make([]string, 0)
+		for _, child := range n.children {
+			childExact, childOk, childRequired := literals(child)
+			all = all && childOk
+			if childOk {
+				childRequired = childExact
+			}
+			if childRequired == nil {
+				return nil, false, nil
+			}
+			union = appendUnique(union, childRequired...)
+		}
+		if all && len(union) <= MAX_LITERALS {
+			return union, true, union
+		}
+		return nil, false, union
+	}
+
+	// Repeticoes opcionais e classes nao garantem nenhum texto
+	return nil, false, nil
+}
+
+// cross retorna todas as concatenacoes de um texto de a com um de b
+func cross(a []string, b []string) []string {
+	result := make([]string, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			result = appendUnique(result, x+y)
+		}
+	}
+	return result
+}
+
+// appendUnique adiciona ao slice os textos que ainda nao estao nele
+func appendUnique(slice []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, s := range slice {
+			if s == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			slice = append(slice, v)
+		}
+	}
+	return slice
+}
+
+// best retorna o conjunto de literais mais seletivo, aquele cujo menor texto
+// é o mais longo, preferindo a em caso de empate
+func best(a []string, b []string) []string {
+	if score(b) > score(a) {
+		return b
+	}
+	return a
+}
+
+// score retorna o tamanho, em runas, do menor texto de um conjunto
+func score(set []string) int {
+	if len(set) == 0 {
+		return 0
+	}
+	min := -1
+	for _, s := range set {
+		if l := len([]rune(s)); min == -1 || l < min {
+			min = l
+		}
+	}
+	return min
+}
+
+// SearchPokemon realiza uma busca por uma expressao regular (search) em um campo específico
+// (field) dos registros de Pokemon, retornando os documentos que contêm o Id do pokemon
+// cujo campo possui alguma ocorrencia da expressao e a quantidade de ocorrencias como score.
+// Uma expressao invalida nao encontra nenhum documento
+func SearchPokemon(search string, field string) (scoredDocuments []invertedIndex.ScoredDocument) {
+	scoredDocuments = make([]invertedIndex.ScoredDocument, 0)
+	re, err := Compile(search)
+	if err != nil {
+		return scoredDocuments
+	}
+
+	// Abertura do controlador de leitura
+	controller, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	defer controller.Close()
+
+	// Ler enquanto nao acontecer FEOF
+	for err := controller.ReadNext(); err == nil; err = controller.ReadNext() {
+		// Se nao possuir lapide pesquisar
+		if !controller.RegistroAtual.IsDead() {
+			matches := re.FindAll(controller.RegistroAtual.Pokemon.GetField(field))
+			if len(matches) > 0 {
+				scoredDocuments = append(scoredDocuments, invertedIndex.ScoredDocument{DocumentID: int64(controller.RegistroAtual.Pokemon.Numero), Score: float64(len(matches))})
+			}
+		}
+	}
+
+	return scoredDocuments
+}
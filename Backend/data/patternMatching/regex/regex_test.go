@@ -0,0 +1,108 @@
+// Testes do automato de Thompson: ocorrencias vazias, repeticoes nao
+// gulosas, capturas, tempo linear em expressoes que fazem implementacoes por
+// backtracking explodirem e os literais usados na filtragem de candidatos
+package regex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		text     string
+		expected []Match
+	}{
+		// Ocorrencias vazias, como no pacote regexp
+		{"a*", "baaab", []Match{{0, 0, nil}, {1, 4, nil}, {5, 5, nil}}},
+		{"x*", "abc", []Match{{0, 0, nil}, {1, 1, nil}, {2, 2, nil}, {3, 3, nil}}},
+		{"^", "abc", []Match{{0, 0, nil}}},
+		{"$", "abc", []Match{{3, 3, nil}}},
+
+		// Repeticoes nao gulosas
+		{"a*?", "aaa", []Match{{0, 0, nil}, {1, 1, nil}, {2, 2, nil}, {3, 3, nil}}},
+		{"a+?", "aaa", []Match{{0, 1, nil}, {1, 2, nil}, {2, 3, nil}}},
+		{"<.+?>", "<a><b>", []Match{{0, 3, nil}, {3, 6, nil}}},
+		{"<.+>", "<a><b>", []Match{{0, 6, nil}}},
+		{"a??b", "ab", []Match{{0, 2, nil}}},
+
+		// Capturas
+		{"(fire|flame) (\\w+)", "A flame burns on its tail", []Match{{2, 13, []Group{{2, 7}, {8, 13}}}}},
+		{"(a|ab)(c|bcd)", "abcd", []Match{{0, 4, []Group{{0, 1}, {1, 4}}}}},
+		{"(a)|b", "b", []Match{{0, 1, []Group{{-1, -1}}}}},
+		{"(?:ab)+", "ababx", []Match{{0, 4, nil}}},
+
+		// Classes, escapes e letras fora do ASCII
+		{"[^0-9 ]+", "mewtwo 150", []Match{{0, 6, nil}}},
+		{"\\d+", "#025 e #026", []Match{{1, 4, nil}, {8, 11, nil}}},
+		{"mr\\.", "Mr. Mime", []Match{{0, 3, nil}}},
+		{"FIRE|flame", "Fire e Flame", []Match{{0, 4, nil}, {7, 12, nil}}},
+		{"ß+", "ẞß", []Match{{0, 2, nil}}},
+		{"チュウ$", "ピカチュウ", []Match{{2, 5, nil}}},
+		{"fogo", "água", nil},
+	}
+
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q) = %v", test.pattern, err)
+		}
+		got := re.FindAll(test.text)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Compile(%q).FindAll(%q) = %v, expected %v", test.pattern, test.text, got, test.expected)
+		}
+	}
+}
+
+func TestLinearTime(t *testing.T) {
+	// Com backtracking, cada 'a' a mais dobra o tempo de "(a*)*b"
+	re, err := Compile("(a*)*b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Repeat("a", 10000)
+
+	if re.MatchString(text) {
+		t.Errorf("(a*)*b matched a text without 'b'")
+	}
+	if got := re.FindAll(text + "b"); len(got) != 1 || got[0].Start != 0 || got[0].End != len(text)+1 {
+		t.Errorf("(a*)*b = %v, expected a single match of the whole text", got)
+	}
+}
+
+func TestLiterals(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"^Mega .*", []string{"Mega "}},
+		{"(fire|flame)", []string{"fire", "flame"}},
+		{"(fire|flame) (\\w+)", []string{"fire ", "flame "}},
+		{"(ab)+c", []string{"ab"}},
+		{"x?yz", []string{"yz"}},
+		{"pika(chu)?", []string{"pika"}},
+		{"a*", nil},
+		{"[a-z]+", nil},
+		{"abc|d*", nil},
+	}
+
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q) = %v", test.pattern, err)
+		}
+		if got := re.Literals(); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Compile(%q).Literals() = %q, expected %q", test.pattern, got, test.expected)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, pattern := range []string{"(", "a)", "[a", "*a", "\\"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) returned no error", pattern)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package regex
+
+import (
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// opcode é o tipo de uma instrucao do programa da expressao
+type opcode uint8
+
+const (
+	opRune  opcode = iota // Consome um caractere igual a r
+	opAny                 // Consome qualquer caractere
+	opClass               // Consome um caractere da classe
+	opSplit               // Segue para x e para y, com prioridade para x
+	opJmp                 // Segue para x
+	opSave                // Guarda a posicao atual no slot n das capturas
+	opBegin               // Exige o inicio do texto
+	opEnd                 // Exige o fim do texto
+	opMatch               // Ocorrencia encontrada
+)
+
+// inst é uma instrucao do programa. O programa é o automato de Thompson da
+// expressao: cada instrucao é um estado, opSplit e opJmp sao as transicoes
+// vazias e as demais consomem um caractere ou verificam uma posicao
+type inst struct {
+	op    opcode
+	r     rune
+	class *charClass
+	x     int
+	y     int
+	n     int
+}
+
+// compiler gera o programa a partir da arvore sintatica
+type compiler struct {
+	prog []inst
+}
+
+// compile gera o programa de uma expressao. O programa guarda o inicio e o
+// fim da ocorrencia nos slots 0 e 1, e os de cada grupo i nos slots 2i e 2i+1
+func compile(root *node) []inst {
+	c := &compiler{}
+	c.emit(inst{op: opSave, n: 0})
+	c.node(root)
+	c.emit(inst{op: opSave, n: 1})
+	c.emit(inst{op: opMatch})
+	return c.prog
+}
+
+// emit adiciona uma instrucao e retorna sua posicao
+func (c *compiler) emit(i inst) int {
+	c.prog = append(c.prog, i)
+	return len(c.prog) - 1
+}
+
+// split preenche os destinos de um opSplit de repeticao: a repeticao gulosa
+// prefere repetir o trecho, e a nao gulosa prefere seguir adiante
+func (c *compiler) split(pc int, first int, second int, lazy bool) {
+	if lazy {
+		first, second = second, first
+	}
+	c.prog[pc].x, c.prog[pc].y = first, second
+}
+
+func (c *compiler) node(n *node) {
+	switch n.kind {
+	case nodeLiteral:
+		c.emit(inst{op: opRune, r: utils.FoldRune(n.r)})
+	case nodeAny:
+		c.emit(inst{op: opAny})
+	case nodeClass:
+		c.emit(inst{op: opClass, class: n.class})
+	case nodeBegin:
+		c.emit(inst{op: opBegin})
+	case nodeEnd:
+		c.emit(inst{op: opEnd})
+	case nodeEmpty:
+	case nodeConcat:
+		for _, child := range n.children {
+			c.node(child)
+		}
+	case nodeGroup:
+		if n.index > 0 {
+			c.emit(inst{op: opSave, n: 2 * n.index})
+		}
+		c.node(n.children[0])
+		if n.index > 0 {
+			c.emit(inst{op: opSave, n: 2*n.index + 1})
+		}
+	case nodeAlternate:
+		// split L1, L2; L1: a; jmp fim; L2: split ...; ultimo filho; fim:
+		jumps := make([]int, 0, len(n.children)-1)
+		for i, child := range n.children {
+			if i == len(n.children)-1 {
+				c.node(child)
+				break
+			}
+			pc := c.emit(inst{op: opSplit})
+			c.prog[pc].x = len(c.prog)
+			c.node(child)
+			jumps = append(jumps, c.emit(inst{op: opJmp}))
+			c.prog[pc].y = len(c.prog)
+		}
+		for _, pc := range jumps {
+			c.prog[pc].x = len(c.prog)
+		}
+	case nodeStar:
+		// Quando o trecho pode ser vazio, e* é compilado como (e+)?, para que
+		// o trecho seja executado ao menos uma vez e suas capturas guardadas
+		if nullable(n.children[0]) {
+			plus := &node{kind: nodePlus, children: n.children, lazy: n.lazy}
+			c.node(&node{kind: nodeQuest, children: []*node{plus}, lazy: n.lazy})
+			break
+		}
+		// L1: split L2, L3; L2: e; jmp L1; L3:
+		pc := c.emit(inst{op: opSplit})
+		c.node(n.children[0])
+		c.emit(inst{op: opJmp, x: pc})
+		c.split(pc, pc+1, len(c.prog), n.lazy)
+	case nodePlus:
+		// L1: e; split L1, L3; L3:
+		start := len(c.prog)
+		c.node(n.children[0])
+		pc := c.emit(inst{op: opSplit})
+		c.split(pc, start, len(c.prog), n.lazy)
+	case nodeQuest:
+		// split L1, L2; L1: e; L2:
+		pc := c.emit(inst{op: opSplit})
+		c.node(n.children[0])
+		c.split(pc, pc+1, len(c.prog), n.lazy)
+	}
+}
+
+// nullable retorna se um trecho da expressao reconhece o texto vazio
+func nullable(n *node) bool {
+	switch n.kind {
+	case nodeBegin, nodeEnd, nodeEmpty, nodeStar, nodeQuest:
+		return true
+	case nodeGroup, nodePlus:
+		return nullable(n.children[0])
+	case nodeConcat:
+		for _, child := range n.children {
+			if !nullable(child) {
+				return false
+			}
+		}
+		return true
+	case nodeAlternate:
+		for _, child := range n.children {
+			if nullable(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// thread é uma execucao do automato: o estado atual e as capturas feitas
+type thread struct {
+	pc   int
+	caps []int
+}
+
+// threadList é a lista de estados ativos em uma posicao do texto, na ordem
+// de prioridade. Cada estado aparece no maximo uma vez, o que limita a
+// lista ao tamanho do programa
+type threadList struct {
+	threads []thread
+	seen    []bool
+	marked  []int // Estados marcados em seen, para limpar a lista
+}
+
+func newThreadList(size int) *threadList {
+	return &threadList{threads: make([]thread, 0, size), seen: make([]bool, size), marked: make([]int, 0, size)}
+}
+
+func (l *threadList) clear() {
+	for _, pc := range l.marked {
+		l.seen[pc] = false
+	}
+	l.marked = l.marked[:0]
+	l.threads = l.threads[:0]
+}
+
+// add adiciona um estado a lista seguindo as transicoes vazias e as
+// verificacoes de posicao, na ordem de prioridade. Quando o estado ja foi
+// alcancado por uma execucao de maior prioridade ele é ignorado, e por isso
+// nenhum estado é visitado mais de uma vez por posicao
+func (re *Regex) add(l *threadList, pc int, pos int, n int, caps []int) {
+	if l.seen[pc] {
+		return
+	}
+	l.seen[pc] = true
+	l.marked = append(l.marked, pc)
+
+	switch i := re.prog[pc]; i.op {
+	case opJmp:
+		re.add(l, i.x, pos, n, caps)
+	case opSplit:
+		re.add(l, i.x, pos, n, caps)
+		re.add(l, i.y, pos, n, caps)
+	case opSave:
+		saved := make([]int, len(caps))
+		copy(saved, caps)
+		saved[i.n] = pos
+		re.add(l, pc+1, pos, n, saved)
+	case opBegin:
+		if pos == 0 {
+			re.add(l, pc+1, pos, n, caps)
+		}
+	case opEnd:
+		if pos == n {
+			re.add(l, pc+1, pos, n, caps)
+		}
+	default:
+		l.threads = append(l.threads, thread{pc: pc, caps: caps})
+	}
+}
+
+// match executa o automato de Thompson sobre o texto a partir de uma posicao
+// e retorna as capturas da ocorrencia mais a esquerda, preferindo entre as
+// que comecam na mesma posicao a de maior prioridade (repeticoes gulosas
+// mais longas e alternativas mais a esquerda), ou nil se nao houver.
+//
+// Todos os estados possiveis sao simulados ao mesmo tempo, uma posicao do
+// texto por vez (maquina de Pike), em tempo O(nm) para um texto de tamanho n
+// e um programa de tamanho m, sem o retrocesso exponencial das implementacoes
+// por backtracking
+func (re *Regex) match(text []rune, start int) []int {
+	size := len(re.prog)
+	clist, nlist := newThreadList(size), newThreadList(size)
+	var matched []int
+
+	for pos := start; ; pos++ {
+		// Enquanto nao houver ocorrencia, uma nova execucao comeca em cada
+		// posicao, com prioridade menor que as que comecaram antes
+		if matched == nil {
+			caps := make([]int, 2*(re.groups+1))
+			for i := range caps {
+				caps[i] = -1
+			}
+			re.add(clist, 0, pos, len(text), caps)
+		}
+		if len(clist.threads) == 0 && matched != nil {
+			break
+		}
+
+		var r, folded rune
+		if pos < len(text) {
+			r = text[pos]
+			folded = utils.FoldRune(r)
+		}
+		for _, t := range clist.threads {
+			i := re.prog[t.pc]
+			if i.op == opMatch {
+				// As execucoes seguintes tem prioridade menor e sao descartadas
+				matched = t.caps
+				break
+			}
+			if pos >= len(text) {
+				continue
+			}
+			if (i.op == opRune && folded == i.r) ||
+				i.op == opAny ||
+				(i.op == opClass && i.class.matches(r)) {
+				re.add(nlist, t.pc+1, pos+1, len(text), t.caps)
+			}
+		}
+
+		if pos >= len(text) {
+			break
+		}
+		clist, nlist = nlist, clist
+		nlist.clear()
+	}
+
+	return matched
+}
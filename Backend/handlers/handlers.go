@@ -6,11 +6,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/bplustree"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/trie"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/fuzzy"
 	"github.com/Bernardo46-2/AEDS-III/data/sorts"
 	"github.com/Bernardo46-2/AEDS-III/logger"
 	"github.com/Bernardo46-2/AEDS-III/models"
@@ -127,6 +133,56 @@ func GetList(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetBy recupera os pokemons cujo campo possui exatamente o valor fornecido,
+// atraves do indice hash do campo
+//
+// Exemplo: /getBy?field=nome&value=Pikachu
+func GetBy(w http.ResponseWriter, r *http.Request) {
+	// struct de retorno para conversao em JSON
+	type retorno struct {
+		Pokemons []models.Pokemon `json:"pokemons"`
+		Time     int64            `json:"time"`
+	}
+
+	field := r.URL.Query().Get("field")
+	value := r.URL.Query().Get("value")
+
+	if _, ok := service.HashFields[field]; !ok {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	pokeList, time, err := service.GetBy(field, value)
+
+	// Resposta
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, 2)
+		return
+	}
+
+	writeJson(w, retorno{
+		Pokemons: pokeList,
+		Time:     time,
+	})
+}
+
+// GetIndexes retorna as estatisticas de todos os indices.
+// O parametro opcional 'top' define quantos termos mais frequentes
+// de cada indice invertido sao listados (padrao 10)
+func GetIndexes(w http.ResponseWriter, r *http.Request) {
+	top := 10
+	if s := r.URL.Query().Get("top"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		top = n
+	}
+
+	writeJson(w, service.IndexStats(top))
+}
+
 // GetPokemon recupera o pokemon pelo ID fornecido
 func GetPokemon(w http.ResponseWriter, r *http.Request) {
 	// recuperar ID e ler do arquivo
@@ -158,6 +214,10 @@ func PostPokemon(w http.ResponseWriter, r *http.Request) {
 	id, err := service.Create(pokemon)
 
 	// Resposta
+	if errors.Is(err, bplustree.ErrDuplicateKey) {
+		writeError(w, http.StatusConflict)
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, 3)
 		return
@@ -183,6 +243,10 @@ func PutPokemon(w http.ResponseWriter, r *http.Request) {
 	err = service.Update(pokemon)
 
 	// Resposta
+	if errors.Is(err, bplustree.ErrDuplicateKey) {
+		writeError(w, http.StatusConflict)
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError)
 		return
@@ -226,18 +290,29 @@ func LoadDatabase(w http.ResponseWriter, r *http.Request) {
 	logger.Println("INFO", "B Tree Criada")
 }
 
-// ToKatakana recebe uma string em alfabeto romato, converte para
-// o padrão katakana da linguagem japonesa e retorna a string
-// convertida
-func ToKatakana(w http.ResponseWriter, r *http.Request) {
+// Transliterate recebe um texto em romaji, katakana ou hiragana e retorna
+// o texto convertido para os tres sistemas de escrita, com o romaji no
+// padrao Hepburn
+func Transliterate(w http.ResponseWriter, r *http.Request) {
+	// struct para conversao dos dados em json
+	type retornoTransliteracao struct {
+		Katakana string `json:"katakana"`
+		Hiragana string `json:"hiragana"`
+		Romaji   string `json:"romaji"`
+	}
+
 	// Intercepta
-	stringToConvert := r.URL.Query().Get("stringToConvert")
+	text := r.URL.Query().Get("text")
 
 	// Converte
-	convertedString := utils.ToKatakana(stringToConvert)
+	katakana := utils.ToKatakana(text)
 
 	// Resposta
-	writeJson(w, convertedString)
+	writeJson(w, retornoTransliteracao{
+		Katakana: katakana,
+		Hiragana: utils.ToHiragana(text),
+		Romaji:   utils.ToRomaji(katakana),
+	})
 }
 
 // Ordenacao faz a chamada do devido metodo de ordenacao indexado
@@ -259,13 +334,28 @@ func Ordenacao(w http.ResponseWriter, r *http.Request) {
 }
 
 // MergeSearch faz a chamada do metodo de pesquisa com ordenacao por
-// incidencia e retorna a lista de ids ordenados e o respectivo tempo de
-// execucao dos algoritmos
+// incidencia e retorna a pagina pedida da lista de ids ordenados, o total
+// de ids encontrados, o cursor da proxima pagina, o respectivo tempo de
+// execucao dos algoritmos, quantos registros foram conferidos pelo
+// pattern matching em relacao ao total de registros, o tempo do algoritmo
+// de substring sobre esses registros (de todos eles com "compare": true),
+// as facetas pedidas e, por documento e campo, as ocorrencias encontradas e
+// o trecho destacado
 func MergeSearch(w http.ResponseWriter, r *http.Request) {
 	// struct para conversao dos dados em json
 	type retornoIndexacao struct {
-		Pokemons []int64 `json:"ids"`
-		Time     int64   `json:"time"`
+		Pokemons   []int64                                `json:"ids"`
+		Scores     []float64                              `json:"scores"`
+		Total      int                                    `json:"total"`
+		Offset     int                                    `json:"offset"`
+		Limit      int                                    `json:"limit"`
+		Next       string                                 `json:"next"`
+		Time       int64                                  `json:"time"`
+		Verified   int64                                  `json:"verified"`
+		Records    int64                                  `json:"records"`
+		Timings    map[string]int64                       `json:"timings,omitempty"`
+		Facets     map[string][]service.FacetCount        `json:"facets,omitempty"`
+		Highlights map[int64]map[string]service.Highlight `json:"highlights,omitempty"`
 	}
 
 	var req service.SearchRequest
@@ -275,19 +365,200 @@ func MergeSearch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Pesquisa os valores no indice
-	idList, duration, err := service.MergeSearch(req)
+	page, duration, stats, err := service.MergeSearch(req)
 
 	// Resposta
+	if outdatedIndex(err) {
+		writeError(w, http.StatusConflict, 11)
+		logger.Println("ERROR", err.Error())
+		return
+	}
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, 2)
+		writeError(w, http.StatusBadRequest, 10)
 		return
 	}
-	if len(idList) == 0 {
+	if page.Total == 0 {
 		writeError(w, 2, 2)
 		return
 	}
 
-	writeJson(w, retornoIndexacao{idList, duration})
+	// Ids da pagina ordenados e seus respectivos scores
+	resp := retornoIndexacao{
+		Pokemons:   make([]int64, 0, len(page.Documents)),
+		Scores:     make([]float64, 0, len(page.Documents)),
+		Total:      page.Total,
+		Offset:     page.Offset,
+		Limit:      req.Limit,
+		Next:       page.Next,
+		Time:       duration,
+		Verified:   stats.Verified,
+		Records:    stats.Total,
+		Timings:    stats.Timings,
+		Facets:     page.Facets,
+		Highlights: page.Highlights,
+	}
+	for _, doc := range page.Documents {
+		resp.Pokemons = append(resp.Pokemons, doc.DocumentID)
+		resp.Scores = append(resp.Scores, doc.Score)
+	}
+
+	writeJson(w, resp)
+}
+
+// Query executa uma consulta na linguagem booleana de service.Query e retorna
+// a lista de ids ordenados por relevancia, seus scores, o tempo de execucao e
+// as facetas dos campos separados por virgula no parametro 'facets'
+//
+// Exemplo: /query?q=tipo:fire AND geracao:[1 TO 3] AND NOT lendario:true&facets=tipo,geracao
+func Query(w http.ResponseWriter, r *http.Request) {
+	// struct para conversao dos dados em json
+	type retornoConsulta struct {
+		Pokemons []int64                         `json:"ids"`
+		Scores   []float64                       `json:"scores"`
+		Time     int64                           `json:"time"`
+		Facets   map[string][]service.FacetCount `json:"facets,omitempty"`
+	}
+
+	var facetFields []string
+	if f := r.URL.Query().Get("facets"); f != "" {
+		facetFields = strings.Split(f, ",")
+	}
+
+	scDoc, facets, duration, err := service.Query(r.URL.Query().Get("q"), facetFields...)
+
+	// Resposta
+	if outdatedIndex(err) {
+		writeError(w, http.StatusConflict, 11)
+		logger.Println("ERROR", err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 9)
+		logger.Println("ERROR", err.Error())
+		return
+	}
+
+	resp := retornoConsulta{Pokemons: []int64{}, Scores: []float64{}, Time: duration, Facets: facets}
+	for _, doc := range scDoc {
+		resp.Pokemons = append(resp.Pokemons, doc.DocumentID)
+		resp.Scores = append(resp.Scores, doc.Score)
+	}
+
+	writeJson(w, resp)
+}
+
+// Fuzzy retorna os termos de um campo proximos do texto pesquisado e suas
+// distancias de edicao. O parametro opcional 'distance' define a distancia
+// maxima; sem ele cada palavra usa a tolerancia padrao
+//
+// Exemplo: /fuzzy?field=nome&q=Pikachoo&distance=2
+func Fuzzy(w http.ResponseWriter, r *http.Request) {
+	// struct de retorno para conversao em JSON
+	type retorno struct {
+		Candidates []fuzzy.Candidate `json:"candidates"`
+		Time       int64             `json:"time"`
+	}
+
+	field := r.URL.Query().Get("field")
+	if field == "" {
+		field = "nome"
+	}
+	distance := -1
+	if s := r.URL.Query().Get("distance"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		distance = n
+	}
+
+	candidates, time, err := service.FuzzySearch(field, r.URL.Query().Get("q"), distance)
+
+	// Resposta
+	if err != nil {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	writeJson(w, retorno{
+		Candidates: candidates,
+		Time:       time,
+	})
+}
+
+// Suggest retorna as palavras de um campo que comecam com o prefixo, para o
+// autocompletar da pesquisa. O parametro opcional 'limit' define a quantidade
+// maxima de sugestoes (padrao 10)
+//
+// Exemplo: /suggest?field=nome&prefix=Char&limit=10
+func Suggest(w http.ResponseWriter, r *http.Request) {
+	// struct de retorno para conversao em JSON
+	type retorno struct {
+		Suggestions []trie.Suggestion `json:"suggestions"`
+		Time        int64             `json:"time"` // Microssegundos
+	}
+
+	field := r.URL.Query().Get("field")
+	if !contains(service.SuggestFields, field) {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	limit := 10
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	suggestions, time, err := service.Suggest(field, r.URL.Query().Get("prefix"), limit)
+
+	// Resposta
+	if err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, retorno{
+		Suggestions: suggestions,
+		Time:        time,
+	})
+}
+
+// Matchup retorna o multiplicador de dano de um tipo de ataque contra um
+// defensor de um ou dois tipos e os pokemons que recebem esse multiplicador
+//
+// Exemplo: /matchup?attacker=electric&defender=water,flying
+func Matchup(w http.ResponseWriter, r *http.Request) {
+	matchup, err := service.CalculateMatchup(r.URL.Query().Get("attacker"), r.URL.Query().Get("defender"))
+
+	// Resposta
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 10)
+		logger.Println("ERROR", err.Error())
+		return
+	}
+
+	writeJson(w, matchup)
+}
+
+// outdatedIndex verifica se uma pesquisa falhou por um indice ausente ou
+// gravado em outro formato, que precisa ser recriado com /loadDatabase
+func outdatedIndex(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, bplustree.ErrOutdatedHeader)
+}
+
+// contains verifica se uma lista de strings contem o valor
+func contains(list []string, value string) bool {
+	for _, s := range list {
+		if s == value {
+			return true
+		}
+	}
+	return false
 }
 
 // Encrypt faz o desempacotamento da requisicao para a chamada
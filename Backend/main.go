@@ -12,6 +12,7 @@ package main
 import (
 	"net/http"
 
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
 	h "github.com/Bernardo46-2/AEDS-III/handlers"
 	l "github.com/Bernardo46-2/AEDS-III/logger"
 	m "github.com/Bernardo46-2/AEDS-III/middlewares"
@@ -21,30 +22,41 @@ func Servidor() {
 	// Inicializa o servidor de log
 	l.LigarServidor()
 
+	// Registros gravados em um formato antigo precisam ser carregados novamente
+	if err := binManager.CheckFormat(); err != nil {
+		l.Println("ERROR", err.Error())
+	}
+
 	// Ordenação externa - TP1
-	http.HandleFunc("/ordenacao/", m.EnableCORS(h.Ordenacao))
+	http.HandleFunc("/ordenacao/", m.EnableCORS(m.CheckDatabase(h.Ordenacao)))
 
 	// Indexação - TP2
-	http.HandleFunc("/getPagesNumber/", m.EnableCORS(h.GetPagesNumber))
-	http.HandleFunc("/getIdList", m.EnableCORS(h.GetIdList))
-	http.HandleFunc("/getList/", m.EnableCORS(h.GetList))
-	http.HandleFunc("/get/", m.EnableCORS(h.GetPokemon))
-	http.HandleFunc("/post/", m.EnableCORS(h.PostPokemon))
-	http.HandleFunc("/put/", m.EnableCORS(h.PutPokemon))
-	http.HandleFunc("/delete/", m.EnableCORS(h.DeletePokemon))
+	http.HandleFunc("/getPagesNumber/", m.EnableCORS(m.CheckDatabase(h.GetPagesNumber)))
+	http.HandleFunc("/getIdList", m.EnableCORS(m.CheckDatabase(h.GetIdList)))
+	http.HandleFunc("/getList/", m.EnableCORS(m.CheckDatabase(h.GetList)))
+	http.HandleFunc("/get/", m.EnableCORS(m.CheckDatabase(h.GetPokemon)))
+	http.HandleFunc("/getBy", m.EnableCORS(m.CheckDatabase(h.GetBy)))
+	http.HandleFunc("/indexes", m.EnableCORS(m.CheckDatabase(h.GetIndexes)))
+	http.HandleFunc("/post/", m.EnableCORS(m.CheckDatabase(h.PostPokemon)))
+	http.HandleFunc("/put/", m.EnableCORS(m.CheckDatabase(h.PutPokemon)))
+	http.HandleFunc("/delete/", m.EnableCORS(m.CheckDatabase(h.DeletePokemon)))
 	http.HandleFunc("/loadDatabase", m.EnableCORS(h.LoadDatabase))
-	http.HandleFunc("/toKatakana/", m.EnableCORS(h.ToKatakana))
+	http.HandleFunc("/transliterate", m.EnableCORS(h.Transliterate))
 
 	// Compressao - TP3
-	http.HandleFunc("/zip/", m.EnableCORS(h.Zip))
-	http.HandleFunc("/unzip/", m.EnableCORS(h.Unzip))
+	http.HandleFunc("/zip/", m.EnableCORS(m.CheckDatabase(h.Zip)))
+	http.HandleFunc("/unzip/", m.EnableCORS(m.CheckDatabase(h.Unzip)))
 
 	// Indexacao - TP4
-	http.HandleFunc("/mergeSearch/", m.EnableCORS(h.MergeSearch))
+	http.HandleFunc("/mergeSearch/", m.EnableCORS(m.CheckDatabase(h.MergeSearch)))
+	http.HandleFunc("/query", m.EnableCORS(m.CheckDatabase(h.Query)))
+	http.HandleFunc("/fuzzy", m.EnableCORS(m.CheckDatabase(h.Fuzzy)))
+	http.HandleFunc("/suggest", m.EnableCORS(m.CheckDatabase(h.Suggest)))
+	http.HandleFunc("/matchup", m.EnableCORS(m.CheckDatabase(h.Matchup)))
 
 	// Criptografia - TP5
-	http.HandleFunc("/encrypt/", m.EnableCORS(h.Encrypt))
-	http.HandleFunc("/decrypt/", m.EnableCORS(h.Decrypt))
+	http.HandleFunc("/encrypt/", m.EnableCORS(m.CheckDatabase(h.Encrypt)))
+	http.HandleFunc("/decrypt/", m.EnableCORS(m.CheckDatabase(h.Decrypt)))
 
 	// Inicializa o servidor HTTP na porta 8080 e escreve no log eventuais erros
 	l.Fatal(http.ListenAndServe(":8080", nil))
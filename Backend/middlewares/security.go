@@ -5,7 +5,12 @@
 package middlewares
 
 import (
+	"encoding/json"
 	"net/http"
+
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/logger"
+	"github.com/Bernardo46-2/AEDS-III/models"
 )
 
 // EnableCORS é uma função intermediaria para regularização do sistema de
@@ -26,3 +31,22 @@ func EnableCORS(handler http.HandlerFunc) http.HandlerFunc {
 		handler(w, r)
 	}
 }
+
+// CheckDatabase é uma função intermediaria que recusa as requisições enquanto
+// a database estiver gravada em um formato de registro antigo
+// (ver binManager.CheckFormat), indicando que ela deve ser carregada
+// novamente com /loadDatabase
+func CheckDatabase(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := binManager.CheckFormat(); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.ErrorResponse(11))
+			logger.Println("ERROR", err.Error())
+			return
+		}
+
+		// chama o handler fornecido
+		handler(w, r)
+	}
+}
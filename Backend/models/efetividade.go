@@ -0,0 +1,106 @@
+// O arquivo Efetividade do pacote Models contem a tabela de efetividade entre
+// os tipos de pokemon, usada para calcular o multiplicador do dano que um
+// pokemon recebe de um ataque de acordo com os seus tipos
+package models
+
+import (
+	"strings"
+
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// Types sao os tipos de pokemon, na ordem das colunas against_* do CSV
+var Types = []string{
+	"normal", "fire", "water", "electric", "grass", "ice",
+	"fighting", "poison", "ground", "flying", "psychic", "bug",
+	"rock", "ghost", "dragon", "dark", "steel", "fairy",
+}
+
+// typeChart relaciona cada tipo de ataque ao multiplicador do dano contra
+// cada tipo de defensor. Os pares ausentes possuem multiplicador 1
+var typeChart = map[string]map[string]float64{
+	"normal":   {"rock": 0.5, "ghost": 0, "steel": 0.5},
+	"fire":     {"fire": 0.5, "water": 0.5, "grass": 2, "ice": 2, "bug": 2, "rock": 0.5, "dragon": 0.5, "steel": 2},
+	"water":    {"fire": 2, "water": 0.5, "grass": 0.5, "ground": 2, "rock": 2, "dragon": 0.5},
+	"electric": {"water": 2, "electric": 0.5, "grass": 0.5, "ground": 0, "flying": 2, "dragon": 0.5},
+	"grass":    {"fire": 0.5, "water": 2, "grass": 0.5, "poison": 0.5, "ground": 2, "flying": 0.5, "bug": 0.5, "rock": 2, "dragon": 0.5, "steel": 0.5},
+	"ice":      {"fire": 0.5, "water": 0.5, "grass": 2, "ice": 0.5, "ground": 2, "flying": 2, "dragon": 2, "steel": 0.5},
+	"fighting": {"normal": 2, "ice": 2, "poison": 0.5, "flying": 0.5, "psychic": 0.5, "bug": 0.5, "rock": 2, "ghost": 0, "dark": 2, "steel": 2, "fairy": 0.5},
+	"poison":   {"grass": 2, "poison": 0.5, "ground": 0.5, "rock": 0.5, "ghost": 0.5, "steel": 0, "fairy": 2},
+	"ground":   {"fire": 2, "electric": 2, "grass": 0.5, "poison": 2, "flying": 0, "bug": 0.5, "rock": 2, "steel": 2},
+	"flying":   {"electric": 0.5, "grass": 2, "fighting": 2, "bug": 2, "rock": 0.5, "steel": 0.5},
+	"psychic":  {"fighting": 2, "poison": 2, "psychic": 0.5, "dark": 0, "steel": 0.5},
+	"bug":      {"fire": 0.5, "grass": 2, "fighting": 0.5, "poison": 0.5, "flying": 0.5, "psychic": 2, "ghost": 0.5, "dark": 2, "steel": 0.5, "fairy": 0.5},
+	"rock":     {"fire": 2, "ice": 2, "fighting": 0.5, "ground": 0.5, "flying": 2, "bug": 2, "steel": 0.5},
+	"ghost":    {"normal": 0, "psychic": 2, "ghost": 2, "dark": 0.5},
+	"dragon":   {"dragon": 2, "steel": 0.5, "fairy": 0},
+	"dark":     {"fighting": 0.5, "psychic": 2, "ghost": 2, "dark": 0.5, "fairy": 0.5},
+	"steel":    {"fire": 0.5, "water": 0.5, "electric": 0.5, "ice": 2, "rock": 2, "steel": 0.5, "fairy": 2},
+	"fairy":    {"fire": 0.5, "fighting": 2, "poison": 0.5, "dragon": 2, "dark": 2, "steel": 0.5},
+}
+
+// DamageField retorna o nome do campo do multiplicador de dano recebido de
+// um tipo de ataque, usado em GetFieldF64 e nas arvores B+ ("fire" -> "dano_fire")
+func DamageField(attacker string) string {
+	return "dano_" + attacker
+}
+
+// IsType verifica se um texto é um dos tipos de pokemon (ver Types), sem
+// diferenciar maiusculas e minusculas
+func IsType(t string) bool {
+	_, ok := typeChart[strings.ToLower(t)]
+	return ok
+}
+
+// Effectiveness retorna o multiplicador do dano de um ataque contra um pokemon
+// com os tipos fornecidos: o produto dos multiplicadores contra cada tipo, de
+// forma que um ataque eletrico contra water e flying tem multiplicador 2*2 = 4
+func Effectiveness(attacker string, defenders ...string) float64 {
+	multiplier := 1.0
+	row := typeChart[strings.ToLower(attacker)]
+	for _, defender := range defenders {
+		if m, ok := row[strings.ToLower(defender)]; ok {
+			multiplier *= m
+		}
+	}
+	return multiplier
+}
+
+// DamageTaken calcula pela tabela de efetividade o multiplicador do dano
+// recebido de cada tipo de ataque por um pokemon com os tipos fornecidos
+func DamageTaken(types []string) map[string]float32 {
+	damage := make(map[string]float32, len(Types))
+	for _, attacker := range Types {
+		damage[attacker] = float32(Effectiveness(attacker, types...))
+	}
+	return damage
+}
+
+// Damage retorna o multiplicador do dano recebido pelo pokemon de um tipo de
+// ataque, ou 1 quando o pokemon nao possui o multiplicador daquele tipo
+func (p Pokemon) Damage(attacker string) float32 {
+	if m, ok := p.DanoRecebido[attacker]; ok {
+		return m
+	}
+	return 1
+}
+
+// damageToBytes serializa os multiplicadores de dano recebido na ordem de
+// Types, com tamanho fixo de um float32 por tipo
+func (p *Pokemon) damageToBytes() []byte {
+	b := make([]byte, 0, 4*len(Types))
+	for _, attacker := range Types {
+		b = append(b, utils.FloatToBytes(p.Damage(attacker))...)
+	}
+	return b
+}
+
+// bytesToDamage desserializa os multiplicadores de dano recebido gravados
+// por damageToBytes e avança o ponteiro ptr
+func bytesToDamage(registro []byte, ptr int) (map[string]float32, int) {
+	damage := make(map[string]float32, len(Types))
+	for _, attacker := range Types {
+		damage[attacker], ptr = utils.BytesToFloat32(registro, ptr)
+	}
+	return damage, ptr
+}
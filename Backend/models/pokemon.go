@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +19,21 @@ import (
 // Tamanho maximo para o nome de um pokemon
 const MAX_NAME_LEN = 40
 
+// Languages sao as linguas dos nomes localizados de um pokemon (ver
+// Pokemon.Nomes), alem do ingles do campo Nome
+var Languages = []string{"de"}
+
+// languageColumns relaciona cada lingua a coluna do seu nome no CSV
+var languageColumns = map[string]int{
+	"de": 3,
+}
+
+// LocalizedField retorna o nome do campo do nome localizado em uma lingua,
+// usado em GetField e nos indices ("de" -> "nome_de")
+func LocalizedField(lang string) string {
+	return "nome_" + lang
+}
+
 // PokemonID faz a formatação do ID para serialização em JSON
 type PokemonID struct {
 	ID int `json:"id"`
@@ -26,45 +42,55 @@ type PokemonID struct {
 // Pokemon representa um Pokémon e seus atributos, como número, nome,
 // espécie, habilidades e características físicas.
 //
+// Nomes guarda os nomes localizados por lingua (ver Languages) e
+// DanoRecebido o multiplicador do dano recebido de cada tipo de ataque
+// (ver Types).
+//
 // Todos os dados sao serializaveis com exceção de Size
 type Pokemon struct {
-	Numero     int32     `json:"numero"`
-	Nome       string    `json:"nome,omitempty"`
-	NomeJap    string    `json:"nomeJap,omitempty"`
-	Geracao    int32     `json:"geracao"`
-	Lancamento time.Time `json:"lancamento"`
-	Especie    string    `json:"especie"`
-	Lendario   bool      `json:"lendario"`
-	Mitico     bool      `json:"mitico"`
-	Tipo       []string  `json:"tipo"`
-	Atk        int32     `json:"atk"`
-	Def        int32     `json:"def"`
-	Hp         int32     `json:"hp"`
-	Altura     float32   `json:"altura"`
-	Peso       float32   `json:"peso"`
-	Descricao  string    `json:"descricao"`
-	Size       PokeSize  `json:"-"`
+	Numero       int32              `json:"numero"`
+	Nome         string             `json:"nome,omitempty"`
+	NomeJap      string             `json:"nomeJap,omitempty"`
+	Romaji       string             `json:"romaji,omitempty"`
+	Nomes        map[string]string  `json:"nomes,omitempty"`
+	Geracao      int32              `json:"geracao"`
+	Lancamento   time.Time          `json:"lancamento"`
+	Especie      string             `json:"especie"`
+	Lendario     bool               `json:"lendario"`
+	Mitico       bool               `json:"mitico"`
+	Tipo         []string           `json:"tipo"`
+	Atk          int32              `json:"atk"`
+	Def          int32              `json:"def"`
+	Hp           int32              `json:"hp"`
+	Altura       float32            `json:"altura"`
+	Peso         float32            `json:"peso"`
+	DanoRecebido map[string]float32 `json:"danoRecebido,omitempty"`
+	Descricao    string             `json:"descricao"`
+	Size         PokeSize           `json:"-"`
 }
 
 // PokeSize faz a intermediação para geração de um array de bytes ligado ao
 // tamanho de cada variavel para armazenamento em arquivo binario
 type PokeSize struct {
-	Total      int32
-	Numero     int32
-	Nome       int32
-	NomeJap    int32
-	Geracao    int32
-	Lancamento int32
-	Especie    int32
-	Lendario   int32
-	Mitico     int32
-	Tipo       int32
-	Atk        int32
-	Def        int32
-	Hp         int32
-	Altura     int32
-	Peso       int32
-	Descricao  int32
+	Total        int32
+	Numero       int32
+	Nome         int32
+	NomeJap      int32
+	Romaji       int32
+	Nomes        int32
+	Geracao      int32
+	Lancamento   int32
+	Especie      int32
+	Lendario     int32
+	Mitico       int32
+	Tipo         int32
+	Atk          int32
+	Def          int32
+	Hp           int32
+	Altura       int32
+	Peso         int32
+	DanoRecebido int32
+	Descricao    int32
 }
 
 // GenReleaseDates é um mapa para facil conversão de geração em data de lançamento
@@ -87,6 +113,8 @@ func (p *Pokemon) ToString() string {
 	str += fmt.Sprintf("Numero     = %d\n", p.Numero)
 	str += fmt.Sprintf("Nome       = %s\n", p.Nome)
 	str += fmt.Sprintf("NomeJap    = %s\n", p.NomeJap)
+	str += fmt.Sprintf("Romaji     = %s\n", p.Romaji)
+	str += fmt.Sprintf("Nomes      = %v\n", p.Nomes)
 	str += fmt.Sprintf("Geracao    = %d\n", p.Geracao)
 	str += fmt.Sprintf("Lancamento = %s\n", p.Lancamento.Format("02/01/2006"))
 	str += fmt.Sprintf("Especie    = %s\n", p.Especie)
@@ -98,6 +126,7 @@ func (p *Pokemon) ToString() string {
 	str += fmt.Sprintf("Hp         = %d\n", p.Hp)
 	str += fmt.Sprintf("Altura     = %f\n", p.Altura)
 	str += fmt.Sprintf("Peso       = %f\n", p.Peso)
+	str += fmt.Sprintf("Dano       = %v\n", p.DanoRecebido)
 	str += fmt.Sprintf("Descricao  = %s\n", p.Descricao)
 
 	return str
@@ -157,6 +186,12 @@ func (p *Pokemon) ToBytes() []byte {
 	pokeBytes, offset = copyBytes(pokeBytes, utils.IntToBytes(int32(len(runes)*4)), offset)
 	pokeBytes, offset = copyBytes(pokeBytes, japName, offset)
 
+	pokeBytes, offset = copyBytes(pokeBytes, utils.IntToBytes(p.Size.Romaji), offset)
+	pokeBytes, offset = copyBytes(pokeBytes, []byte(p.Romaji), offset)
+
+	pokeBytes, offset = copyBytes(pokeBytes, utils.IntToBytes(p.Size.Nomes), offset)
+	pokeBytes, offset = copyBytes(pokeBytes, p.nomesToBytes(), offset)
+
 	pokeBytes, offset = copyBytes(pokeBytes, utils.IntToBytes(p.Geracao), offset)
 
 	pokeBytes, offset = copyBytes(pokeBytes, utils.IntToBytes(p.Size.Lancamento), offset)
@@ -180,6 +215,7 @@ func (p *Pokemon) ToBytes() []byte {
 	pokeBytes, offset = copyBytes(pokeBytes, utils.IntToBytes(p.Hp), offset)
 	pokeBytes, offset = copyBytes(pokeBytes, utils.FloatToBytes(p.Altura), offset)
 	pokeBytes, offset = copyBytes(pokeBytes, utils.FloatToBytes(p.Peso), offset)
+	pokeBytes, offset = copyBytes(pokeBytes, p.damageToBytes(), offset)
 	pokeBytes, offset = copyBytes(pokeBytes, utils.IntToBytes(p.Size.Descricao), offset)
 	pokeBytes, _ = copyBytes(pokeBytes, []byte(p.Descricao), offset)
 
@@ -195,6 +231,8 @@ func (p *Pokemon) ParseBinToPoke(registro []byte) error {
 	p.Numero, ptr = utils.BytesToInt32(registro, ptr)
 	p.Nome, ptr = utils.BytesToFixedSizeString(registro, ptr, MAX_NAME_LEN)
 	p.NomeJap, ptr = utils.BytesToJapName(registro, ptr)
+	p.Romaji, ptr = utils.BytesToString(registro, ptr)
+	p.Nomes, ptr = utils.BytesToStringMap(registro, ptr)
 	p.Geracao, ptr = utils.BytesToInt32(registro, ptr)
 	p.Lancamento, ptr = utils.BytesToTime(registro, ptr)
 	p.Especie, ptr = utils.BytesToString(registro, ptr)
@@ -206,6 +244,7 @@ func (p *Pokemon) ParseBinToPoke(registro []byte) error {
 	p.Hp, ptr = utils.BytesToInt32(registro, ptr)
 	p.Altura, ptr = utils.BytesToFloat32(registro, ptr)
 	p.Peso, ptr = utils.BytesToFloat32(registro, ptr)
+	p.DanoRecebido, ptr = bytesToDamage(registro, ptr)
 	p.Descricao, _ = utils.BytesToString(registro, ptr)
 	p.CalculateSize()
 
@@ -220,6 +259,15 @@ func ParsePokemon(line []string) Pokemon {
 	pokemon.Numero, _ = utils.Atoi32(line[1])
 	pokemon.Nome = line[2]
 	pokemon.NomeJap = utils.RemoveAfterSpace(line[4])
+	pokemon.Romaji = parseRomaji(line[4], pokemon.NomeJap)
+	for lang, column := range languageColumns {
+		if name := strings.TrimSpace(line[column]); name != "" {
+			if pokemon.Nomes == nil {
+				pokemon.Nomes = make(map[string]string)
+			}
+			pokemon.Nomes[lang] = name
+		}
+	}
 	geracao, _ := utils.Atoi32(line[5])
 	pokemon.Geracao = geracao
 	pokemon.Lancamento, _ = time.Parse("2006/01/02", GenReleaseDates[int(geracao)])
@@ -238,6 +286,19 @@ func ParsePokemon(line []string) Pokemon {
 
 	pokemon.Altura = float32(altura)
 	pokemon.Peso = float32(peso)
+
+	// Multiplicadores de dano das colunas against_*, que comecam na coluna 35.
+	// Valores ausentes sao calculados pela tabela de efetividade
+	computed := DamageTaken(pokemon.Tipo)
+	pokemon.DanoRecebido = make(map[string]float32, len(Types))
+	for i, attacker := range Types {
+		m, err := strconv.ParseFloat(line[35+i], 32)
+		if err != nil {
+			pokemon.DanoRecebido[attacker] = computed[attacker]
+			continue
+		}
+		pokemon.DanoRecebido[attacker] = float32(m)
+	}
 	pokemon.Descricao = line[len(line)-1]
 
 	pokemon.CalculateSize()
@@ -245,6 +306,39 @@ func ParsePokemon(line []string) Pokemon {
 	return pokemon
 }
 
+// parseRomaji retorna o nome em romaji da coluna japanese_name do CSV, que fica
+// entre parenteses depois do nome em katakana ("フシギダネ (Fushigidane)").
+// Quando a coluna nao possui o romaji ele é gerado a partir do katakana
+func parseRomaji(column string, japName string) string {
+	start := strings.Index(column, "(")
+	end := strings.LastIndex(column, ")")
+	if start >= 0 && end > start {
+		if romaji := strings.TrimSpace(column[start+1 : end]); romaji != "" {
+			return romaji
+		}
+	}
+	return utils.ToRomaji(japName)
+}
+
+// nomesToBytes serializa os nomes localizados em ordem de lingua, cada um
+// como o tamanho e o texto da lingua seguidos do tamanho e do texto do nome
+func (p *Pokemon) nomesToBytes() []byte {
+	langs := make([]string, 0, len(p.Nomes))
+	for lang := range p.Nomes {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	b := make([]byte, 0)
+	for _, lang := range langs {
+		b = append(b, utils.IntToBytes(int32(len(lang)))...)
+		b = append(b, lang...)
+		b = append(b, utils.IntToBytes(int32(len(p.Nomes[lang])))...)
+		b = append(b, p.Nomes[lang]...)
+	}
+	return b
+}
+
 // CalculateSize adiciona ao campo nao serializavel SIZE da struct Pokemon
 // o somatorio do tamanho em byte de todos os campos + features necessarias
 // para a serialização em binario
@@ -253,6 +347,8 @@ func (p *Pokemon) CalculateSize() {
 	p.Size.Numero = int32(unsafe.Sizeof(p.Numero))
 	p.Size.Nome = MAX_NAME_LEN
 	p.Size.NomeJap = int32(len(p.NomeJap) / 3 * 4)
+	p.Size.Romaji = int32(len(p.Romaji))
+	p.Size.Nomes = int32(len(p.nomesToBytes()))
 	p.Size.Geracao = int32(unsafe.Sizeof(p.Geracao))
 
 	date_size, err := p.Lancamento.MarshalBinary()
@@ -273,12 +369,15 @@ func (p *Pokemon) CalculateSize() {
 	p.Size.Hp = int32(unsafe.Sizeof(p.Hp))
 	p.Size.Altura = int32(unsafe.Sizeof(p.Altura))
 	p.Size.Peso = int32(unsafe.Sizeof(p.Peso))
+	p.Size.DanoRecebido = int32(len(Types)) * int32(unsafe.Sizeof(p.Peso))
 	p.Size.Descricao = int32(len(p.Descricao))
 
 	// Soma e adiciona o espaço ocupado pelo bit de tamanho
 	p.Size.Total = p.Size.Numero + 4 +
 		MAX_NAME_LEN +
 		p.Size.NomeJap + 4 +
+		p.Size.Romaji + 4 +
+		p.Size.Nomes + 4 +
 		p.Size.Geracao +
 		p.Size.Lancamento + 4 +
 		p.Size.Especie + 4 +
@@ -290,6 +389,7 @@ func (p *Pokemon) CalculateSize() {
 		p.Size.Hp +
 		p.Size.Altura +
 		p.Size.Peso +
+		p.Size.DanoRecebido +
 		p.Size.Descricao + 4 + 1
 }
 
@@ -306,6 +406,8 @@ func (p Pokemon) GetField(fieldName string) string {
 		return p.Nome
 	case "nomejap":
 		return p.NomeJap
+	case "romaji":
+		return p.Romaji
 	case "geracao":
 		return fmt.Sprint(p.Geracao)
 	case "lancamento":
@@ -331,6 +433,10 @@ func (p Pokemon) GetField(fieldName string) string {
 	case "descricao":
 		return p.Descricao
 	default:
+		// Nomes localizados: "nome_de"
+		if lang, ok := strings.CutPrefix(field, LocalizedField("")); ok {
+			return p.Nomes[lang]
+		}
 		return ""
 	}
 }
@@ -350,6 +456,11 @@ func PokeStrings() []string {
 		}
 	}
 
+	// O mapa de nomes localizados gera um campo textual por lingua
+	for _, lang := range Languages {
+		fieldNames = append(fieldNames, LocalizedField(lang))
+	}
+
 	return fieldNames
 }
 
@@ -380,6 +491,10 @@ func (p Pokemon) GetFieldF64(fieldName string) (float64, int64) {
 	case "mitico":
 		return utils.BoolToFloat(p.Mitico), int64(p.Numero)
 	default:
+		// Multiplicadores de dano recebido: "dano_fire"
+		if attacker, ok := strings.CutPrefix(field, DamageField("")); ok && IsType(attacker) {
+			return float64(p.Damage(attacker)), int64(p.Numero)
+		}
 		return -1, -1
 	}
 }
@@ -428,5 +543,10 @@ func PokeNumbers() []string {
 		}
 	}
 
+	// O mapa de multiplicadores de dano gera um campo numerico por tipo
+	for _, attacker := range Types {
+		fields = append(fields, DamageField(attacker))
+	}
+
 	return fields
 }
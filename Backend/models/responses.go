@@ -56,6 +56,12 @@ func ErrorResponse(codigo int) Response {
 		msg = "Tipo de mídia não suportado"
 	case 8:
 		msg = "Chave invalida!"
+	case 9:
+		msg = "Consulta invalida"
+	case 10:
+		msg = "Parametros de pesquisa invalidos"
+	case 11:
+		msg = "Database em formato antigo, carregue-a novamente com /loadDatabase"
 	default:
 		msg = "Erro desconhecido"
 	}
@@ -0,0 +1,151 @@
+// O arquivo facets do pacote service calcula as facetas de uma pesquisa:
+// contagens dos documentos encontrados agrupados pelos valores de um campo,
+// como "Fire (23) · Water (41)" ou "Gen 1 (12)".
+//
+// Campos textuais sao contados por termo do indice invertido, e campos
+// numericos por intervalos [inicio, fim) de largura fixa, percorridos na
+// arvore B+ do campo.
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/bplustree"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+)
+
+// FacetFields relaciona os campos que aceitam facetas com a largura dos
+// intervalos de suas contagens. Campos com largura 0 sao contados por termo
+// do indice invertido
+var FacetFields = map[string]float64{
+	"tipo":     0,
+	"especie":  0,
+	"geracao":  1,
+	"lendario": 1,
+	"mitico":   1,
+	"atk":      25,
+	"def":      25,
+	"hp":       25,
+	"altura":   1,
+	"peso":     50,
+}
+
+// FacetCount é a quantidade de documentos encontrados com um valor do campo.
+// Nos campos numericos o valor é o intervalo [From, To) contado
+type FacetCount struct {
+	Value string  `json:"value"`
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// facets calcula as facetas dos campos fornecidos sobre os documentos encontrados
+func facets(docs []invertedIndex.ScoredDocument, fields []string) (map[string][]FacetCount, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	documents := make(map[int64]bool, len(docs))
+	for _, doc := range docs {
+		documents[doc.DocumentID] = true
+	}
+
+	result := make(map[string][]FacetCount, len(fields))
+	for _, field := range fields {
+		width, ok := FacetFields[field]
+		if !ok {
+			return nil, fmt.Errorf("invalid facet field '%s'", field)
+		}
+
+		var counts []FacetCount
+		var err error
+		if width == 0 {
+			counts, err = termFacet(field, documents)
+		} else {
+			counts, err = rangeFacet(field, width, documents)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[field] = counts
+	}
+
+	return result, nil
+}
+
+// termFacet conta os documentos por termo do indice invertido de um campo,
+// do termo mais frequente para o menos frequente
+func termFacet(field string, documents map[int64]bool) ([]FacetCount, error) {
+	terms, err := invertedIndex.Count(binManager.FILES_PATH, field, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]FacetCount, 0, len(terms))
+	for term, count := range terms {
+		counts = append(counts, FacetCount{Value: term, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+
+	return counts, nil
+}
+
+// rangeFacet conta os documentos por intervalos de largura fixa da arvore B+
+// de um campo, do menor intervalo para o maior
+func rangeFacet(field string, width float64, documents map[int64]bool) ([]FacetCount, error) {
+	tree, err := bplustree.ReadBPlusTree(binManager.FILES_PATH, field)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	min, max, err := tree.Bounds()
+	if err != nil {
+		return []FacetCount{}, nil
+	}
+
+	counts := make([]FacetCount, 0)
+	for from := math.Floor(min/width) * width; from <= max; from += width {
+		to := from + width
+		bucket := FacetCount{Value: facetLabel(from, to, width), From: from, To: to}
+
+		ids, _ := tree.FindRange(from, math.Nextafter(to, math.Inf(-1)))
+		for _, id := range ids {
+			if documents[id] {
+				bucket.Count++
+			}
+		}
+		counts = append(counts, bucket)
+	}
+
+	// Apenas os intervalos com documentos sao retornados
+	result := make([]FacetCount, 0, len(counts))
+	for _, bucket := range counts {
+		if bucket.Count > 0 {
+			result = append(result, bucket)
+		}
+	}
+
+	return result, nil
+}
+
+// facetLabel retorna o nome de um intervalo: o proprio valor nos
+// intervalos de largura 1, ou "inicio-fim" nos demais
+func facetLabel(from float64, to float64, width float64) string {
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if width == 1 {
+		return format(from)
+	}
+	return format(from) + "-" + format(to)
+}
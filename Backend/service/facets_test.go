@@ -0,0 +1,169 @@
+// Testes das facetas: nomes dos intervalos, contagens por intervalo da
+// arvore B+ e por termo do indice invertido, sobre indices gravados em um
+// diretorio temporario
+package service
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/bplustree"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+)
+
+// facetObject é um documento com os campos "id", "tipo" e "atk"
+type facetObject struct {
+	id   int64
+	tipo string
+	atk  float64
+}
+
+func (o facetObject) GetField(fieldName string) string {
+	if fieldName == "id" {
+		return strconv.FormatInt(o.id, 10)
+	}
+	return o.tipo
+}
+
+func (o facetObject) GetFieldF64(fieldName string) (float64, int64) {
+	return o.atk, o.id
+}
+
+// facetReader percorre uma lista de documentos
+type facetReader struct {
+	objects []facetObject
+	next    int
+}
+
+func (r *facetReader) ReadNextGeneric() (any, bool, int64, error) {
+	if r.next >= len(r.objects) {
+		return nil, false, 0, errors.New("EOF")
+	}
+	o := r.objects[r.next]
+	r.next++
+	return o, false, o.id, nil
+}
+
+// facetFiles grava os indices de "tipo" e "atk" em um diretorio temporario,
+// que passa a ser o diretorio de trabalho ate o fim do teste
+func facetFiles(t *testing.T, objects ...facetObject) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	os.MkdirAll(binManager.FILES_PATH, 0755)
+	if err := invertedIndex.New(&facetReader{objects: objects}, "tipo", binManager.FILES_PATH, 0, "standard"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "atk", bplustree.NON_UNIQUE, &facetReader{objects: objects}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFacetLabel(t *testing.T) {
+	tests := []struct {
+		from, to, width float64
+		expected        string
+	}{
+		{0, 25, 25, "0-25"},
+		{75, 100, 25, "75-100"},
+		{-50, 0, 50, "-50-0"},
+		{1, 2, 1, "1"},
+		{0, 1, 1, "0"},
+		{0.5, 1, 0.5, "0.5-1"},
+		{1.5, 2.5, 1, "1.5"},
+	}
+
+	for _, test := range tests {
+		if got := facetLabel(test.from, test.to, test.width); got != test.expected {
+			t.Errorf("facetLabel(%v, %v, %v) = %q, expected %q", test.from, test.to, test.width, got, test.expected)
+		}
+	}
+}
+
+func TestFacets(t *testing.T) {
+	facetFiles(t,
+		facetObject{1, "Grass Poison", 49},
+		facetObject{2, "Grass Poison", 62},
+		facetObject{3, "Fire", 52},
+		facetObject{4, "Fire Flying", 84},
+		facetObject{5, "Water", 48},
+		facetObject{6, "Water", 50},
+		facetObject{7, "Fire", 130},
+	)
+
+	tests := []struct {
+		name     string
+		docs     []int64
+		field    string
+		expected []FacetCount
+	}{
+		{
+			// Mais documentos primeiro, empates em ordem alfabetica
+			"terms",
+			[]int64{1, 2, 3, 4, 5},
+			"tipo",
+			[]FacetCount{{Value: "fire", Count: 2}, {Value: "grass", Count: 2}, {Value: "poison", Count: 2}, {Value: "flying", Count: 1}, {Value: "water", Count: 1}},
+		},
+		{
+			"terms outside the results are not counted",
+			[]int64{5, 6},
+			"tipo",
+			[]FacetCount{{Value: "water", Count: 2}},
+		},
+		{
+			// Valores no fim de um intervalo contam no seguinte, e intervalos
+			// sem documentos (100-125) nao sao retornados
+			"ranges",
+			[]int64{1, 2, 3, 4, 5, 6, 7},
+			"atk",
+			[]FacetCount{
+				{Value: "25-50", From: 25, To: 50, Count: 2},
+				{Value: "50-75", From: 50, To: 75, Count: 3},
+				{Value: "75-100", From: 75, To: 100, Count: 1},
+				{Value: "125-150", From: 125, To: 150, Count: 1},
+			},
+		},
+		{
+			"ranges outside the results are not counted",
+			[]int64{6},
+			"atk",
+			[]FacetCount{{Value: "50-75", From: 50, To: 75, Count: 1}},
+		},
+		{"no results", []int64{}, "atk", []FacetCount{}},
+	}
+
+	for _, test := range tests {
+		docs := make([]invertedIndex.ScoredDocument, len(test.docs))
+		for i, id := range test.docs {
+			docs[i] = invertedIndex.ScoredDocument{DocumentID: id}
+		}
+		got, err := facets(docs, []string{test.field})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got[test.field], test.expected) {
+			t.Errorf("%s: facets = %+v, expected %+v", test.name, got[test.field], test.expected)
+		}
+	}
+
+	if got, err := facets(nil, nil); got != nil || err != nil {
+		t.Errorf("facets without fields = %v, %v, expected nothing", got, err)
+	}
+	if _, err := facets(nil, []string{"nome"}); err == nil {
+		t.Errorf("facets accepted a field without facets")
+	}
+	if _, err := facets(nil, []string{"def"}); err == nil {
+		t.Errorf("facets on a missing tree returned no error")
+	}
+}
@@ -0,0 +1,140 @@
+// O arquivo highlight do pacote service monta o destaque dos resultados de
+// uma pesquisa por pattern matching: as posicoes das ocorrencias em cada campo
+// e um trecho da descricao com as ocorrencias marcadas, para que o frontend
+// possa mostrar por que cada pokemon foi encontrado.
+package service
+
+import (
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+)
+
+// Campo do qual é gerado o trecho destacado e quantidade de caracteres de
+// contexto mantidos antes e depois das ocorrencias
+const (
+	SNIPPET_FIELD  string = "descricao"
+	SNIPPET_RADIUS int    = 60
+)
+
+// Marcacao das ocorrencias no trecho destacado
+const (
+	MARK_OPEN  string = "<mark>"
+	MARK_CLOSE string = "</mark>"
+)
+
+// Match é uma ocorrencia de um padrao no intervalo [Start, End) de um campo,
+// em caracteres (runas) do texto. Nas expressoes regulares Groups guarda o
+// intervalo de cada grupo de captura, com -1 nos que nao participaram
+type Match struct {
+	Start  int     `json:"start"`
+	End    int     `json:"end"`
+	Groups []Match `json:"groups,omitempty"`
+}
+
+// Highlight é o destaque de um campo de um documento: suas ocorrencias e,
+// na descricao, o trecho ao redor delas com as ocorrencias marcadas
+type Highlight struct {
+	Matches []Match `json:"matches"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// highlights monta o destaque de cada campo dos documentos fornecidos com
+// as ocorrencias guardadas durante a pesquisa
+func highlights(docs []invertedIndex.ScoredDocument, stats *SearchStats) map[int64]map[string]Highlight {
+	result := make(map[int64]map[string]Highlight)
+
+	for _, doc := range docs {
+		fields, ok := stats.matches[doc.DocumentID]
+		if !ok {
+			continue
+		}
+
+		result[doc.DocumentID] = make(map[string]Highlight, len(fields))
+		for field, matches := range fields {
+			h := Highlight{Matches: mergeMatches(matches)}
+			if field == SNIPPET_FIELD {
+				pokemon, err := Read(int(doc.DocumentID))
+				if err == nil {
+					h.Snippet = snippet(pokemon.GetField(field), h.Matches)
+				}
+			}
+			result[doc.DocumentID][field] = h
+		}
+	}
+
+	return result
+}
+
+// mergeMatches ordena as ocorrencias e une as que se sobrepoem
+func mergeMatches(matches []Match) []Match {
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+
+	merged := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		if last := len(merged) - 1; last >= 0 && m.Start <= merged[last].End {
+			if m.End > merged[last].End {
+				merged[last].End = m.End
+			}
+			continue
+		}
+		merged = append(merged, m)
+	}
+
+	return merged
+}
+
+// snippet retorna o trecho do texto ao redor da primeira ocorrencia, com
+// todas as ocorrencias do trecho entre <mark> e </mark>. O texto é escapado
+// para HTML e os cortes sao indicados com "..."
+func snippet(text string, matches []Match) string {
+	if len(matches) == 0 {
+		return ""
+	}
+	runes := []rune(text)
+
+	// Janela ao redor da primeira ocorrencia
+	start := clamp(matches[0].Start-SNIPPET_RADIUS, len(runes))
+	end := clamp(matches[0].End+SNIPPET_RADIUS, len(runes))
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("...")
+	}
+
+	pos := start
+	for _, m := range matches {
+		if m.Start < pos || m.Start >= end || m.End > len(runes) {
+			continue
+		}
+		// Ocorrencias que comecam dentro do trecho sao mostradas inteiras
+		if m.End > end {
+			end = m.End
+		}
+		sb.WriteString(html.EscapeString(string(runes[pos:m.Start])))
+		sb.WriteString(MARK_OPEN)
+		sb.WriteString(html.EscapeString(string(runes[m.Start:m.End])))
+		sb.WriteString(MARK_CLOSE)
+		pos = m.End
+	}
+	sb.WriteString(html.EscapeString(string(runes[pos:end])))
+
+	if end < len(runes) {
+		sb.WriteString("...")
+	}
+
+	return sb.String()
+}
+
+// clamp limita uma posicao ao intervalo [0, length]
+func clamp(pos int, length int) int {
+	if pos < 0 {
+		return 0
+	}
+	if pos > length {
+		return length
+	}
+	return pos
+}
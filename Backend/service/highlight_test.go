@@ -0,0 +1,98 @@
+// Testes do destaque: uniao das ocorrencias sobrepostas e trechos da
+// descricao com marcacao, escape para HTML e cortes indicados com "..."
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		matches  []Match
+		expected []Match
+	}{
+		{"empty", []Match{}, []Match{}},
+		{"unsorted", []Match{{Start: 5, End: 7}, {Start: 0, End: 2}}, []Match{{Start: 0, End: 2}, {Start: 5, End: 7}}},
+		{"overlapping", []Match{{Start: 0, End: 4}, {Start: 2, End: 6}}, []Match{{Start: 0, End: 6}}},
+		{"contained", []Match{{Start: 0, End: 10}, {Start: 2, End: 4}}, []Match{{Start: 0, End: 10}}},
+		{"adjacent", []Match{{Start: 0, End: 3}, {Start: 3, End: 5}}, []Match{{Start: 0, End: 5}}},
+		{"chain", []Match{{Start: 6, End: 9}, {Start: 0, End: 3}, {Start: 2, End: 7}}, []Match{{Start: 0, End: 9}}},
+		{"repeated", []Match{{Start: 1, End: 4}, {Start: 1, End: 4}}, []Match{{Start: 1, End: 4}}},
+	}
+
+	for _, test := range tests {
+		if got := mergeMatches(test.matches); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: mergeMatches = %v, expected %v", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	a := strings.Repeat("a", 100)
+	b := strings.Repeat("b", 100)
+	x := strings.Repeat("x", 58)
+	context := func(s string) string { return s[:SNIPPET_RADIUS] }
+
+	tests := []struct {
+		name     string
+		text     string
+		matches  []Match
+		expected string
+	}{
+		{"no matches", "Pikachu", nil, ""},
+		{
+			"escaped",
+			`Tom & "Jerry" <b>`,
+			[]Match{{Start: 7, End: 12}},
+			`Tom &amp; &#34;<mark>Jerry</mark>&#34; &lt;b&gt;`,
+		},
+		{"escaped match", "a<b", []Match{{Start: 1, End: 2}}, "a<mark>&lt;</mark>b"},
+		{
+			"runes",
+			"ピカチュウ & ライチュウ",
+			[]Match{{Start: 8, End: 13}},
+			"ピカチュウ &amp; <mark>ライチュウ</mark>",
+		},
+		{
+			"clipped on both sides",
+			a + "Pikachu" + b,
+			[]Match{{Start: 100, End: 107}},
+			"..." + context(a) + "<mark>Pikachu</mark>" + context(b) + "...",
+		},
+		{
+			"clipped at the end",
+			"Pikachu" + b,
+			[]Match{{Start: 0, End: 7}},
+			"<mark>Pikachu</mark>" + context(b) + "...",
+		},
+		{
+			"clipped text is escaped",
+			strings.Repeat("&", 100) + "Pikachu",
+			[]Match{{Start: 100, End: 107}},
+			"..." + strings.Repeat("&amp;", SNIPPET_RADIUS) + "<mark>Pikachu</mark>",
+		},
+		{
+			// Ocorrencias que comecam dentro do trecho sao mostradas inteiras
+			"match crossing the end",
+			"Pikachu" + x + "Raichu" + strings.Repeat("c", 50),
+			[]Match{{Start: 0, End: 7}, {Start: 65, End: 71}},
+			"<mark>Pikachu</mark>" + x + "<mark>Raichu</mark>...",
+		},
+		{
+			"match after the end",
+			"Pikachu" + b + "Raichu",
+			[]Match{{Start: 0, End: 7}, {Start: 107, End: 113}},
+			"<mark>Pikachu</mark>" + context(b) + "...",
+		},
+		{"match past the text", "Pikachu", []Match{{Start: 3, End: 20}}, "Pikachu"},
+	}
+
+	for _, test := range tests {
+		if got := snippet(test.text, test.matches); got != test.expected {
+			t.Errorf("%s: snippet = %q, expected %q", test.name, got, test.expected)
+		}
+	}
+}
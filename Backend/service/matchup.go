@@ -0,0 +1,132 @@
+// O arquivo matchup do pacote service calcula o confronto entre um tipo de
+// ataque e um defensor de um ou dois tipos. Os pokemons do defensor sao
+// encontrados no indice invertido do campo tipo, e o multiplicador de dano é
+// o gravado nesses pokemons, com a tabela de efetividade usada apenas para
+// combinacoes de tipos que nenhum pokemon possui.
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/bplustree"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/models"
+)
+
+// Matchup é o resultado de um confronto: o multiplicador contra cada tipo do
+// defensor isolado, o multiplicador contra o defensor e os ids dos pokemons
+// do defensor que recebem esse multiplicador de um ataque do atacante
+type Matchup struct {
+	Attacker    string             `json:"attacker"`
+	Defender    []string           `json:"defender"`
+	Multipliers map[string]float64 `json:"multipliers"`
+	Multiplier  float64            `json:"multiplier"`
+	Pokemons    []int64            `json:"pokemons"`
+}
+
+// CalculateMatchup calcula o confronto entre um tipo de ataque e um defensor
+// com um ou dois tipos separados por virgula ("water,flying"). Os
+// multiplicadores sao os gravados nos pokemons com exatamente os tipos do
+// defensor (ver storedMultiplier), e os pokemons retornados sao os do defensor
+// que recebem o multiplicador combinado, lidos do intervalo [m, m] da arvore
+// B+ do atacante. Tipos desconhecidos retornam erro
+func CalculateMatchup(attacker string, defender string) (matchup Matchup, err error) {
+	attacker = strings.ToLower(strings.TrimSpace(attacker))
+	if !models.IsType(attacker) {
+		return matchup, fmt.Errorf("unknown attacker type '%s'", attacker)
+	}
+
+	types := make([]string, 0, 2)
+	for _, t := range strings.Split(defender, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !models.IsType(t) {
+			return matchup, fmt.Errorf("unknown defender type '%s'", t)
+		}
+		if !contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) > 2 {
+		return matchup, fmt.Errorf("a pokemon has at most 2 types, got %d", len(types))
+	}
+
+	matchup = Matchup{Attacker: attacker, Defender: types, Multipliers: make(map[string]float64), Pokemons: []int64{}}
+	records := make(map[int64]models.Pokemon)
+	for _, t := range types {
+		matchup.Multipliers[t], _ = storedMultiplier(attacker, []string{t}, records)
+	}
+	multiplier, ids := storedMultiplier(attacker, types, records)
+	matchup.Multiplier = multiplier
+
+	tree, err := bplustree.ReadBPlusTree(binManager.FILES_PATH, models.DamageField(attacker))
+	if err != nil {
+		return matchup, err
+	}
+	defer tree.Close()
+
+	found, _ := tree.FindRange(multiplier, multiplier)
+	for _, id := range found {
+		if ids[id] {
+			matchup.Pokemons = append(matchup.Pokemons, id)
+		}
+	}
+	sort.Slice(matchup.Pokemons, func(i, j int) bool { return matchup.Pokemons[i] < matchup.Pokemons[j] })
+
+	return matchup, nil
+}
+
+// storedMultiplier retorna o multiplicador de dano do atacante gravado nos
+// pokemons com exatamente os tipos fornecidos e os ids desses pokemons. Os
+// pokemons sao a intersecao das listas invertidas dos tipos no campo tipo,
+// sem os que possuem algum outro tipo, e quando seus multiplicadores diferem
+// é usado o mais comum. Sem nenhum pokemon com esses tipos o multiplicador é
+// calculado pela tabela de efetividade.
+//
+// Os registros lidos sao guardados em 'records' para as proximas chamadas
+func storedMultiplier(attacker string, types []string, records map[int64]models.Pokemon) (float64, map[int64]bool) {
+	ids := make(map[int64]bool)
+	counts := make(map[float64]int)
+
+	for _, doc := range invertedIndex.Match(binManager.FILES_PATH, "tipo", types...) {
+		pokemon, ok := records[doc.DocumentID]
+		if !ok {
+			var err error
+			if pokemon, err = Read(int(doc.DocumentID)); err != nil {
+				continue
+			}
+			records[doc.DocumentID] = pokemon
+		}
+		if !sameTypes(pokemon.Tipo, types) {
+			continue
+		}
+		ids[doc.DocumentID] = true
+		counts[float64(pokemon.Damage(attacker))]++
+	}
+
+	if len(counts) == 0 {
+		return models.Effectiveness(attacker, types...), ids
+	}
+	multiplier, best := 0.0, 0
+	for m, count := range counts {
+		if count > best || (count == best && m < multiplier) {
+			multiplier, best = m, count
+		}
+	}
+	return multiplier, ids
+}
+
+// sameTypes testa se os tipos de um pokemon sao exatamente os fornecidos
+func sameTypes(tipo []string, types []string) bool {
+	if len(tipo) != len(types) {
+		return false
+	}
+	for _, t := range tipo {
+		if !contains(types, strings.ToLower(t)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+// O arquivo pagination do pacote service implementa a paginacao e ordenacao
+// dos resultados de uma pesquisa (ver MergeSearch).
+//
+// Os documentos podem ser ordenados pelo score ou por qualquer campo numerico
+// do pokemon (ver models.Pokemon.GetFieldF64), com empates desfeitos pelo id,
+// de forma que a ordem do resultado seja sempre total e deterministica.
+//
+// Uma pagina pode ser pedida por offset ou por cursor. O cursor guarda a chave
+// (valor de ordenacao e id) do ultimo documento da pagina anterior, e a pagina
+// seguinte comeca no primeiro documento apos essa chave. Ao contrario do
+// offset, o cursor continua valido quando pokemons sao inseridos ou removidos
+// entre uma pagina e outra, sem repetir ou pular resultados.
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/bplustree"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/models"
+)
+
+// PAGE_SIZE é a quantidade de pokemons exibidos por pagina no frontend
+const PAGE_SIZE int = 60
+
+// Ordenacoes aceitas
+const (
+	SORT_SCORE string = "score"
+	ORDER_ASC  string = "asc"
+	ORDER_DESC string = "desc"
+)
+
+// SearchPage é uma pagina do resultado de uma pesquisa
+type SearchPage struct {
+	Documents  []invertedIndex.ScoredDocument // Documentos da pagina
+	Total      int                            // Total de documentos encontrados
+	Offset     int                            // Posicao do primeiro documento da pagina
+	Next       string                         // Cursor da proxima pagina, vazio na ultima
+	Facets     map[string][]FacetCount        // Facetas de todos os documentos encontrados
+	Highlights map[int64]map[string]Highlight // Ocorrencias dos documentos da pagina
+}
+
+// sortKey é a chave de ordenacao de um documento
+type sortKey struct {
+	value float64
+	id    int64
+}
+
+// paginate ordena os documentos pelo criterio da requisicao e retorna
+// a pagina pedida. Um limite menor ou igual a 0 retorna todos os
+// documentos a partir do offset ou do cursor.
+//
+// Apenas os documentos necessarios sao ordenados: com cursor, os que vem
+// depois dele, e dentre esses somente os que chegam ao fim da pagina
+func (req SearchRequest) paginate(docs []invertedIndex.ScoredDocument) (page SearchPage, err error) {
+	sortBy, order, err := req.sorting()
+	if err != nil {
+		return page, err
+	}
+
+	// Chave de cada documento
+	values, err := sortValues(docs, sortBy)
+	if err != nil {
+		return page, err
+	}
+	keys := make(map[int64]sortKey, len(docs))
+	for _, doc := range docs {
+		keys[doc.DocumentID] = sortKey{value: values[doc.DocumentID], id: doc.DocumentID}
+	}
+
+	less := func(a, b sortKey) bool {
+		if a.value != b.value {
+			if order == ORDER_DESC {
+				return a.value > b.value
+			}
+			return a.value < b.value
+		}
+		return a.id < b.id
+	}
+
+	// Inicio da pagina: com cursor os documentos ate a sua chave sao
+	// descartados sem ordenar, e a pagina comeca no primeiro que sobrar
+	total := len(docs)
+	start, skip := req.Offset, req.Offset
+	if req.Cursor != "" {
+		after, err := decodeCursor(req.Cursor, sortBy, order)
+		if err != nil {
+			return page, err
+		}
+		rest := make([]invertedIndex.ScoredDocument, 0, len(docs))
+		for _, doc := range docs {
+			if less(after, keys[doc.DocumentID]) {
+				rest = append(rest, doc)
+			}
+		}
+		start, skip = total-len(rest), 0
+		docs = rest
+	}
+	if skip < 0 {
+		return page, fmt.Errorf("invalid offset %d", skip)
+	}
+	if skip > len(docs) {
+		start, skip = len(docs), len(docs)
+	}
+
+	// Fim da pagina
+	end := len(docs)
+	if req.Limit > 0 && skip+req.Limit < end {
+		end = skip + req.Limit
+	}
+
+	sortFirst(docs, end, func(a, b invertedIndex.ScoredDocument) bool {
+		return less(keys[a.DocumentID], keys[b.DocumentID])
+	})
+
+	page = SearchPage{Documents: docs[skip:end], Total: total, Offset: start}
+	if end < len(docs) && end > skip {
+		page.Next = encodeCursor(sortBy, order, keys[docs[end-1].DocumentID])
+	}
+
+	return page, nil
+}
+
+// sortValues retorna o valor de ordenacao de cada documento. O score e o id
+// vem do proprio documento, e os demais campos sao lidos em uma unica passada
+// pelas folhas da arvore B+ do campo, sem ler os registros dos pokemons
+func sortValues(docs []invertedIndex.ScoredDocument, sortBy string) (map[int64]float64, error) {
+	values := make(map[int64]float64, len(docs))
+	switch sortBy {
+	case SORT_SCORE:
+		for _, doc := range docs {
+			values[doc.DocumentID] = doc.Score
+		}
+	case "id":
+		for _, doc := range docs {
+			values[doc.DocumentID] = float64(doc.DocumentID)
+		}
+	default:
+		tree, err := bplustree.ReadBPlusTree(binManager.FILES_PATH, sortBy)
+		if err != nil {
+			return nil, err
+		}
+		defer tree.Close()
+
+		found := make(map[int64]bool, len(docs))
+		for _, doc := range docs {
+			found[doc.DocumentID] = true
+		}
+		keys, _ := tree.FindRangeKeys(-math.MaxFloat64, math.MaxFloat64)
+		for _, k := range keys {
+			if found[k.Ptr] {
+				values[k.Ptr] = k.Id
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// sortFirst ordena apenas os k primeiros documentos: ao final docs[:k] sao
+// os k menores segundo 'less', em ordem, e o restante fica em qualquer ordem.
+// Os k menores sao selecionados com um heap de maximo em docs[:k]
+func sortFirst(docs []invertedIndex.ScoredDocument, k int, less func(a, b invertedIndex.ScoredDocument) bool) {
+	if k >= len(docs) {
+		sort.Slice(docs, func(i, j int) bool { return less(docs[i], docs[j]) })
+		return
+	}
+	if k <= 0 {
+		return
+	}
+
+	siftDown := func(i int) {
+		for {
+			largest := i
+			for _, child := range []int{2*i + 1, 2*i + 2} {
+				if child < k && less(docs[largest], docs[child]) {
+					largest = child
+				}
+			}
+			if largest == i {
+				return
+			}
+			docs[i], docs[largest] = docs[largest], docs[i]
+			i = largest
+		}
+	}
+
+	for i := k/2 - 1; i >= 0; i-- {
+		siftDown(i)
+	}
+	for i := k; i < len(docs); i++ {
+		if less(docs[i], docs[0]) {
+			docs[0], docs[i] = docs[i], docs[0]
+			siftDown(0)
+		}
+	}
+
+	first := docs[:k]
+	sort.Slice(first, func(i, j int) bool { return less(first[i], first[j]) })
+}
+
+// sorting retorna o campo e a direcao de ordenacao da requisicao. O padrao
+// é o score em ordem decrescente, e os campos numericos em ordem crescente
+func (req SearchRequest) sorting() (sortBy string, order string, err error) {
+	sortBy = strings.ToLower(req.SortBy)
+	if sortBy == "" {
+		sortBy = SORT_SCORE
+	}
+	if sortBy != SORT_SCORE && sortBy != "id" && !contains(models.PokeNumbers(), sortBy) {
+		return "", "", fmt.Errorf("invalid sort field '%s'", req.SortBy)
+	}
+
+	order = strings.ToLower(req.Order)
+	switch {
+	case order == "" && sortBy == SORT_SCORE:
+		order = ORDER_DESC
+	case order == "":
+		order = ORDER_ASC
+	case order != ORDER_ASC && order != ORDER_DESC:
+		return "", "", fmt.Errorf("invalid sort order '%s'", req.Order)
+	}
+
+	return sortBy, order, nil
+}
+
+// encodeCursor gera o cursor que aponta para depois da chave fornecida.
+// A ordenacao é incluida no cursor para que ele nao seja usado com outra
+func encodeCursor(sortBy string, order string, key sortKey) string {
+	raw := strings.Join([]string{
+		sortBy,
+		order,
+		strconv.FormatFloat(key.value, 'g', -1, 64),
+		strconv.FormatInt(key.id, 10),
+	}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor recupera a chave de um cursor, validando sua ordenacao
+func decodeCursor(cursor string, sortBy string, order string) (key sortKey, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return key, fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 4 || parts[0] != sortBy || parts[1] != order {
+		return key, fmt.Errorf("invalid cursor")
+	}
+
+	key.value, err = strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return key, fmt.Errorf("invalid cursor")
+	}
+	key.id, err = strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return key, fmt.Errorf("invalid cursor")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,194 @@
+// Testes da paginacao: selecao dos primeiros documentos, cursores e
+// estabilidade das paginas seguintes quando documentos sao inseridos ou
+// removidos entre uma pagina e outra
+package service
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+)
+
+// scored cria documentos com os pares (id, score) fornecidos
+func scored(pairs ...float64) []invertedIndex.ScoredDocument {
+	docs := make([]invertedIndex.ScoredDocument, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		docs = append(docs, invertedIndex.ScoredDocument{DocumentID: int64(pairs[i]), Score: pairs[i+1]})
+	}
+	return docs
+}
+
+// docIDs retorna os ids dos documentos, na ordem
+func docIDs(docs []invertedIndex.ScoredDocument) []int64 {
+	result := make([]int64, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.DocumentID
+	}
+	return result
+}
+
+func TestSortFirst(t *testing.T) {
+	random := rand.New(rand.NewSource(46))
+	less := func(a, b invertedIndex.ScoredDocument) bool {
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.DocumentID < b.DocumentID
+	}
+
+	for n := 0; n <= 40; n += 5 {
+		for k := -1; k <= n+1; k++ {
+			docs := make([]invertedIndex.ScoredDocument, n)
+			for i := range docs {
+				docs[i] = invertedIndex.ScoredDocument{DocumentID: int64(i), Score: float64(random.Intn(5))}
+			}
+			random.Shuffle(n, func(i, j int) { docs[i], docs[j] = docs[j], docs[i] })
+			expected := append([]invertedIndex.ScoredDocument{}, docs...)
+			sort.Slice(expected, func(i, j int) bool { return less(expected[i], expected[j]) })
+
+			sortFirst(docs, k, less)
+			first := k
+			if first < 0 {
+				first = 0
+			}
+			if first > n {
+				first = n
+			}
+			if !reflect.DeepEqual(docs[:first], expected[:first]) {
+				t.Fatalf("n = %d, k = %d: sortFirst = %v, expected %v", n, k, docs[:first], expected[:first])
+			}
+
+			// O restante continua com os mesmos documentos
+			rest := docIDs(docs)
+			sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+			for i, id := range rest {
+				if id != int64(i) {
+					t.Fatalf("n = %d, k = %d: documents lost or repeated: %v", n, k, rest)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	key := sortKey{value: -2.5, id: 25}
+	cursor := encodeCursor(SORT_SCORE, ORDER_DESC, key)
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		cursor  string
+		sortBy  string
+		order   string
+		invalid bool
+	}{
+		{cursor, SORT_SCORE, ORDER_DESC, false},
+		{encodeCursor("atk", ORDER_ASC, key), "atk", ORDER_ASC, false},
+		// Cursor de outra ordenacao
+		{cursor, SORT_SCORE, ORDER_ASC, true},
+		{cursor, "atk", ORDER_DESC, true},
+		// Cursores mal formados
+		{"", SORT_SCORE, ORDER_DESC, true},
+		{"%%%", SORT_SCORE, ORDER_DESC, true},
+		{raw("score|desc|1"), SORT_SCORE, ORDER_DESC, true},
+		{raw("score|desc|x|25"), SORT_SCORE, ORDER_DESC, true},
+		{raw("score|desc|1|2|3"), SORT_SCORE, ORDER_DESC, true},
+		{raw("score|desc|1|vinte"), SORT_SCORE, ORDER_DESC, true},
+	}
+
+	for _, test := range tests {
+		got, err := decodeCursor(test.cursor, test.sortBy, test.order)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("decodeCursor(%q, %s, %s) = %v, expected an error", test.cursor, test.sortBy, test.order, got)
+			}
+			continue
+		}
+		if err != nil || got != key {
+			t.Errorf("decodeCursor(%q, %s, %s) = %v, %v, expected %v", test.cursor, test.sortBy, test.order, got, err, key)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	docs := scored(1, 5, 2, 3, 3, 5, 4, 1, 5, 3, 6, 2)
+	// Ordem por score decrescente e id: 1 3 2 5 6 4
+
+	tests := []struct {
+		name     string
+		req      SearchRequest
+		expected []int64
+		offset   int
+		next     bool
+	}{
+		{"first page", SearchRequest{Limit: 2}, []int64{1, 3}, 0, true},
+		{"offset", SearchRequest{Offset: 2, Limit: 2}, []int64{2, 5}, 2, true},
+		{"last page ends exactly at the end", SearchRequest{Offset: 4, Limit: 2}, []int64{6, 4}, 4, false},
+		{"last page shorter than the limit", SearchRequest{Offset: 5, Limit: 2}, []int64{4}, 5, false},
+		{"offset after the end", SearchRequest{Offset: 10, Limit: 2}, []int64{}, 6, false},
+		{"no limit", SearchRequest{Offset: 1}, []int64{3, 2, 5, 6, 4}, 1, false},
+		{"sort by id", SearchRequest{SortBy: "id", Order: "desc", Limit: 3}, []int64{6, 5, 4}, 0, true},
+	}
+
+	for _, test := range tests {
+		page, err := test.req.paginate(append([]invertedIndex.ScoredDocument{}, docs...))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := docIDs(page.Documents); !reflect.DeepEqual(got, test.expected) || page.Offset != test.offset || page.Total != len(docs) {
+			t.Errorf("%s: page = %v at %d of %d, expected %v at %d of %d", test.name, got, page.Offset, page.Total, test.expected, test.offset, len(docs))
+		}
+		if (page.Next != "") != test.next {
+			t.Errorf("%s: next = %q, expected a cursor: %t", test.name, page.Next, test.next)
+		}
+	}
+
+	for _, req := range []SearchRequest{{Offset: -1}, {SortBy: "nome"}, {Order: "up"}, {Cursor: "invalid"}} {
+		if _, err := req.paginate(docs); err == nil {
+			t.Errorf("paginate(%+v) returned no error", req)
+		}
+	}
+}
+
+func TestCursorStability(t *testing.T) {
+	docs := scored(1, 5, 2, 3, 3, 5, 4, 1, 5, 3, 6, 2)
+	page, err := SearchRequest{Limit: 3}.paginate(append([]invertedIndex.ScoredDocument{}, docs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := docIDs(page.Documents); !reflect.DeepEqual(got, []int64{1, 3, 2}) {
+		t.Fatalf("first page = %v, expected [1 3 2]", got)
+	}
+
+	// Entre as paginas o documento 1 (ja exibido) e o 5 (da proxima pagina) sao
+	// removidos, e sao inseridos documentos antes e depois do cursor
+	docs = scored(2, 3, 3, 5, 4, 1, 6, 2, 7, 9, 8, 3, 9, 0, 10, 3)
+
+	// As paginas seguintes continuam do documento 2 (score 3), sem repetir
+	// ou pular documentos que existiam, e passam pelos novos que ficaram
+	// depois dele
+	expected := [][]int64{{8, 10, 6}, {4, 9}}
+	req := SearchRequest{Limit: 3, Cursor: page.Next}
+	for i, want := range expected {
+		page, err = req.paginate(append([]invertedIndex.ScoredDocument{}, docs...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := docIDs(page.Documents); !reflect.DeepEqual(got, want) {
+			t.Errorf("page %d = %v, expected %v", i+2, got, want)
+		}
+		if page.Total != len(docs) {
+			t.Errorf("page %d total = %d, expected %d", i+2, page.Total, len(docs))
+		}
+		req.Cursor = page.Next
+	}
+	if page.Next != "" {
+		t.Errorf("last page has a next cursor %q", page.Next)
+	}
+	if page.Offset != len(docs)-2 {
+		t.Errorf("last page offset = %d, expected %d", page.Offset, len(docs)-2)
+	}
+}
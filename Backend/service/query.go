@@ -0,0 +1,998 @@
+// O arquivo query do pacote service implementa a linguagem de consulta booleana
+// da database. Uma consulta combina criterios sobre os campos textuais (indice
+// invertido) e numericos (arvores B+):
+//
+//	tipo:fire AND geracao:[1 TO 3] AND NOT lendario:true
+//	(nome:pikachu OR nome:raichu) atk:[80 TO *]
+//	descricao:"seed on its back" OR charmander
+//	fraco:electric AND resiste:fire resiste:water
+//
+// Gramatica:
+//
+//	expr    := and { "OR" and }
+//	and     := not { ["AND"] not }        // termos lado a lado sao unidos por AND
+//	not     := "NOT" not | primary
+//	primary := "(" expr ")" | campo ":" valor | valor
+//	valor   := palavra | "frase" | "[" inicio "TO" fim "]"
+//
+// Valores sem campo sao pesquisados em todos os campos textuais. Intervalos sao
+// inclusivos e aceitam '*' como limite aberto, datas no formato dd/mm/aaaa e
+// true/false nos campos booleanos.
+//
+// Os campos dano_<tipo> guardam o multiplicador do dano recebido de cada tipo
+// de ataque (dano_electric:[2 TO *]), e os atalhos fraco:, resiste: e imune:
+// pesquisam os multiplicadores de pelo menos 2, de no maximo 0.5 e 0.
+//
+// AND, OR e NOT sao avaliados como intersecao, uniao e diferenca dos documentos
+// encontrados, somando os scores de cada criterio.
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Bernardo46-2/AEDS-III/data/binManager"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/bplustree"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/ngram"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/suffixArray"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/ahoCorasick"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/bitap"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/boyerMoore"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/fuzzy"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/horspool"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/kmp"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/rabinKarp"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/regex"
+	"github.com/Bernardo46-2/AEDS-III/models"
+	"github.com/Bernardo46-2/AEDS-III/utils"
+)
+
+// queryNode é um no da arvore de uma consulta, que retorna os
+// documentos que o satisfazem e seus scores, ou o erro de um indice
+// que nao pode ser lido
+type queryNode interface {
+	eval(stats *SearchStats) (map[int64]float64, error)
+}
+
+// SearchStats conta os registros conferidos pelos metodos de pattern matching
+// (substring, Aho-Corasick e expressao regular) e o total de registros que
+// seriam percorridos sem o indice de trigramas, somados entre todos os
+// criterios da pesquisa
+type SearchStats struct {
+	Verified int64 `json:"verified"`
+	Total    int64 `json:"total"`
+
+	// Tempo, em nanossegundos, gasto pelo algoritmo da pesquisa para conferir
+	// os registros e, com compare, pelos demais algoritmos de substring para
+	// conferir os mesmos registros, para comparacao entre eles
+	Timings map[string]int64 `json:"timings"`
+
+	// Confere os registros tambem com os demais algoritmos de substring (ver
+	// SearchRequest.Compare)
+	compare bool
+
+	// Ocorrencias encontradas pelo pattern matching por documento e campo
+	matches map[int64]map[string][]Match
+}
+
+// substringEngine é um algoritmo de pattern matching exato, que retorna as
+// posicoes, em runas, de todas as ocorrencias do padrao no texto
+type substringEngine struct {
+	name string
+	find func(pattern string, text string) []int
+}
+
+// substringEngines relaciona os metodos de pesquisa por substring
+// (ver MergeSearch) com seus algoritmos
+var substringEngines = map[string]substringEngine{
+	"1": {"kmp", func(pattern string, text string) []int { return kmp.SearchString(text, pattern) }},
+	"2": {"rabinKarp", rabinKarp.RabinKarp},
+	"4": {"boyerMoore", boyerMoore.BoyerMoore},
+	"5": {"horspool", horspool.Horspool},
+}
+
+// addTiming soma o tempo gasto por um algoritmo de substring
+func (stats *SearchStats) addTiming(name string, elapsed time.Duration) {
+	if stats.Timings == nil {
+		stats.Timings = make(map[string]int64)
+	}
+	stats.Timings[name] += elapsed.Nanoseconds()
+}
+
+// addMatches guarda as ocorrencias de um padrao de 'length' runas
+// encontradas nas posicoes (em runas) fornecidas do campo de um documento
+func (stats *SearchStats) addMatches(id int64, field string, positions []int, length int) {
+	if stats.matches == nil {
+		stats.matches = make(map[int64]map[string][]Match)
+	}
+	if stats.matches[id] == nil {
+		stats.matches[id] = make(map[string][]Match)
+	}
+	for _, p := range positions {
+		stats.matches[id][field] = append(stats.matches[id][field], Match{Start: p, End: p + length})
+	}
+}
+
+// addMatch guarda uma ocorrencia de tamanho variavel, como as de uma
+// expressao regular, no campo de um documento. Ocorrencias vazias nao
+// possuem o que destacar e sao ignoradas
+func (stats *SearchStats) addMatch(id int64, field string, match Match) {
+	if match.End <= match.Start {
+		return
+	}
+	if stats.matches == nil {
+		stats.matches = make(map[int64]map[string][]Match)
+	}
+	if stats.matches[id] == nil {
+		stats.matches[id] = make(map[string][]Match)
+	}
+	stats.matches[id][field] = append(stats.matches[id][field], match)
+}
+
+// andNode é satisfeito pelos documentos presentes em todos os filhos
+type andNode struct {
+	children []queryNode
+}
+
+// orNode é satisfeito pelos documentos presentes em algum dos filhos
+type orNode struct {
+	children []queryNode
+}
+
+// notNode é satisfeito pelos documentos ausentes no filho
+type notNode struct {
+	child queryNode
+}
+
+// termNode pesquisa um texto em um campo textual
+type termNode struct {
+	field  string
+	text   string
+	method string  // Metodo de pesquisa (ver MergeSearch)
+	strict bool    // Exige todos os termos da pesquisa no indice invertido
+	boost  float64 // Peso do campo
+}
+
+// multiPatternNode pesquisa varios padroes em varios campos de uma vez com
+// um unico automato de Aho-Corasick
+type multiPatternNode struct {
+	patterns []fieldPattern
+}
+
+// fieldPattern é um padrao pesquisado em um campo
+type fieldPattern struct {
+	field   string
+	pattern string
+	boost   float64
+}
+
+// rangeNode pesquisa um intervalo [start, end] na arvore B+ de um campo
+type rangeNode struct {
+	field string
+	start float64
+	end   float64
+	boost float64
+}
+
+func (n andNode) eval(stats *SearchStats) (map[int64]float64, error) {
+	result, err := n.children[0].eval(stats)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range n.children[1:] {
+		if len(result) == 0 {
+			break
+		}
+		docs, err := child.eval(stats)
+		if err != nil {
+			return nil, err
+		}
+		for id := range result {
+			if score, ok := docs[id]; ok {
+				result[id] += score
+			} else {
+				delete(result, id)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (n orNode) eval(stats *SearchStats) (map[int64]float64, error) {
+	result := make(map[int64]float64)
+	for _, child := range n.children {
+		docs, err := child.eval(stats)
+		if err != nil {
+			return nil, err
+		}
+		for id, score := range docs {
+			result[id] += score
+		}
+	}
+	return result, nil
+}
+
+func (n notNode) eval(stats *SearchStats) (map[int64]float64, error) {
+	result, err := allDocuments()
+	if err != nil {
+		return nil, err
+	}
+	docs, err := n.child.eval(stats)
+	if err != nil {
+		return nil, err
+	}
+	for id := range docs {
+		delete(result, id)
+	}
+	return result, nil
+}
+
+func (n termNode) eval(stats *SearchStats) (map[int64]float64, error) {
+	var docs []invertedIndex.ScoredDocument
+	switch n.method {
+	case "1", "2", "4", "5": // KMP, Rabin Karp, Boyer-Moore e Horspool
+		docs = n.substring(stats)
+	case "3": // Fuzzy
+		docs = fuzzy.SearchPokemon(n.text, n.field)
+	case "7": // Expressao regular
+		docs = n.regex(stats)
+	case "8": // Array de sufixos
+		docs = n.suffixArray(stats)
+	case "9": // Bitap
+		docs = n.approximate(stats)
+	default:
+		if n.strict {
+			docs = invertedIndex.Match(binManager.FILES_PATH, n.field, n.text)
+		} else {
+			docs = invertedIndex.Read(binManager.FILES_PATH, n.field, strings.Fields(n.text)...)
+		}
+	}
+
+	result := make(map[int64]float64, len(docs))
+	for _, doc := range invertedIndex.Boost(docs, n.boost) {
+		result[doc.DocumentID] += doc.Score
+	}
+	return result, nil
+}
+
+// substring pesquisa o texto como substring do campo com o algoritmo do
+// metodo da pesquisa (ver substringEngines). Quando o campo possui indice de
+// trigramas, apenas os candidatos retornados pelo indice sao lidos e
+// conferidos, caso contrario toda a database é percorrida.
+//
+// Com stats.compare os registros lidos sao conferidos tambem pelos demais
+// algoritmos, apenas para medir o tempo de cada um. As posicoes das
+// ocorrencias sao guardadas em stats para o destaque dos resultados (ver
+// highlights)
+func (n termNode) substring(stats *SearchStats) []invertedIndex.ScoredDocument {
+	candidates, documents, filtered, err := ngram.Candidates(binManager.FILES_PATH, n.field, n.text)
+	ids, texts := fieldTexts(n.field, candidates, err == nil && filtered)
+	if err != nil || !filtered {
+		documents = int64(len(ids))
+	}
+	stats.Verified += int64(len(ids))
+	stats.Total += documents
+
+	length := utf8.RuneCountInString(n.text)
+	found := n.find(substringEngines[n.method], texts, stats)
+	docs := make([]invertedIndex.ScoredDocument, 0)
+	for i, positions := range found {
+		if len(positions) > 0 {
+			docs = append(docs, invertedIndex.ScoredDocument{DocumentID: ids[i], Score: float64(len(positions))})
+			stats.addMatches(ids[i], n.field, positions, length)
+		}
+	}
+
+	// Os demais algoritmos conferem os mesmos textos apenas para comparacao,
+	// e suas ocorrencias sao descartadas
+	if stats.compare {
+		for method, engine := range substringEngines {
+			if method != n.method {
+				n.find(engine, texts, stats)
+			}
+		}
+	}
+
+	return docs
+}
+
+// find pesquisa o texto em cada um dos textos com um algoritmo de substring,
+// somando o tempo gasto em stats
+func (n termNode) find(engine substringEngine, texts []string, stats *SearchStats) [][]int {
+	start := time.Now()
+	found := make([][]int, len(texts))
+	for i, text := range texts {
+		found[i] = engine.find(n.text, text)
+	}
+	stats.addTiming(engine.name, time.Since(start))
+	return found
+}
+
+// suffixArray pesquisa o texto como substring do campo no seu array de
+// sufixos, que retorna as ocorrencias sem ler nenhum registro. Campos sem
+// array de sufixos (ver SuffixArrayFields) sao pesquisados com o KMP
+func (n termNode) suffixArray(stats *SearchStats) []invertedIndex.ScoredDocument {
+	if !contains(SuffixArrayFields, n.field) {
+		n.method = "1"
+		return n.substring(stats)
+	}
+
+	start := time.Now()
+	occurrences, documents, err := suffixArray.Search(binManager.FILES_PATH, n.field, n.text)
+	stats.addTiming("suffixArray", time.Since(start))
+	docs := make([]invertedIndex.ScoredDocument, 0)
+	if err != nil {
+		return docs
+	}
+	stats.Total += documents
+
+	// As ocorrencias vem ordenadas por documento
+	length := utf8.RuneCountInString(n.text)
+	for _, o := range occurrences {
+		if last := len(docs) - 1; last >= 0 && docs[last].DocumentID == o.DocumentID {
+			docs[last].Score++
+		} else {
+			docs = append(docs, invertedIndex.ScoredDocument{DocumentID: o.DocumentID, Score: 1})
+		}
+		stats.addMatches(o.DocumentID, n.field, []int{o.Offset}, length)
+	}
+
+	return docs
+}
+
+// regex pesquisa o texto como expressao regular no campo. Quando todos os
+// literais obrigatorios da expressao (ver regex.Literals) possuem candidatos
+// no indice de trigramas, apenas a uniao dos candidatos é lida e conferida,
+// caso contrario toda a database é percorrida. O score de um documento é a
+// quantidade de ocorrencias, que sao guardadas em stats com suas capturas
+func (n termNode) regex(stats *SearchStats) []invertedIndex.ScoredDocument {
+	docs := make([]invertedIndex.ScoredDocument, 0)
+	re, err := regex.Compile(n.text)
+	if err != nil {
+		return docs
+	}
+
+	// Candidatos de todos os literais, dos quais ao menos um esta em
+	// toda ocorrencia da expressao
+	literals := re.Literals()
+	filtered := len(literals) > 0
+	documents := int64(0)
+	union := make([]int64, 0)
+	seen := make(map[int64]bool)
+	for _, literal := range literals {
+		ids, total, ok, err := ngram.Candidates(binManager.FILES_PATH, n.field, literal)
+		if err != nil || !ok {
+			filtered = false
+			break
+		}
+		documents = total
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				union = append(union, id)
+			}
+		}
+	}
+
+	ids, texts := fieldTexts(n.field, union, filtered)
+	if !filtered {
+		documents = int64(len(ids))
+	}
+	stats.Verified += int64(len(ids))
+	stats.Total += documents
+
+	start := time.Now()
+	for i, text := range texts {
+		found := re.FindAll(text)
+		if len(found) == 0 {
+			continue
+		}
+		docs = append(docs, invertedIndex.ScoredDocument{DocumentID: ids[i], Score: float64(len(found))})
+		for _, m := range found {
+			match := Match{Start: m.Start, End: m.End}
+			for _, g := range m.Groups {
+				match.Groups = append(match.Groups, Match{Start: g.Start, End: g.End})
+			}
+			stats.addMatch(ids[i], n.field, match)
+		}
+	}
+	stats.addTiming("regex", time.Since(start))
+
+	return docs
+}
+
+// approximate pesquisa o texto como substring do campo com o Bitap, com ate
+// bitap.MaxErrors erros. Toda ocorrencia contem sem erros uma das partes do
+// texto (ver bitap.Pieces), entao quando todas as partes possuem candidatos no
+// indice de trigramas apenas a uniao dos candidatos é lida e conferida, caso
+// contrario toda a database é percorrida. O score de um documento é a soma de
+// 1/(1 + erros) das suas ocorrencias (ver bitap.Score)
+func (n termNode) approximate(stats *SearchStats) []invertedIndex.ScoredDocument {
+	k := bitap.MaxErrors(n.text)
+	pieces := bitap.Pieces(n.text, k)
+	filtered := len(pieces) > 0
+	documents := int64(0)
+	union := make([]int64, 0)
+	seen := make(map[int64]bool)
+	for _, piece := range pieces {
+		ids, total, ok, err := ngram.Candidates(binManager.FILES_PATH, n.field, piece)
+		if err != nil || !ok {
+			filtered = false
+			break
+		}
+		documents = total
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				union = append(union, id)
+			}
+		}
+	}
+
+	ids, texts := fieldTexts(n.field, union, filtered)
+	if !filtered {
+		documents = int64(len(ids))
+	}
+	stats.Verified += int64(len(ids))
+	stats.Total += documents
+
+	start := time.Now()
+	docs := make([]invertedIndex.ScoredDocument, 0)
+	for i, text := range texts {
+		found := bitap.Bitap(n.text, text, k)
+		if len(found) == 0 {
+			continue
+		}
+		docs = append(docs, invertedIndex.ScoredDocument{DocumentID: ids[i], Score: bitap.Score(found)})
+		for _, m := range found {
+			stats.addMatch(ids[i], n.field, Match{Start: m.Start, End: m.End})
+		}
+	}
+	stats.addTiming("bitap", time.Since(start))
+
+	return docs
+}
+
+// fieldTexts retorna os ids e os textos de um campo dos registros a
+// conferir: os candidatos fornecidos quando filtered, ou todos os
+// registros da database
+func fieldTexts(field string, candidates []int64, filtered bool) (ids []int64, texts []string) {
+	ids = make([]int64, 0)
+	texts = make([]string, 0)
+
+	if filtered {
+		for _, pokemon := range readMany(candidates) {
+			ids = append(ids, int64(pokemon.Numero))
+			texts = append(texts, pokemon.GetField(field))
+		}
+		return ids, texts
+	}
+
+	controller, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	defer controller.Close()
+
+	for err := controller.ReadNext(); err == nil; err = controller.ReadNext() {
+		if !controller.RegistroAtual.IsDead() {
+			pokemon := controller.RegistroAtual.Pokemon
+			ids = append(ids, int64(pokemon.Numero))
+			texts = append(texts, pokemon.GetField(field))
+		}
+	}
+	return ids, texts
+}
+
+// validateRegex confere as expressoes regulares dos termos de uma
+// consulta, retornando o erro da primeira expressao invalida
+func validateRegex(node queryNode) error {
+	switch n := node.(type) {
+	case termNode:
+		if n.method == "7" {
+			if _, err := regex.Compile(n.text); err != nil {
+				return fmt.Errorf("invalid regular expression in '%s': %v", n.field, err)
+			}
+		}
+	case orNode:
+		for _, child := range n.children {
+			if err := validateRegex(child); err != nil {
+				return err
+			}
+		}
+	case andNode:
+		for _, child := range n.children {
+			if err := validateRegex(child); err != nil {
+				return err
+			}
+		}
+	case notNode:
+		return validateRegex(n.child)
+	}
+	return nil
+}
+
+// eval constroi o automato com todos os padroes e percorre cada registro uma
+// unica vez, pesquisando todos os padroes em todos os campos. O score de um
+// documento é a soma das ocorrencias de cada padrao multiplicadas pelo peso
+// do seu campo.
+//
+// Quando todos os padroes possuem candidatos no indice de trigramas, apenas
+// a uniao dos candidatos é lida, caso contrario toda a database é percorrida
+func (n multiPatternNode) eval(stats *SearchStats) (map[int64]float64, error) {
+	result := make(map[int64]float64)
+	if len(n.patterns) == 0 {
+		return result, nil
+	}
+
+	patterns := make([]string, len(n.patterns))
+	fields := make([]string, 0)
+	for i, p := range n.patterns {
+		patterns[i] = p.pattern
+		if !contains(fields, p.field) {
+			fields = append(fields, p.field)
+		}
+	}
+	automaton := ahoCorasick.New(patterns)
+
+	// Registros a percorrer
+	filtered := true
+	documents := int64(0)
+	union := make(map[int64]bool)
+	for _, p := range n.patterns {
+		ids, total, ok, err := ngram.Candidates(binManager.FILES_PATH, p.field, p.pattern)
+		if err != nil || !ok {
+			filtered = false
+			break
+		}
+		documents = total
+		for _, id := range ids {
+			union[id] = true
+		}
+	}
+
+	records := make([]models.Pokemon, 0)
+	if filtered {
+		ids := make([]int64, 0, len(union))
+		for id := range union {
+			ids = append(ids, id)
+		}
+		records = readMany(ids)
+	} else {
+		controller, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+		defer controller.Close()
+
+		for err := controller.ReadNext(); err == nil; err = controller.ReadNext() {
+			if !controller.RegistroAtual.IsDead() {
+				records = append(records, controller.RegistroAtual.Pokemon)
+			}
+		}
+		documents = int64(len(records))
+	}
+	stats.Verified += int64(len(records))
+	stats.Total += documents
+
+	// Cada campo de cada registro é percorrido uma unica vez pelo automato,
+	// e apenas as ocorrencias dos padroes daquele campo sao contadas
+	start := time.Now()
+	for _, pokemon := range records {
+		id := int64(pokemon.Numero)
+		for _, field := range fields {
+			text := pokemon.GetField(field)
+			for _, m := range automaton.Search(text) {
+				p := n.patterns[m.Pattern]
+				if p.field != field {
+					continue
+				}
+				result[id] += p.boost
+				stats.addMatches(id, field, []int{m.Position}, utf8.RuneCountInString(p.pattern))
+			}
+		}
+	}
+	stats.addTiming("ahoCorasick", time.Since(start))
+
+	return result, nil
+}
+
+// eval pesquisa o intervalo na arvore B+ do campo. Uma arvore ausente ou
+// gravada em outro formato (ver bplustree.ErrOutdatedHeader) retorna erro
+func (n rangeNode) eval(stats *SearchStats) (map[int64]float64, error) {
+	tree, err := bplustree.ReadBPlusTree(binManager.FILES_PATH, n.field)
+	if err != nil {
+		return nil, fmt.Errorf("index of '%s': %w", n.field, err)
+	}
+	defer tree.Close()
+
+	ids, err := tree.FindRange(n.start, n.end)
+	if err != nil {
+		return nil, fmt.Errorf("index of '%s': %w", n.field, err)
+	}
+	result := make(map[int64]float64, len(ids))
+	for _, id := range ids {
+		result[id] += n.boost
+	}
+	return result, nil
+}
+
+// allDocuments retorna todos os documentos da database com score 0,
+// atraves da arvore B+ de numeros
+func allDocuments() (map[int64]float64, error) {
+	return rangeNode{field: "numero", start: -math.MaxFloat64, end: math.MaxFloat64}.eval(nil)
+}
+
+// Query interpreta e executa uma consulta na linguagem booleana, retornando os
+// documentos encontrados ordenados por score, as facetas dos campos fornecidos
+// (ver FacetFields) e a duracao da pesquisa
+func Query(q string, facetFields ...string) (scDoc []invertedIndex.ScoredDocument, counts map[string][]FacetCount, duration int64, err error) {
+	root, err := parseQuery(q)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	start := time.Now()
+	if scDoc, err = runQuery(root, &SearchStats{}); err != nil {
+		return nil, nil, 0, err
+	}
+	counts, err = facets(scDoc, facetFields)
+	duration = time.Since(start).Milliseconds()
+
+	return
+}
+
+// runQuery avalia uma consulta e ordena os documentos por relevancia,
+// acumulando em stats os registros conferidos por pattern matching
+func runQuery(root queryNode, stats *SearchStats) ([]invertedIndex.ScoredDocument, error) {
+	docs, err := root.eval(stats)
+	if err != nil {
+		return nil, err
+	}
+	scDoc := make([]invertedIndex.ScoredDocument, 0, len(docs))
+	for id, score := range docs {
+		scDoc = append(scDoc, invertedIndex.ScoredDocument{DocumentID: id, Score: score})
+	}
+	return invertedIndex.Merge(scDoc), nil
+}
+
+// ===================================== Parser ====================================== //
+
+// Tipos de token da consulta
+const (
+	tokenWord = iota
+	tokenPhrase
+	tokenColon
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+)
+
+// queryToken é um token da consulta
+type queryToken struct {
+	kind int
+	text string
+}
+
+// queryParser é um parser descendente recursivo sobre os tokens da consulta
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// lexQuery separa a consulta em tokens
+func lexQuery(q string) ([]queryToken, error) {
+	tokens := make([]queryToken, 0)
+	symbols := map[rune]int{':': tokenColon, '(': tokenLParen, ')': tokenRParen, '[': tokenLBracket, ']': tokenRBracket}
+	runes := []rune(q)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated phrase at position %d", i)
+			}
+			tokens = append(tokens, queryToken{tokenPhrase, string(runes[i : end+1])})
+			i = end + 1
+		default:
+			if kind, ok := symbols[r]; ok {
+				tokens = append(tokens, queryToken{kind, string(r)})
+				i++
+				continue
+			}
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				if _, ok := symbols[runes[end]]; ok {
+					break
+				}
+				end++
+			}
+			tokens = append(tokens, queryToken{tokenWord, string(runes[i:end])})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// parseQuery transforma uma consulta em sua arvore de avaliacao
+func parseQuery(q string) (queryNode, error) {
+	tokens, err := lexQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s'", p.tokens[p.pos].text)
+	}
+
+	return root, nil
+}
+
+// peek retorna o proximo token sem consumi-lo
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// isKeyword verifica se o proximo token é a palavra reservada fornecida
+func (p *queryParser) isKeyword(keyword string) bool {
+	t, ok := p.peek()
+	return ok && t.kind == tokenWord && t.text == keyword
+}
+
+// expect consome o proximo token, que deve ser do tipo fornecido
+func (p *queryParser) expect(kind int, description string) (queryToken, error) {
+	t, ok := p.peek()
+	if !ok || t.kind != kind {
+		return t, fmt.Errorf("expected %s", description)
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []queryNode{node}
+	for p.isKeyword("OR") {
+		p.pos++
+		if node, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return orNode{children}, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	node, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []queryNode{node}
+	for {
+		if p.isKeyword("AND") {
+			p.pos++
+		} else if t, ok := p.peek(); !ok || t.kind == tokenRParen || p.isKeyword("OR") {
+			break
+		}
+		if node, err = p.parseNot(); err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return andNode{children}, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.isKeyword("NOT") {
+		p.pos++
+		child, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	switch t.kind {
+	case tokenLParen:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case tokenPhrase:
+		p.pos++
+		return anyTextField(t.text), nil
+
+	case tokenWord:
+		p.pos++
+		if next, ok := p.peek(); ok && next.kind == tokenColon {
+			p.pos++
+			return p.parseValue(t.text)
+		}
+		return anyTextField(t.text), nil
+	}
+
+	return nil, fmt.Errorf("unexpected '%s'", t.text)
+}
+
+// damageShortcuts relaciona os atalhos de efetividade da consulta ao
+// intervalo do multiplicador de dano recebido do tipo pesquisado. Pokemons
+// imunes (multiplicador 0) nao sao incluidos em resiste:
+var damageShortcuts = map[string][2]float64{
+	"fraco":   {2, math.MaxFloat64},
+	"resiste": {math.Nextafter(0, 1), 0.5},
+	"imune":   {0, 0},
+}
+
+// parseValue interpreta o valor de um criterio sobre um campo
+func (p *queryParser) parseValue(field string) (queryNode, error) {
+	if field == "id" {
+		field = "numero"
+	}
+	if bounds, ok := damageShortcuts[field]; ok {
+		t, err := p.expect(tokenWord, "type")
+		if err != nil {
+			return nil, err
+		}
+		if !models.IsType(t.text) {
+			return nil, fmt.Errorf("unknown type '%s'", t.text)
+		}
+		return rangeNode{field: models.DamageField(strings.ToLower(t.text)), start: bounds[0], end: bounds[1], boost: 1}, nil
+	}
+	textField := contains(models.PokeStrings(), field)
+	if !textField && !contains(models.PokeNumbers(), field) {
+		return nil, fmt.Errorf("unknown field '%s'", field)
+	}
+
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("expected value for '%s'", field)
+	}
+	p.pos++
+
+	switch {
+	case t.kind == tokenLBracket && !textField:
+		start, err := p.expect(tokenWord, "range start")
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("TO") {
+			return nil, fmt.Errorf("expected 'TO' in range of '%s'", field)
+		}
+		p.pos++
+		end, err := p.expect(tokenWord, "range end")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRBracket, "']'"); err != nil {
+			return nil, err
+		}
+		return numericRange(field, start.text, end.text)
+
+	case t.kind == tokenWord && !textField:
+		return numericRange(field, t.text, t.text)
+
+	case (t.kind == tokenWord || t.kind == tokenPhrase) && textField:
+		text := t.text
+		if field == "nomeJap" {
+			text = utils.ToKatakana(text)
+		}
+		return termNode{field: field, text: text, strict: true, boost: 1}, nil
+	}
+
+	return nil, fmt.Errorf("invalid value '%s' for '%s'", t.text, field)
+}
+
+// isLatin verifica se um texto contem letras do alfabeto romano
+func isLatin(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyTextField pesquisa um texto em todos os campos textuais
+func anyTextField(text string) queryNode {
+	node := orNode{}
+	for _, field := range models.PokeStrings() {
+		node.children = append(node.children, termNode{field: field, text: text, strict: true, boost: 1})
+	}
+	return node
+}
+
+// numericRange cria o criterio de um intervalo inclusivo em um campo numerico
+func numericRange(field string, start string, end string) (queryNode, error) {
+	s, err := parseBound(start, -math.MaxFloat64)
+	if err != nil {
+		return nil, err
+	}
+	e, err := parseBound(end, math.MaxFloat64)
+	if err != nil {
+		return nil, err
+	}
+
+	return rangeNode{field: field, start: s, end: e, boost: 1}, nil
+}
+
+// parseBound converte um limite de intervalo: '*' (aberto), true/false,
+// datas dd/mm/aaaa ou numeros
+func parseBound(value string, open float64) (float64, error) {
+	switch {
+	case value == "*":
+		return open, nil
+	case value == "true":
+		return 1, nil
+	case value == "false":
+		return 0, nil
+	case strings.Count(value, "/") == 2:
+		date, err := time.Parse("02/01/2006", value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid date '%s'", value)
+		}
+		return float64(date.Unix()), nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s'", value)
+	}
+	return f, nil
+}
+
+// contains verifica se uma lista de strings contem o valor
+func contains(list []string, value string) bool {
+	for _, s := range list {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
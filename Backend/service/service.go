@@ -5,6 +5,7 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"os"
@@ -21,8 +22,11 @@ import (
 	"github.com/Bernardo46-2/AEDS-III/data/indexes/btree"
 	"github.com/Bernardo46-2/AEDS-III/data/indexes/hashing"
 	"github.com/Bernardo46-2/AEDS-III/data/indexes/invertedIndex"
-	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/kmp"
-	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/rabinKarp"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/linearHashing"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/ngram"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/suffixArray"
+	"github.com/Bernardo46-2/AEDS-III/data/indexes/trie"
+	"github.com/Bernardo46-2/AEDS-III/data/patternMatching/fuzzy"
 	"github.com/Bernardo46-2/AEDS-III/models"
 	"github.com/Bernardo46-2/AEDS-III/utils"
 )
@@ -53,6 +57,84 @@ type SearchRequest struct {
 	Lendario     string `json:"lendario"`
 	Mitico       string `json:"mitico"`
 	PatternMatch string `json:"patternMatch"`
+
+	// Lingua do nome pesquisado: vazio ou "en" para o nome em ingles, ou uma
+	// das linguas de models.Languages, ex: "de" para pesquisar "Glumanda"
+	Lang string `json:"lang"`
+
+	// Peso de cada campo na pontuacao final (padrao 1), ex: {"nome": 2}
+	Boosts map[string]float64 `json:"boosts"`
+
+	// Paginacao e ordenacao do resultado (ver pagination.go)
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	SortBy string `json:"sortBy"` // "score" ou um campo numerico
+	Order  string `json:"order"`  // "asc" ou "desc"
+	Cursor string `json:"cursor"` // Cursor retornado pela pagina anterior
+
+	// Campos cujas facetas sao calculadas sobre o resultado (ver FacetFields)
+	Facets []string `json:"facets"`
+
+	// Nos metodos de substring, confere os registros tambem com os demais
+	// algoritmos para comparar o tempo de cada um (ver SearchStats.Timings)
+	Compare bool `json:"compare"`
+}
+
+// nameField retorna o campo do nome na lingua da pesquisa (ver
+// models.LocalizedField), ou erro se a lingua nao for suportada
+func (req SearchRequest) nameField() (string, error) {
+	switch {
+	case req.Lang == "" || req.Lang == "en":
+		return "nome", nil
+	case contains(models.Languages, req.Lang):
+		return models.LocalizedField(req.Lang), nil
+	}
+	return "", fmt.Errorf("unsupported language '%s'", req.Lang)
+}
+
+// boost retorna o peso de um campo na pesquisa
+func (req SearchRequest) boost(field string) float64 {
+	if weight, ok := req.Boosts[field]; ok {
+		return weight
+	}
+	return 1
+}
+
+// HashFields relaciona os campos que possuem indice hash de busca exata
+// com o nome da funcao hash utilizada (ver hashing.HashFunctions)
+var HashFields = map[string]string{
+	"nome":    "fnv",
+	"nomeJap": "fnv",
+	"especie": "fnv",
+}
+
+// SuggestFields sao os campos com trie de sugestoes (ver Suggest)
+var SuggestFields = []string{"nome", "especie", "tipo"}
+
+// NgramFields sao os campos com indice de trigramas, usado para filtrar os
+// candidatos das pesquisas com KMP e Rabin-Karp (ver MergeSearch)
+var NgramFields = []string{"nome", "nomeJap", "especie", "tipo", "descricao"}
+
+// SuffixArrayFields sao os campos com array de sufixos, usado nas pesquisas
+// de substring do metodo 8 (ver MergeSearch)
+var SuffixArrayFields = []string{"descricao"}
+
+// hashFieldNames retorna os nomes dos campos com indice hash
+func hashFieldNames() (fields []string) {
+	for field := range HashFields {
+		fields = append(fields, field)
+	}
+	return
+}
+
+// IndexesStats agrupa as estatisticas de todos os indices da database
+type IndexesStats struct {
+	Hash            hashing.HashStats                 `json:"hash"`
+	FieldHashes     map[string]hashing.FieldHashStats `json:"fieldHashes"`
+	LinearHash      linearHashing.Stats               `json:"linearHash"`
+	BTree           btree.Stats                       `json:"btree"`
+	BPlusTrees      map[string]bplustree.Stats        `json:"bplustrees"`
+	InvertedIndexes map[string]invertedIndex.Stats    `json:"invertedIndexes"`
 }
 
 // ReadPagesNumber retorna o numero de paginas disponiveis para a
@@ -63,7 +145,7 @@ func ReadPagesNumber() (numeroPaginas int, err error) {
 	numRegistros, _, _ := binManager.NumRegistros()
 
 	// calcula e retorna o total
-	numeroPaginas = int(math.Ceil((float64(numRegistros) / float64(60))))
+	numeroPaginas = int(math.Ceil((float64(numRegistros) / float64(PAGE_SIZE))))
 	return
 }
 
@@ -101,6 +183,7 @@ func GetIdList() (ids []int32, err error) {
 //	1 - Hash
 //	2 - Arvore B
 //	3 - Arvore B+
+//	4 - Hash Linear
 func GetList(idList []int64, method int) (pokeList []models.Pokemon, duration int64, err error) {
 	c, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
 	defer c.Close()
@@ -131,13 +214,66 @@ func GetList(idList []int64, method int) (pokeList []models.Pokemon, duration in
 		}
 		btree.Close()
 	case 3: // Arvore B+
-		bptreeeee, _ := bplustree.ReadBPlusTree(binManager.FILES_PATH, "id")
+		bptreeeee, err := bplustree.ReadBPlusTree(binManager.FILES_PATH, "id")
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, id := range idList {
+			keys := bptreeeee.Find(float64(id))
+			if len(keys) > 0 {
+				pokeList = append(pokeList, c.ReadTarget(keys[0].Ptr))
+			}
+		}
+		bptreeeee.Close()
+	case 4: // Hash Linear
+		hash, err := linearHashing.Load(binManager.FILES_PATH, "linearHashIndex")
+		if err != nil {
+			return nil, 0, err
+		}
 		for _, id := range idList {
-			pos := bptreeeee.Find(float64(id))
+			pos, err := hash.Read(id)
 			if err == nil {
-				pokeList = append(pokeList, c.ReadTarget(pos.Ptr))
+				pokeList = append(pokeList, c.ReadTarget(pos))
 			}
 		}
+		hash.Close()
+	}
+	duration = time.Since(start).Milliseconds()
+
+	return
+}
+
+// GetBy recupera todos os pokemons cujo campo possui exatamente o valor
+// fornecido (sem diferenciar maiusculas e minusculas), utilizando o indice
+// hash do campo ao inves de uma pesquisa sequencial.
+//
+// Os registros apontados pelo indice sao conferidos, descartando colisoes
+// da funcao hash. Tambem retorna a duracao da pesquisa em milissegundos
+func GetBy(field string, value string) (pokeList []models.Pokemon, duration int64, err error) {
+	if _, ok := HashFields[field]; !ok {
+		return nil, 0, fmt.Errorf("field '%s' has no hash index", field)
+	}
+
+	c, err := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	if err != nil {
+		return
+	}
+	defer c.Close()
+
+	start := time.Now()
+	index, err := hashing.LoadFieldHash(binManager.FILES_PATH, field)
+	if err != nil {
+		return
+	}
+	addresses := index.Read(value)
+	index.Close()
+
+	pokeList = make([]models.Pokemon, 0, len(addresses))
+	for _, address := range addresses {
+		pokemon := c.ReadTarget(address)
+		if pokemon.Numero != -1 && strings.EqualFold(strings.TrimSpace(pokemon.GetField(field)), strings.TrimSpace(value)) {
+			pokeList = append(pokeList, pokemon)
+		}
 	}
 	duration = time.Since(start).Milliseconds()
 
@@ -149,6 +285,9 @@ func GetList(idList []int64, method int) (pokeList []models.Pokemon, duration in
 // Recebe um modelo pokemon e serializa para inserir
 // Por fim retorna o ID do pokemon criado e erro se houver.
 //
+// Antes da insercao as restricoes de unicidade das arvores B+ sao
+// verificadas, gerando bplustree.ErrDuplicateKey em caso de violacao
+//
 // tambem realiza: HashCreate
 func Create(pokemon models.Pokemon) (int, error) {
 	// Recupera o ultimo ID para gerar o proximo
@@ -156,16 +295,36 @@ func Create(pokemon models.Pokemon) (int, error) {
 	ultimoID++
 	pokemon.Numero = ultimoID
 
+	// Restricoes de unicidade
+	if err := bplustree.CheckUnique(pokemon, binManager.FILES_PATH, bPlusTreeFields()); err != nil {
+		return 0, err
+	}
+
 	// Prepara, serializa e insere
+	if pokemon.Romaji == "" {
+		pokemon.Romaji = utils.ToRomaji(pokemon.NomeJap)
+	}
+	if pokemon.DanoRecebido == nil {
+		pokemon.DanoRecebido = models.DamageTaken(pokemon.Tipo)
+	}
 	pokemon.CalculateSize()
 	pokeBytes := pokemon.ToBytes()
 	address, err := binManager.AppendPokemon(pokeBytes)
+	if err != nil {
+		return 0, err
+	}
 
 	// Indice invertido
 	invertedIndex.Create(pokemon, binManager.FILES_PATH, models.PokeStrings()...)
+	fuzzy.BKTreeCreate(pokemon, binManager.FILES_PATH, models.PokeStrings()...)
+	trie.TrieCreate(pokemon, binManager.FILES_PATH, SuggestFields...)
+	ngram.NgramCreate(pokemon, binManager.FILES_PATH, NgramFields...)
+	suffixArray.SuffixArrayCreate(pokemon, binManager.FILES_PATH, SuffixArrayFields...)
 
 	// Tabela Hash
 	hashing.HashCreate(int64(pokemon.Numero), address, binManager.FILES_PATH, "hashIndex")
+	hashing.FieldHashCreate(pokemon, address, binManager.FILES_PATH, hashFieldNames()...)
+	linearHashing.LinearHashCreate(int64(pokemon.Numero), address, binManager.FILES_PATH, "linearHashIndex")
 
 	// Arvore B
 	bTree, _ := btree.ReadBTree(binManager.FILES_PATH)
@@ -173,8 +332,10 @@ func Create(pokemon models.Pokemon) (int, error) {
 	bTree.Close()
 
 	// Arvore B+
-	bplustree.Create(pokemon, address, binManager.FILES_PATH, []string{"id"})
-	bplustree.Create(pokemon, int64(pokemon.Numero), binManager.FILES_PATH, models.PokeNumbers())
+	if err = bplustree.Create(pokemon, address, binManager.FILES_PATH, []string{"id"}); err != nil {
+		return 0, err
+	}
+	err = bplustree.Create(pokemon, int64(pokemon.Numero), binManager.FILES_PATH, models.PokeNumbers())
 
 	return int(ultimoID), err
 }
@@ -187,12 +348,40 @@ func Read(id int) (models.Pokemon, error) {
 	return pokemon, err
 }
 
+// readMany lê os registros de varios ids abrindo a hash e o arquivo binário
+// uma unica vez, na ordem fornecida. Ids nao encontrados sao ignorados
+func readMany(ids []int64) []models.Pokemon {
+	pokemons := make([]models.Pokemon, 0, len(ids))
+	hash, err := hashing.Load(binManager.FILES_PATH, "hashIndex")
+	if err != nil {
+		return pokemons
+	}
+	defer hash.Close()
+	c, err := binManager.InicializarControleLeitura(binManager.BIN_FILE)
+	if err != nil {
+		return pokemons
+	}
+	defer c.Close()
+
+	for _, id := range ids {
+		if pos, err := hash.Read(id); err == nil {
+			if pokemon := c.ReadTarget(pos); pokemon.Numero != -1 {
+				pokemons = append(pokemons, pokemon)
+			}
+		}
+	}
+	return pokemons
+}
+
 // Update atualiza um registro no arquivo binário de acordo com o número do pokemon informado.
 // Recebe uma struct do tipo models.Pokemon a ser atualizada.
 // Retorna um erro caso ocorra algum problema ao atualizar o registro.
 //
 // O update é feito deletando um valor e adicionando outro ao final do arquivo.
 //
+// Assim como no Create, as restricoes de unicidade das arvores B+ sao
+// verificadas antes da atualizacao, gerando bplustree.ErrDuplicateKey
+//
 // tambem realiza: HashUpdate
 func Update(pokemon models.Pokemon) (err error) {
 	// Recupera a posição do id no arquivo
@@ -202,7 +391,25 @@ func Update(pokemon models.Pokemon) (err error) {
 	}
 	old := binManager.ReadTargetPokemon(pos)
 
-	// Serializa os dados
+	// Restricoes de unicidade
+	if err = bplustree.CheckUniqueUpdate(old, pokemon, binManager.FILES_PATH, bPlusTreeFields()); err != nil {
+		return
+	}
+
+	// Serializa os dados, mantendo o romaji do registro antigo quando o
+	// nome japones nao mudou
+	if pokemon.Romaji == "" && pokemon.NomeJap == old.NomeJap {
+		pokemon.Romaji = old.Romaji
+	} else if pokemon.Romaji == "" {
+		pokemon.Romaji = utils.ToRomaji(pokemon.NomeJap)
+	}
+	// Os multiplicadores de dano sao mantidos enquanto os tipos nao mudam
+	sameTypes := strings.TrimRight(pokemon.GetField("tipo"), ",") == strings.TrimRight(old.GetField("tipo"), ",")
+	if pokemon.DanoRecebido == nil && sameTypes {
+		pokemon.DanoRecebido = old.DanoRecebido
+	} else if pokemon.DanoRecebido == nil {
+		pokemon.DanoRecebido = models.DamageTaken(pokemon.Tipo)
+	}
 	pokemon.CalculateSize()
 	pokeBytes := pokemon.ToBytes()
 
@@ -219,9 +426,15 @@ func Update(pokemon models.Pokemon) (err error) {
 
 	// Indice invertido
 	invertedIndex.Update(pokemon, binManager.FILES_PATH, models.PokeStrings()...)
+	fuzzy.BKTreeUpdate(old, pokemon, binManager.FILES_PATH, models.PokeStrings()...)
+	trie.TrieUpdate(old, pokemon, binManager.FILES_PATH, SuggestFields...)
+	ngram.NgramUpdate(old, pokemon, binManager.FILES_PATH, NgramFields...)
+	suffixArray.SuffixArrayUpdate(old, pokemon, binManager.FILES_PATH, SuffixArrayFields...)
 
 	// Tabela Hash
 	err = hashing.HashUpdate(int64(pokemon.Numero), newAddress, binManager.FILES_PATH, "hashIndex")
+	hashing.FieldHashUpdate(old, pokemon, pos, newAddress, binManager.FILES_PATH, hashFieldNames()...)
+	linearHashing.LinearHashUpdate(int64(pokemon.Numero), newAddress, binManager.FILES_PATH, "linearHashIndex")
 
 	// Arvore B
 	btree, _ := btree.ReadBTree(binManager.FILES_PATH)
@@ -229,8 +442,10 @@ func Update(pokemon models.Pokemon) (err error) {
 	btree.Close()
 
 	// Arvore B+
-	bplustree.Update(old, pokemon, pos, newAddress, binManager.FILES_PATH, []string{"id"})
-	bplustree.Update(old, pokemon, int64(old.Numero), int64(pokemon.Numero), binManager.FILES_PATH, models.PokeNumbers())
+	if err = bplustree.Update(old, pokemon, pos, newAddress, binManager.FILES_PATH, []string{"id"}); err != nil {
+		return
+	}
+	err = bplustree.Update(old, pokemon, int64(old.Numero), int64(pokemon.Numero), binManager.FILES_PATH, models.PokeNumbers())
 
 	return
 }
@@ -254,9 +469,15 @@ func Delete(id int) (pokemon models.Pokemon, err error) {
 
 	// Indice invertido
 	invertedIndex.Delete(pokemon, binManager.FILES_PATH, models.PokeStrings()...)
+	fuzzy.BKTreeDelete(pokemon, binManager.FILES_PATH, models.PokeStrings()...)
+	trie.TrieDelete(pokemon, binManager.FILES_PATH, SuggestFields...)
+	ngram.NgramDelete(pokemon, binManager.FILES_PATH, NgramFields...)
+	suffixArray.SuffixArrayDelete(pokemon, binManager.FILES_PATH, SuffixArrayFields...)
 
 	// Tabela Hash
 	hashing.HashDelete(int64(pokemon.Numero), binManager.FILES_PATH, "hashIndex")
+	hashing.FieldHashDelete(pokemon, pos, binManager.FILES_PATH, hashFieldNames()...)
+	linearHashing.LinearHashDelete(int64(pokemon.Numero), binManager.FILES_PATH, "linearHashIndex")
 
 	// Arvore B
 	btree, err := btree.ReadBTree(binManager.FILES_PATH)
@@ -277,86 +498,221 @@ func Delete(id int) (pokemon models.Pokemon, err error) {
 // atraves do metodo de pattern matching selecionado.
 //
 // Todos os campos fornecidos serao pesquisados e retornados no formato de um
-// scored document, para entao serem ordenados, os ids serao extraidos dos documentos
-// para por fim serem retornados. A duracao do tempo de pesquisa também é realizado
+// scored document. O score de cada campo é multiplicado pelo seu peso em
+// req.Boosts e os documentos sao entao somados e ordenados por relevancia.
+// A duracao do tempo de pesquisa também é retornada
+//
+// A requisicao é uma forma simplificada da linguagem de consulta (ver Query):
+// cada campo preenchido vira um criterio e os criterios sao unidos por OR.
+//
+// O resultado é ordenado por req.SortBy e apenas a pagina pedida por
+// req.Offset ou req.Cursor, com ate req.Limit documentos, é retornada,
+// junto do total de documentos encontrados e do cursor da proxima pagina.
+// As facetas dos campos de req.Facets sao calculadas sobre todos os
+// documentos encontrados, e nao apenas sobre a pagina. Nos metodos de pattern
+// matching a pagina traz tambem as ocorrencias encontradas em cada campo e um
+// trecho destacado da descricao (ver highlight.go)
 //
 // Para fins de melhoria indice invertido esta inserido junto de pattern matching
 // por realizarem coisas relativamente parecidas. Os metodos de pattern matching
-// sao feitos atraves de uma pesquisa extensa e direta sobre os campos de todos os
-// pokemons sequencialmente, diferente da lista invertida que funciona como hash
+// conferem apenas os pokemons candidatos do indice de trigramas do campo (ver
+// NgramFields), e percorrem toda a database quando o campo nao possui indice
+// ou o texto tem menos de 3 caracteres. A quantidade de registros conferidos e
+// o total de registros sao retornados em stats
+//
+// No indice invertido os campos aceitam frases entre aspas e clausulas NEAR/n
+// ("seed on its back", flame NEAR/2 tail), que ficam a frente dos documentos
+// que contem apenas as palavras soltas
 //
 // Metodos suportados:
 //
 //	0 - Indice invertido
 //	1 - KMP
 //	2 - Rabin Karp
-func MergeSearch(req SearchRequest) (idList []int64, duration int64, err error) {
-	// Lambda para direcionamento de pesquisa atraves do campo
-	getFieldScDoc := func(field, text string) []invertedIndex.ScoredDocument {
-		switch req.PatternMatch {
-		case "1": // KMP
-			return kmp.SearchPokemon(text, field)
-		case "2": // Rabin Karp
-			return rabinKarp.SearchPokemon(text, field)
-		default:
-			return invertedIndex.Read(binManager.FILES_PATH, field, strings.Fields(text)...)
-		}
+//	3 - Fuzzy (distancia de edicao sobre o dicionario do indice invertido)
+//	4 - Boyer-Moore
+//	5 - Boyer-Moore-Horspool
+//	6 - Aho-Corasick (todas as palavras de todos os campos em uma unica passada)
+//	7 - Expressao regular (ver regex.Compile), com as capturas de cada ocorrencia
+//	8 - Array de sufixos (campos de SuffixArrayFields, os demais usam o KMP)
+//	9 - Bitap (substring com ate bitap.MaxErrors erros, as mais proximas primeiro)
+//
+// Nos metodos de substring (1, 2, 4 e 5) o tempo do algoritmo escolhido é
+// retornado em stats.Timings. Com req.Compare os registros conferidos sao
+// pesquisados tambem pelos demais algoritmos, e o tempo de cada um é
+// retornado junto para comparacao. No metodo 7 uma expressao invalida
+// retorna erro
+//
+// O nome é pesquisado na lingua de req.Lang, em ingles por padrao, e uma
+// lingua nao suportada retorna erro
+func MergeSearch(req SearchRequest) (page SearchPage, duration int64, stats SearchStats, err error) {
+	start := time.Now()
+	if _, err = req.nameField(); err != nil {
+		return page, 0, stats, err
 	}
+	root := req.query()
+	if err = validateRegex(root); err != nil {
+		return page, 0, stats, err
+	}
+	stats.compare = req.Compare
+	docs, err := runQuery(root, &stats)
+	if err != nil {
+		return page, 0, stats, err
+	}
+	counts, err := facets(docs, req.Facets)
+	if err != nil {
+		return page, 0, stats, err
+	}
+	page, err = req.paginate(docs)
+	page.Facets = counts
+	page.Highlights = highlights(page.Documents, &stats)
+	duration = time.Since(start).Milliseconds()
 
-	// Lambda para a recuperacao do range de valores dentro da arvore B+
-	getIdsBPTree := func(start string, end string, field string) []invertedIndex.ScoredDocument {
-		tree, _ := bplustree.ReadBPlusTree(binManager.FILES_PATH, field)
-		defer tree.Close()
-		startf64, _ := strconv.ParseFloat(start, 64)
-		endf64, _ := strconv.ParseFloat(end, 64)
-		result, _ := tree.FindRange(startf64, endf64)
-		docs := make([]invertedIndex.ScoredDocument, len(result))
-		for i, id := range result {
-			docs[i] = invertedIndex.ScoredDocument{DocumentID: id, Score: 1}
+	return
+}
+
+// query converte os campos preenchidos da requisicao em uma consulta
+// que une todos os criterios
+func (req SearchRequest) query() queryNode {
+	root := orNode{}
+	name, _ := req.nameField()
+
+	// Campos em formato de string
+	texts := []struct{ field, text string }{
+		{name, req.Nome},
+		{"especie", req.Especie},
+		{"tipo", req.Tipo},
+		{"descricao", req.Descricao},
+		{"nomeJap", utils.ToKatakana(req.JapName)},
+	}
+	// A conversao para katakana removeria os operadores da expressao regular
+	if req.PatternMatch == "7" {
+		texts[len(texts)-1].text = req.JapName
+	}
+	// O nome japones aceita romaji, katakana ou hiragana, e é convertido para
+	// katakana acima. Em romaji ele é pesquisado tambem na grafia do CSV
+	// ("Lizardon"), que nem sempre segue o Hepburn
+	if isLatin(req.JapName) {
+		texts = append(texts, struct{ field, text string }{"romaji", req.JapName})
+	}
+	multi := multiPatternNode{}
+	for _, t := range texts {
+		if strings.TrimSpace(t.text) == "" {
+			continue
+		}
+		// No Aho-Corasick cada palavra de cada campo vira um padrao do automato
+		if req.PatternMatch == "6" {
+			for _, word := range strings.Fields(t.text) {
+				multi.patterns = append(multi.patterns, fieldPattern{field: t.field, pattern: word, boost: req.boost(t.field)})
+			}
+			continue
 		}
-		return docs
+		root.children = append(root.children, termNode{field: t.field, text: t.text, method: req.PatternMatch, boost: req.boost(t.field)})
+	}
+	if len(multi.patterns) > 0 {
+		root.children = append(root.children, multi)
 	}
 
-	// Parsing de valores nao formatados
-	req.LancamentoI = utils.FormatDate(req.LancamentoI)
-	req.LancamentoF = utils.FormatDate(req.LancamentoF)
-	req.JapName = utils.ToKatakana(req.JapName)
-
-	// Pesquisa de campos em formato de string com contagem de tempo de execucao
-	start := time.Now()
-	nomeScDoc := getFieldScDoc("nome", req.Nome)
-	especieScDoc := getFieldScDoc("especie", req.Especie)
-	tipoScDoc := getFieldScDoc("tipo", req.Tipo)
-	descricaoScDoc := getFieldScDoc("descricao", req.Descricao)
-	japNameScDoc := getFieldScDoc("nomeJap", req.JapName)
-	duration = time.Since(start).Milliseconds()
+	// Campos numericos, pesquisados na arvore B+
+	ranges := []struct{ field, start, end string }{
+		{"numero", req.IDI, req.IDF},
+		{"geracao", req.GeracaoI, req.GeracaoF},
+		{"lancamento", req.LancamentoI, req.LancamentoF},
+		{"atk", req.AtkI, req.AtkF},
+		{"def", req.DefI, req.DefF},
+		{"hp", req.HpI, req.HpF},
+		{"altura", req.AlturaI, req.AlturaF},
+		{"peso", req.PesoI, req.PesoF},
+	}
+	for _, r := range ranges {
+		if r.start == "" || r.end == "" {
+			continue
+		}
+		if r.field == "lancamento" {
+			r.start, r.end = utils.FormatDate(r.start), utils.FormatDate(r.end)
+		}
+		start, _ := strconv.ParseFloat(r.start, 64)
+		end, _ := strconv.ParseFloat(r.end, 64)
+		root.children = append(root.children, rangeNode{field: r.field, start: start, end: end, boost: req.boost(r.field)})
+	}
 
-	// Pesquisa em campos numericos
-	ID := getIdsBPTree(req.IDI, req.IDF, "numero")
-	Geracao := getIdsBPTree(req.GeracaoI, req.GeracaoF, "geracao")
-	Lancamento := getIdsBPTree(req.LancamentoI, req.LancamentoF, "lancamento")
-	Atk := getIdsBPTree(req.AtkI, req.AtkF, "atk")
-	Def := getIdsBPTree(req.DefI, req.DefF, "def")
-	Hp := getIdsBPTree(req.HpI, req.HpF, "hp")
-	Altura := getIdsBPTree(req.AlturaI, req.AlturaF, "altura")
-	Peso := getIdsBPTree(req.PesoI, req.PesoF, "peso")
-
-	// Pesquisa em campos booleanos
-	var Lendario []invertedIndex.ScoredDocument
-	var Mitico []invertedIndex.ScoredDocument
+	// Campos booleanos
 	if req.Lendario == "1" {
-		Lendario = getIdsBPTree("1", "2", "lendario")
+		root.children = append(root.children, rangeNode{field: "lendario", start: 1, end: 2, boost: req.boost("lendario")})
 	}
 	if req.Mitico == "1" {
-		Mitico = getIdsBPTree("1", "2", "mitico")
+		root.children = append(root.children, rangeNode{field: "mitico", start: 1, end: 2, boost: req.boost("mitico")})
 	}
 
-	// Ordenacao dos scored documents de acordo com incidencia
-	scDoc := invertedIndex.Merge(nomeScDoc, especieScDoc, tipoScDoc, descricaoScDoc, japNameScDoc, ID, Geracao, Lancamento, Atk, Def, Hp, Altura, Peso, Lendario, Mitico)
+	return root
+}
+
+// FuzzySearch retorna os termos do dicionario do indice invertido de um campo
+// proximos das palavras do texto, com suas distancias de edicao. Uma distancia
+// negativa utiliza a tolerancia padrao de cada palavra (ver fuzzy.MaxDistance)
+func FuzzySearch(field string, text string, distance int) (candidates []fuzzy.Candidate, duration int64, err error) {
+	start := time.Now()
+	candidates, err = fuzzy.Search(binManager.FILES_PATH, field, text, distance)
+	duration = time.Since(start).Milliseconds()
+
+	return
+}
+
+// Suggest retorna ate 'limit' palavras de um campo que comecam com o prefixo,
+// ordenadas pela quantidade de pokemons que as possuem, e a duracao da pesquisa
+// em microssegundos
+func Suggest(field string, prefix string, limit int) (suggestions []trie.Suggestion, duration int64, err error) {
+	start := time.Now()
+	suggestions, err = trie.Suggest(binManager.FILES_PATH, field, prefix, limit)
+	duration = time.Since(start).Microseconds()
+
+	return
+}
 
-	// Conversao dos documentos em uma lista de ids
-	for _, tmp := range scDoc {
-		idList = append(idList, tmp.DocumentID)
+// bPlusTreeFields retorna os campos indexados por arvores B+, incluindo
+// a arvore "id" que aponta para o endereco do registro
+func bPlusTreeFields() []string {
+	return append([]string{"id"}, models.PokeNumbers()...)
+}
+
+// IndexStats coleta as estatisticas de todos os indices: tamanho dos arquivos,
+// altura e preenchimento das arvores, ocupacao dos buckets das hashes e o
+// vocabulario dos indices invertidos, com seus 'top' termos mais frequentes.
+//
+// Indices que nao puderem ser abertos sao omitidos
+func IndexStats(top int) (stats IndexesStats) {
+	stats.FieldHashes = make(map[string]hashing.FieldHashStats)
+	stats.BPlusTrees = make(map[string]bplustree.Stats)
+	stats.InvertedIndexes = make(map[string]invertedIndex.Stats)
+
+	if hash, err := hashing.Load(binManager.FILES_PATH, "hashIndex"); err == nil {
+		stats.Hash = hash.Stats()
+		hash.Close()
+	}
+	for _, field := range hashFieldNames() {
+		if fh, err := hashing.LoadFieldHash(binManager.FILES_PATH, field); err == nil {
+			stats.FieldHashes[field] = fh.Stats()
+			fh.Close()
+		}
+	}
+	if hash, err := linearHashing.Load(binManager.FILES_PATH, "linearHashIndex"); err == nil {
+		stats.LinearHash = hash.Stats()
+		hash.Close()
+	}
+	if tree, err := btree.ReadBTree(binManager.FILES_PATH); err == nil {
+		stats.BTree = tree.Stats()
+		tree.Close()
+	}
+	for _, field := range bPlusTreeFields() {
+		if tree, err := bplustree.ReadBPlusTree(binManager.FILES_PATH, field); err == nil {
+			stats.BPlusTrees[field] = tree.Stats()
+			tree.Close()
+		}
+	}
+	for _, field := range models.PokeStrings() {
+		if ii, err := invertedIndex.ReadStats(binManager.FILES_PATH, field, top); err == nil {
+			stats.InvertedIndexes[field] = ii
+		}
 	}
 
 	return
@@ -496,9 +852,15 @@ func Unzip(method int) {
 // Indexacoes geradas:
 //
 //	Hash (id)
+//	Hash (campos de HashFields)
+//	Hash Linear (id)
 //	Arvore B (id)
 //	Arvore B+ (numericos)
 //	Indice Invertido (textuais)
+//	BK-tree (textuais)
+//	Trie (campos de SuggestFields)
+//	Trigramas (campos de NgramFields)
+//	Array de sufixos (campos de SuffixArrayFields)
 func ReconstruirIndices() {
 	// controler de leitura do arquivo binario
 	controler, _ := binManager.InicializarControleLeitura(binManager.BIN_FILE)
@@ -506,43 +868,85 @@ func ReconstruirIndices() {
 
 	// Hashing
 	hashing.StartHashFile(controler, 8, binManager.FILES_PATH, "hashIndex")
+	for field, hashName := range HashFields {
+		controler.Reset()
+		hashing.StartFieldHashFile(controler, 8, binManager.FILES_PATH, field, hashName)
+	}
+
+	// Hash Linear
+	controler.Reset()
+	linearHashing.StartLinearHashFile(controler, 8, binManager.FILES_PATH, "linearHashIndex")
 
 	// Arvore B
 	btree.StartBTreeFile(binManager.FILES_PATH)
 
 	// Indice Invertido
 	controler.Reset()
-	invertedIndex.New(controler, "nome", binManager.FILES_PATH, 0)
+	invertedIndex.New(controler, "nome", binManager.FILES_PATH, 0, "standard")
 	controler.Reset()
-	invertedIndex.New(controler, "nomeJap", binManager.FILES_PATH, 0)
+	invertedIndex.New(controler, "nomeJap", binManager.FILES_PATH, 0, "japanese")
+	controler.Reset()
+	invertedIndex.New(controler, "romaji", binManager.FILES_PATH, 0, "standard")
+	for _, lang := range models.Languages {
+		controler.Reset()
+		invertedIndex.New(controler, models.LocalizedField(lang), binManager.FILES_PATH, 0, "standard")
+	}
 	controler.Reset()
-	invertedIndex.New(controler, "especie", binManager.FILES_PATH, 0.8)
+	invertedIndex.New(controler, "especie", binManager.FILES_PATH, 0.8, "english")
 	controler.Reset()
-	invertedIndex.New(controler, "tipo", binManager.FILES_PATH, 0)
+	invertedIndex.New(controler, "tipo", binManager.FILES_PATH, 0, "standard")
 	controler.Reset()
-	invertedIndex.New(controler, "descricao", binManager.FILES_PATH, 0.8)
+	invertedIndex.New(controler, "descricao", binManager.FILES_PATH, 0.8, "english")
+
+	// BK-tree da pesquisa aproximada
+	for _, field := range models.PokeStrings() {
+		controler.Reset()
+		fuzzy.StartBKTreeFile(controler, binManager.FILES_PATH, field)
+	}
+
+	// Trie de sugestoes
+	for _, field := range SuggestFields {
+		controler.Reset()
+		trie.StartTrieFile(controler, binManager.FILES_PATH, field)
+	}
+
+	// Indice de trigramas
+	for _, field := range NgramFields {
+		controler.Reset()
+		ngram.StartNgramFile(controler, binManager.FILES_PATH, field)
+	}
+
+	// Array de sufixos
+	for _, field := range SuffixArrayFields {
+		controler.Reset()
+		suffixArray.StartSuffixArrayFile(controler, binManager.FILES_PATH, field)
+	}
 
 	// B+ Tree
 	controler.Reset()
-	bplustree.StartBPlusTreeFilesSearch(binManager.FILES_PATH, "id", controler)
+	bplustree.StartBPlusTreeFilesSearch(binManager.FILES_PATH, "id", bplustree.UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "numero", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "numero", bplustree.UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "geracao", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "geracao", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "atk", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "atk", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "def", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "def", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "hp", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "hp", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "altura", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "altura", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "peso", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "peso", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "lancamento", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "lancamento", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "lendario", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "lendario", bplustree.NON_UNIQUE, controler)
 	controler.Reset()
-	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "mitico", controler)
+	bplustree.StartBPlusTreeFile(binManager.FILES_PATH, "mitico", bplustree.NON_UNIQUE, controler)
+	for _, attacker := range models.Types {
+		controler.Reset()
+		bplustree.StartBPlusTreeFile(binManager.FILES_PATH, models.DamageField(attacker), bplustree.NON_UNIQUE, controler)
+	}
 }
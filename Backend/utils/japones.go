@@ -1,6 +1,7 @@
 // o arquivo Japones do pacote Utils permite a conversão de palavras em alfabeto
 // romanico em tradução literal para o sistema de simbolos estrangeiros usado no japao
-// conhecido como 'katakana'
+// conhecido como 'katakana', e o caminho inverso: de katakana ou hiragana para o
+// alfabeto romanico no padrao Hepburn (romaji)
 package utils
 
 import (
@@ -31,6 +32,17 @@ var preKatakana = strings.NewReplacer(
 	"XE", "ェ",
 	"XO", "ォ",
 
+	"Ā", "A-", // Vogais longas do Hepburn (macron) e do Nihon-shiki (circunflexo) viram
+	"Ī", "I-", // a vogal seguida da marca de prolongamento
+	"Ū", "U-",
+	"Ē", "E-",
+	"Ō", "O-",
+	"Â", "A-",
+	"Î", "I-",
+	"Û", "U-",
+	"Ê", "E-",
+	"Ô", "O-",
+
 	"BB", "ッB", // Pre-convert double-consonants into っconsonant pairs for step 2 replacement.
 	"TC", "ッC",
 	"CC", "ッC",
@@ -258,13 +270,154 @@ var postKanaSpecial = strings.NewReplacer(
 	"'", "", // strip out single quotes used to designated moriac n's.
 )
 
-// removeNonJapaneseChards remove todos os caracteres não asiaticos de uma string
+// removeNonJapaneseChards remove todos os caracteres não asiaticos de uma string.
+// A marca de prolongamento 'ー' é mantida, ja que pertence ao conjunto comum do
+// unicode e nao ao katakana
 func removeNonJapaneseChars(s string) string {
 	var result []rune
 	for _, r := range s {
-		if unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) || r == 'ー' {
 			result = append(result, r)
 		}
 	}
 	return string(result)
 }
+
+// KatakanaToHiragana troca um caractere katakana pelo hiragana equivalente.
+// Caracteres sem equivalente, como 'ヷ' e a marca de prolongamento, sao
+// mantidos
+func KatakanaToHiragana(r rune) rune {
+	if (r >= 'ァ' && r <= 'ヶ') || (r >= 'ヽ' && r <= 'ヾ') {
+		return r - 0x60
+	}
+	return r
+}
+
+// ToHiragana converte um texto em romaji, katakana ou hiragana para hiragana
+func ToHiragana(s string) string {
+	return strings.Map(KatakanaToHiragana, ToKatakana(s))
+}
+
+// ToRomaji converte um texto em katakana ou hiragana para romaji no padrao
+// Hepburn: "フシギダネ" -> "fushigidane", "リザードン" -> "rizādon".
+//
+// As silabas com 'ャ', 'ュ', 'ョ' e as vogais pequenas sao convertidas junto
+// com o kana anterior ("シャ" -> "sha", "ファ" -> "fa"), o 'ッ' dobra a
+// consoante seguinte ("ッチ" -> "tchi"), o 'ー' vira o macron da vogal
+// anterior e o 'ン' é seguido de apostrofo antes de vogais e de 'y'
+// ("ン|ア" -> "n'a"). Os demais caracteres sao mantidos
+func ToRomaji(s string) string {
+	runes := []rune(strings.Map(HiraganaToKatakana, s))
+	var sb strings.Builder
+	geminate := false
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		// Silabas de dois kana tem prioridade sobre os kana isolados
+		romaji, ok := "", false
+		if i+1 < len(runes) {
+			romaji, ok = kanaDigraphs[string(runes[i:i+2])]
+			if ok {
+				i++
+			}
+		}
+		if !ok {
+			romaji, ok = kanaRomaji[r]
+		}
+
+		switch {
+		case r == 'ッ' && !ok:
+			geminate = true
+			continue
+		case r == 'ー':
+			long := lengthen(sb.String())
+			sb.Reset()
+			sb.WriteString(long)
+			continue
+		case !ok:
+			sb.WriteRune(r)
+			geminate = false
+			continue
+		}
+
+		if geminate && !strings.ContainsAny(romaji[:1], "aiueon") {
+			if strings.HasPrefix(romaji, "ch") {
+				sb.WriteByte('t')
+			} else {
+				sb.WriteByte(romaji[0])
+			}
+		}
+		geminate = false
+		sb.WriteString(romaji)
+
+		// ン antes de vogal ou de 'y' é separado por apostrofo
+		if r == 'ン' && i+1 < len(runes) {
+			if next, ok := kanaRomaji[HiraganaToKatakana(runes[i+1])]; ok && strings.ContainsAny(next[:1], "aiueoy") {
+				sb.WriteByte('\'')
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// lengthen retorna o texto com a ultima vogal trocada pela vogal longa
+// (macron), para a marca de prolongamento 'ー'. O texto é retornado sem
+// alteracoes quando nao termina em vogal
+func lengthen(s string) string {
+	long := map[rune]rune{'a': 'ā', 'i': 'ī', 'u': 'ū', 'e': 'ē', 'o': 'ō'}
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	if l, ok := long[runes[len(runes)-1]]; ok {
+		runes[len(runes)-1] = l
+	}
+	return string(runes)
+}
+
+// kanaRomaji relaciona cada katakana ao seu romaji no padrao Hepburn
+var kanaRomaji = map[rune]string{
+	'ア': "a", 'イ': "i", 'ウ': "u", 'エ': "e", 'オ': "o",
+	'カ': "ka", 'キ': "ki", 'ク': "ku", 'ケ': "ke", 'コ': "ko",
+	'ガ': "ga", 'ギ': "gi", 'グ': "gu", 'ゲ': "ge", 'ゴ': "go",
+	'サ': "sa", 'シ': "shi", 'ス': "su", 'セ': "se", 'ソ': "so",
+	'ザ': "za", 'ジ': "ji", 'ズ': "zu", 'ゼ': "ze", 'ゾ': "zo",
+	'タ': "ta", 'チ': "chi", 'ツ': "tsu", 'テ': "te", 'ト': "to",
+	'ダ': "da", 'ヂ': "ji", 'ヅ': "zu", 'デ': "de", 'ド': "do",
+	'ナ': "na", 'ニ': "ni", 'ヌ': "nu", 'ネ': "ne", 'ノ': "no",
+	'ハ': "ha", 'ヒ': "hi", 'フ': "fu", 'ヘ': "he", 'ホ': "ho",
+	'バ': "ba", 'ビ': "bi", 'ブ': "bu", 'ベ': "be", 'ボ': "bo",
+	'パ': "pa", 'ピ': "pi", 'プ': "pu", 'ペ': "pe", 'ポ': "po",
+	'マ': "ma", 'ミ': "mi", 'ム': "mu", 'メ': "me", 'モ': "mo",
+	'ヤ': "ya", 'ユ': "yu", 'ヨ': "yo",
+	'ラ': "ra", 'リ': "ri", 'ル': "ru", 'レ': "re", 'ロ': "ro",
+	'ワ': "wa", 'ヰ': "i", 'ヱ': "e", 'ヲ': "o", 'ン': "n",
+	'ヴ': "vu",
+	'ァ': "a", 'ィ': "i", 'ゥ': "u", 'ェ': "e", 'ォ': "o",
+	'ャ': "ya", 'ュ': "yu", 'ョ': "yo", 'ヮ': "wa", 'ヵ': "ka", 'ヶ': "ke",
+}
+
+// kanaDigraphs relaciona as silabas escritas com dois katakana ao seu romaji
+// no padrao Hepburn
+var kanaDigraphs = map[string]string{
+	"キャ": "kya", "キュ": "kyu", "キョ": "kyo",
+	"ギャ": "gya", "ギュ": "gyu", "ギョ": "gyo",
+	"シャ": "sha", "シュ": "shu", "ショ": "sho", "シェ": "she",
+	"ジャ": "ja", "ジュ": "ju", "ジョ": "jo", "ジェ": "je",
+	"チャ": "cha", "チュ": "chu", "チョ": "cho", "チェ": "che",
+	"ヂャ": "ja", "ヂュ": "ju", "ヂョ": "jo",
+	"ニャ": "nya", "ニュ": "nyu", "ニョ": "nyo",
+	"ヒャ": "hya", "ヒュ": "hyu", "ヒョ": "hyo",
+	"ビャ": "bya", "ビュ": "byu", "ビョ": "byo",
+	"ピャ": "pya", "ピュ": "pyu", "ピョ": "pyo",
+	"ミャ": "mya", "ミュ": "myu", "ミョ": "myo",
+	"リャ": "rya", "リュ": "ryu", "リョ": "ryo",
+	"ファ": "fa", "フィ": "fi", "フェ": "fe", "フォ": "fo", "フュ": "fyu",
+	"ヴァ": "va", "ヴィ": "vi", "ヴェ": "ve", "ヴォ": "vo",
+	"ティ": "ti", "ディ": "di", "トゥ": "tu", "ドゥ": "du", "テュ": "tyu", "デュ": "dyu",
+	"ウィ": "wi", "ウェ": "we", "ウォ": "wo",
+	"ツァ": "tsa", "ツィ": "tsi", "ツェ": "tse", "ツォ": "tso",
+	"イェ": "ye", "クァ": "kwa", "グァ": "gwa",
+}
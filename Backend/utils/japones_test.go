@@ -0,0 +1,44 @@
+// Testes da conversao de katakana e hiragana para romaji no padrao Hepburn
+package utils
+
+import "testing"
+
+func TestToRomaji(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"フシギダネ", "fushigidane"},
+		{"ゼニガメ", "zenigame"},
+		// 'ー' vira o macron da vogal anterior
+		{"リザードン", "rizādon"},
+		{"ニャース", "nyāsu"},
+		{"ファイヤー", "faiyā"},
+		// Silabas de dois kana
+		{"ピカチュウ", "pikachuu"},
+		{"シャワーズ", "shawāzu"},
+		// 'ッ' dobra a consoante seguinte, com "tch" antes de "ch"
+		{"ッチ", "tchi"},
+		{"カッコウ", "kakkou"},
+		{"マッチャ", "matcha"},
+		// 'ン' seguido de apostrofo antes de vogais e de 'y'
+		{"シンオウ", "shin'ou"},
+		{"コンヤ", "kon'ya"},
+		{"ンア", "n'a"},
+		{"ホウオウ", "houou"},
+		{"ポケモン", "pokemon"},
+		// Hiragana e texto misturado
+		{"ふしぎだね", "fushigidane"},
+		{"ぴかちゅう", "pikachuu"},
+		{"しんおう", "shin'ou"},
+		{"まっちゃ", "matcha"},
+		{"ポケモン GO", "pokemon GO"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := ToRomaji(test.text); got != test.expected {
+			t.Errorf("ToRomaji(%q) = %q, expected %q", test.text, got, test.expected)
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Atoi32 converte uma string em um int32 e retorna o resultado
@@ -103,6 +104,26 @@ func BytesToString(registro []byte, ptr int) (string, int) {
 	return strings.TrimSpace(string(nomeBytes)), ptr + size
 }
 
+// BytesToStringMap retorna um mapa de strings de tamanho variavel, gravado como
+// pares de chave e valor de tamanho variavel, e avança o ponteiro ptr. Um mapa
+// vazio é retornado como nil
+func BytesToStringMap(registro []byte, ptr int) (map[string]string, int) {
+	size, ptr := BytesToVarSize(registro, ptr)
+	end := ptr + size
+
+	var m map[string]string
+	for ptr < end {
+		var key, value string
+		key, ptr = BytesToString(registro, ptr)
+		value, ptr = BytesToString(registro, ptr)
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[key] = value
+	}
+	return m, end
+}
+
 // BytesToFixedSizeString retorna uma string de tamanho fixo e avança o ponteiro ptr
 func BytesToFixedSizeString(registro []byte, ptr int, maxSize int) (string, int) {
 	nome := make([]byte, maxSize)
@@ -207,6 +228,34 @@ func Decaptalize(str string) string {
 	return strings.ToLower(str[0:1]) + str[1:]
 }
 
+// FoldRunes converte uma string em um slice de runas com as letras
+// normalizadas para a comparacao sem diferenciar maiusculas e minusculas.
+//
+// Cada runa é trocada pela menor runa da sua orbita de case folding
+// (unicode.SimpleFold), o que funciona tambem fora do ASCII: 'É' e 'é',
+// 'Σ', 'σ' e 'ς', ou 'K' e o simbolo de Kelvin resultam na mesma runa.
+// A quantidade de runas é sempre a mesma do texto original, de forma que
+// as posicoes encontradas no slice valem tambem para o texto
+func FoldRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = FoldRune(r)
+	}
+	return runes
+}
+
+// FoldRune retorna a menor runa da orbita de case folding de uma runa
+// (ver FoldRunes)
+func FoldRune(r rune) rune {
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+	return folded
+}
+
 // BoolToFloat converte um valor booleano para um número de ponto
 // flutuante (float64). Retorna 1.0 se o booleano for true e 0.0
 // se for false.
@@ -311,6 +360,15 @@ func ChangeExtension(filePath, newExtension string) string {
 	return newFilePath
 }
 
+// FileSize retorna o tamanho em bytes de um arquivo, ou 0 se ele nao existir
+func FileSize(filePath string) int64 {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
 // ByteArrayToAscii converte um array de bytes de tamanho 10 em uma string contendo
 // a representação ASCII de cada byte, separados por espaço.
 func ByteArrayToAscii(b [10]byte) string {
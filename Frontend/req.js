@@ -114,10 +114,17 @@ const indexMethod = {
     1: "Hashing",
     2: "Arvore B",
     3: "ArvoreB+",
-    4: "ArvoreB*",
+    4: "Hash Linear",
     5: "Indice Inv.",
     6: "KMP",
     7: "RabinKarp",
+    8: "Fuzzy",
+    9: "Boyer-Moore",
+    10: "Horspool",
+    11: "Aho-Corasick",
+    12: "Regex",
+    13: "Suffix Array",
+    14: "Bitap",
 };
 
 showAll.onclick = () => {
@@ -177,18 +184,50 @@ function paginarIds(ids) {
     return object;
 }
 
-function recuperarCards(pos) {
-    const idList = JSON.parse(sessionStorage.getItem('idList'));
-    let searchMethod = JSON.parse(sessionStorage.getItem('searchMethod'));
+function paginarBusca(data, request) {
+    const object = {
+        pages: Math.ceil(data.total / data.limit),
+        groups: [data.ids],
+        request
+    };
 
-    const ids = idList.groups[pos];
-    fetch('http://localhost:8080/getList/?method=' + searchMethod, {
+    sessionStorage.setItem('idList', JSON.stringify(object));
+
+    return object;
+}
+
+function recuperarIds(idList, pos) {
+    if (idList.groups[pos] || !idList.request) {
+        return Promise.resolve(idList.groups[pos]);
+    }
+
+    const request = { ...idList.request, offset: pos * idList.request.limit };
+    return fetch('http://localhost:8080/mergeSearch/', {
         method: 'POST',
         headers: {
             'Content-Type': 'application/json'
         },
-        body: JSON.stringify(ids)
+        body: JSON.stringify(request)
     })
+        .then(response => response.json())
+        .then(data => {
+            sessionStorage.setItem('duracao', JSON.stringify(data.time));
+            return data.ids;
+        });
+}
+
+function recuperarCards(pos) {
+    const idList = JSON.parse(sessionStorage.getItem('idList'));
+    let searchMethod = JSON.parse(sessionStorage.getItem('searchMethod'));
+
+    recuperarIds(idList, pos)
+        .then(ids => fetch('http://localhost:8080/getList/?method=' + searchMethod, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(ids)
+        }))
         .then(response => response.json())
         .then(data => {
             adicionarCards(data.pokemons);
@@ -712,43 +751,46 @@ search.addEventListener('click', function (event) {
                 patternMatch = "0";
             }
 
+            const request = {
+                nome: nome,
+                especie: especie,
+                tipo: tipo,
+                descricao: descricao,
+                japName: japName,
+                idI: idI,
+                idF: idF,
+                hpI: hpI,
+                hpF: hpF,
+                atkI: atkI,
+                atkF: atkF,
+                defI: defI,
+                defF: defF,
+                pesoI: pesoI,
+                pesoF: pesoF,
+                alturaI: alturaI,
+                alturaF: alturaF,
+                geracaoI: geracaoI,
+                geracaoF: geracaoF,
+                LancamentoI: LancamentoI,
+                LancamentoF: LancamentoF,
+                Lendario: lendario,
+                Mitico: mitico,
+                patternMatch: patternMatch,
+                limit: 60,
+            };
+
             fetch('http://localhost:8080/mergeSearch/', {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json'
                 },
-                body: JSON.stringify({
-                    nome: nome,
-                    especie: especie,
-                    tipo: tipo,
-                    descricao: descricao,
-                    japName: japName,
-                    idI: idI,
-                    idF: idF,
-                    hpI: hpI,
-                    hpF: hpF,
-                    atkI: atkI,
-                    atkF: atkF,
-                    defI: defI,
-                    defF: defF,
-                    pesoI: pesoI,
-                    pesoF: pesoF,
-                    alturaI: alturaI,
-                    alturaF: alturaF,
-                    geracaoI: geracaoI,
-                    geracaoF: geracaoF,
-                    LancamentoI: LancamentoI,
-                    LancamentoF: LancamentoF,
-                    Lendario: lendario,
-                    Mitico: mitico,
-                    patternMatch: patternMatch,
-                })
+                body: JSON.stringify(request)
             })
                 .then(response => response.json())
                 .then(data => {
                     cardsFatherDiv.style.position = fatherDivPosition;
                     modalContainer2.classList.add('out');
-                    paginarIds(data.ids);
+                    paginarBusca(data, request);
                     sessionStorage.setItem('duracao', JSON.stringify(data.time));
                     lastClicked = 1;
                     insertDots = true;
@@ -906,7 +948,7 @@ ordenar2.onclick = () => {
 const index = document.querySelector('#Index');
 const indexDropdown = document.querySelector('#indexDropdown');
 const indexButtons = document.querySelectorAll('.index-buttons');
-const indexChoice = document.querySelectorAll('#Index0, #Index1, #Index2, #Index3');
+const indexChoice = document.querySelectorAll('#Index0, #Index1, #Index2, #Index3, #Index4');
 const indexTransition = index.style.transition;
 const indexVar3 = index.style.paddingTop;
 let indexAberto = false;
@@ -915,9 +957,9 @@ index.addEventListener('click', function (event) {
     if (event.target === index && !indexAberto) {
         index.style.transition = "all 0.4s ease-in-out";
         indexDropdown.style.transition = "all 0.4s ease-in-out";
-        indexDropdown.style.height = "280px";
+        indexDropdown.style.height = "340px";
         indexDropdown.style.marginBottom = "15px";
-        index.style.height = "280px";
+        index.style.height = "340px";
         index.style.paddingTop = "15px";
         indexAberto = true;
         window.setTimeout(() => {
@@ -936,6 +978,10 @@ index.addEventListener('click', function (event) {
             indexButtons[3].style.pointerEvents = 'auto';
             indexButtons[3].style.opacity = "1";
         }, 300);
+        window.setTimeout(() => {
+            indexButtons[4].style.pointerEvents = 'auto';
+            indexButtons[4].style.opacity = "1";
+        }, 375);
     } else if (event.target === index) {
         setTimeout(() => {
             indexDropdown.style.height = 60 + "px";
@@ -952,6 +998,10 @@ index.addEventListener('click', function (event) {
                 index.style.transition = indexTransition;
             }, 500);
         }, 200);
+        window.setTimeout(() => {
+            indexButtons[4].style.pointerEvents = 'auto';
+            indexButtons[4].style.opacity = "0";
+        }, 0);
         window.setTimeout(() => {
             indexButtons[3].style.pointerEvents = 'auto';
             indexButtons[3].style.opacity = "0";
@@ -1139,7 +1189,7 @@ zip1.onclick = () => {
 const casamento = document.querySelector('#Casamento');
 const casamentoDropdown = document.querySelector('#casamentoDropdown');
 const casamentoButtons = document.querySelectorAll('.casamento-buttons');
-const casamentoChoice = document.querySelectorAll('#Casamento0, #Casamento1, #Casamento2');
+const casamentoChoice = document.querySelectorAll('#Casamento0, #Casamento1, #Casamento2, #Casamento3, #Casamento4, #Casamento5, #Casamento6, #Casamento7, #Casamento8, #Casamento9');
 const casamentoTransition = casamento.style.transition;
 const casamentoVar3 = casamento.style.paddingTop;
 let casamentoAberto = false;
@@ -1148,9 +1198,9 @@ casamento.addEventListener('click', function (event) {
     if (event.target === casamento && !casamentoAberto) {
         casamento.style.transition = "all 0.4s ease-in-out";
         casamentoDropdown.style.transition = "all 0.4s ease-in-out";
-        casamentoDropdown.style.height = "225px";
+        casamentoDropdown.style.height = "610px";
         casamentoDropdown.style.marginBottom = "15px";
-        casamento.style.height = "225px";
+        casamento.style.height = "610px";
         casamento.style.paddingTop = "15px";
         casamentoAberto = true;
         window.setTimeout(() => {
@@ -1165,6 +1215,34 @@ casamento.addEventListener('click', function (event) {
             casamentoButtons[2].style.pointerEvents = 'auto';
             casamentoButtons[2].style.opacity = "1";
         }, 300);
+        window.setTimeout(() => {
+            casamentoButtons[3].style.pointerEvents = 'auto';
+            casamentoButtons[3].style.opacity = "1";
+        }, 400);
+        window.setTimeout(() => {
+            casamentoButtons[4].style.pointerEvents = 'auto';
+            casamentoButtons[4].style.opacity = "1";
+        }, 500);
+        window.setTimeout(() => {
+            casamentoButtons[5].style.pointerEvents = 'auto';
+            casamentoButtons[5].style.opacity = "1";
+        }, 600);
+        window.setTimeout(() => {
+            casamentoButtons[6].style.pointerEvents = 'auto';
+            casamentoButtons[6].style.opacity = "1";
+        }, 700);
+        window.setTimeout(() => {
+            casamentoButtons[7].style.pointerEvents = 'auto';
+            casamentoButtons[7].style.opacity = "1";
+        }, 800);
+        window.setTimeout(() => {
+            casamentoButtons[8].style.pointerEvents = 'auto';
+            casamentoButtons[8].style.opacity = "1";
+        }, 900);
+        window.setTimeout(() => {
+            casamentoButtons[9].style.pointerEvents = 'auto';
+            casamentoButtons[9].style.opacity = "1";
+        }, 1000);
     } else if (event.target === casamento) {
         setTimeout(() => {
             casamentoDropdown.style.height = 60 + "px";
@@ -1181,18 +1259,46 @@ casamento.addEventListener('click', function (event) {
                 casamento.style.transition = casamentoTransition;
             }, 500);
         }, 200);
+        window.setTimeout(() => {
+            casamentoButtons[9].style.pointerEvents = 'auto';
+            casamentoButtons[9].style.opacity = "0";
+        }, 0);
+        window.setTimeout(() => {
+            casamentoButtons[8].style.pointerEvents = 'auto';
+            casamentoButtons[8].style.opacity = "0";
+        }, 100);
+        window.setTimeout(() => {
+            casamentoButtons[7].style.pointerEvents = 'auto';
+            casamentoButtons[7].style.opacity = "0";
+        }, 200);
+        window.setTimeout(() => {
+            casamentoButtons[6].style.pointerEvents = 'auto';
+            casamentoButtons[6].style.opacity = "0";
+        }, 300);
+        window.setTimeout(() => {
+            casamentoButtons[5].style.pointerEvents = 'auto';
+            casamentoButtons[5].style.opacity = "0";
+        }, 400);
+        window.setTimeout(() => {
+            casamentoButtons[4].style.pointerEvents = 'auto';
+            casamentoButtons[4].style.opacity = "0";
+        }, 500);
+        window.setTimeout(() => {
+            casamentoButtons[3].style.pointerEvents = 'auto';
+            casamentoButtons[3].style.opacity = "0";
+        }, 600);
         window.setTimeout(() => {
             casamentoButtons[2].style.pointerEvents = 'auto';
             casamentoButtons[2].style.opacity = "0";
-        }, 0);
+        }, 700);
         window.setTimeout(() => {
             casamentoButtons[1].style.pointerEvents = 'auto';
             casamentoButtons[1].style.opacity = "0";
-        }, 100);
+        }, 800);
         window.setTimeout(() => {
             casamentoButtons[0].style.pointerEvents = 'auto';
             casamentoButtons[0].style.opacity = "0";
-        }, 200);
+        }, 900);
     }
 })
 
@@ -1776,13 +1882,13 @@ const collectFormData = async () => {
     const pokeMitic = document.getElementById('mitico');
     const pokeDescription = document.getElementById('descricao-pokemon');
 
-    const japName = await fetch(`http://localhost:8080/toKatakana/?stringToConvert=${pokeNameJap.value}`);
+    const japName = await fetch(`http://localhost:8080/transliterate?text=${encodeURIComponent(pokeNameJap.value)}`);
     let number = pokeNumber.innerText;
     number = number == '' ? 1000 : +number.substring(1);
 
     pokemon.numero = number;
     pokemon.nome = pokeName.value;
-    pokemon.nomeJap = await japName.json();
+    pokemon.nomeJap = (await japName.json()).katakana;
     pokemon.especie = pokeEspecies.value;
     pokemon.tipo = [pokeType1.value, pokeType2.value];
     pokemon.peso = +pokeWeight.value;